@@ -1,29 +1,91 @@
 package app
 
 import (
+	"fashion-api/attribute/attribute_handler"
+	"fashion-api/attribute/attribute_repo/attribute_pg"
+	"fashion-api/attribute/attribute_service"
+
 	"fashion-api/category/category_handler"
 	"fashion-api/category/category_repo/category_pg"
 	"fashion-api/category/category_service"
 
+	"fashion-api/currency/currency_handler"
+	"fashion-api/currency/currency_repo/currency_pg"
+	"fashion-api/currency/currency_service"
+
 	"fashion-api/infra/config"
 	"fashion-api/infra/db"
 
+	"fashion-api/inventory/inventory_allocator"
+	"fashion-api/inventory/inventory_handler"
+	"fashion-api/inventory/inventory_repo/inventory_pg"
+	"fashion-api/inventory/inventory_service"
+
+	"fashion-api/invoice/invoice_handler"
+	"fashion-api/invoice/invoice_repo/invoice_pg"
+	"fashion-api/invoice/invoice_service"
 	"fashion-api/order/order_handler"
 	"fashion-api/order/order_repo/order_pg"
 	"fashion-api/order/order_service"
 
+	"fashion-api/payment/payment_handler"
+	"fashion-api/payment/payment_provider"
+	"fashion-api/payment/payment_repo/payment_pg"
+	"fashion-api/payment/payment_service"
+
 	"fashion-api/product/product_handler"
+	"fashion-api/product/product_job"
 	"fashion-api/product/product_repo/product_pg"
 	"fashion-api/product/product_service"
 
+	"fashion-api/promotion/promotion_handler"
+	"fashion-api/promotion/promotion_repo/promotion_pg"
+	"fashion-api/promotion/promotion_service"
+
+	"fashion-api/report/report_handler"
+	"fashion-api/report/report_job"
+	"fashion-api/report/report_repo/report_pg"
+	"fashion-api/report/report_service"
+
+	"fashion-api/review/review_handler"
+	"fashion-api/review/review_repo/review_pg"
+	"fashion-api/review/review_service"
+
+	"fashion-api/rma/rma_handler"
+	"fashion-api/rma/rma_repo/rma_pg"
+	"fashion-api/rma/rma_service"
+
+	"fashion-api/shipment/shipment_handler"
+	"fashion-api/shipment/shipment_repo/shipment_pg"
+	"fashion-api/shipment/shipment_service"
+
+	"fashion-api/shipping/shipping_handler"
+	"fashion-api/shipping/shipping_repo/shipping_pg"
+	"fashion-api/shipping/shipping_service"
+
+	"fashion-api/tax/tax_calculator"
+	"fashion-api/tax/tax_handler"
+	"fashion-api/tax/tax_repo/tax_pg"
+	"fashion-api/tax/tax_service"
+
 	"fashion-api/transaction/transaction_handler"
 	"fashion-api/transaction/transaction_repo/transaction_pg"
 	"fashion-api/transaction/transaction_service"
 
+	"fashion-api/notification/notification_job"
+	"fashion-api/notification/notification_repo/notification_pg"
+	"fashion-api/notification/notification_template"
+	"fashion-api/notification/notifier"
+
 	"fashion-api/user/user_handler"
 	"fashion-api/user/user_repo/user_pg"
 	"fashion-api/user/user_service"
 
+	"fashion-api/wishlist/wishlist_handler"
+	"fashion-api/wishlist/wishlist_job"
+	"fashion-api/wishlist/wishlist_repo/wishlist_pg"
+	"fashion-api/wishlist/wishlist_service"
+
 	"log"
 	"net/http"
 	"sync"
@@ -57,40 +119,136 @@ func StartApplication() {
 			http.MethodPut,
 			http.MethodOptions,
 		},
-		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		AllowedHeaders: []string{"Authorization", "Content-Type", "X-Currency"},
 	}))
 
 	// dependency injection
+	appConfig := config.NewAppConfig()
+
+	nt := notifier.NewNotifier(appConfig.Notifier, &notifier.Settings{
+		From:         appConfig.MailFrom,
+		OutboxDir:    appConfig.NotifierOutboxDir,
+		SMTPHost:     appConfig.SMTPHost,
+		SMTPPort:     appConfig.SMTPPort,
+		SMTPUsername: appConfig.SMTPUsername,
+		SMTPPassword: appConfig.SMTPPassword,
+	})
+
+	ntt, err := notification_template.NewTemplates(appConfig.DefaultLocale)
+
+	if err != nil {
+		log.Fatal("error occured while parse notification templates : ", err.Error())
+	}
+
+	ntr := notification_pg.NewNotificationPg(pg)
+	nj := notification_job.NewNotificationJob(ntr, ntt, nt, appConfig.SellerName, appConfig.StorefrontURL, appConfig.NotificationRetry)
+
+	go nj.Run()
+
 	ur := user_pg.NewUserPg(pg)
-	us := user_service.NewUserService(ur, wg)
+	us := user_service.NewUserService(ur, wg, nj)
 	uh := user_handler.NewUserHandler(us)
 
 	cr := category_pg.NewCategoryPg(pg)
-	cs := category_service.NewCategoryService(cr)
-	ch := category_handler.NewCategoryHandler(cs)
-
 	pr := product_pg.NewProductPg(pg)
-	ps := product_service.NewProductService(pr, cr, wg)
+
+	cur := currency_pg.NewCurrencyPg(pg)
+	cus := currency_service.NewCurrencyService(cur, pr)
+	cuh := currency_handler.NewCurrencyHandler(cus)
+
+	ar := attribute_pg.NewAttributePg(pg)
+	as := attribute_service.NewAttributeService(ar)
+	ah := attribute_handler.NewAttributeHandler(as)
+
+	wr := wishlist_pg.NewWishlistPg(pg)
+	ws := wishlist_service.NewWishlistService(wr, pr, cus)
+	wh := wishlist_handler.NewWishlistHandler(ws)
+	bj := wishlist_job.NewBackInStockJob(wr, pr, nj)
+
+	go bj.Run()
+
+	ij := product_job.NewStaleImportJob(pr)
+
+	go ij.Run()
+
+	ps := product_service.NewProductService(pr, cr, cus, ar, bj, wg)
 	ph := product_handler.NewProductHandler(ps)
 
+	ir := inventory_pg.NewInventoryPg(pg)
+	is := inventory_service.NewInventoryService(ir, pr)
+	ih := inventory_handler.NewInventoryHandler(is)
+	al := inventory_allocator.NewAllocator(config.NewAppConfig().StockAllocation)
+
+	rpr := report_pg.NewReportPg(pg)
+	rps := report_service.NewReportService(rpr)
+	rph := report_handler.NewReportHandler(rps)
+	lj := report_job.NewLowStockJob(rpr, nj, config.NewAppConfig().LowStockCheckInterval)
+
+	go lj.Run()
+
+	rvr := review_pg.NewReviewPg(pg)
+	rvs := review_service.NewReviewService(rvr, pr)
+	rvh := review_handler.NewReviewHandler(rvs)
+
+	cs := category_service.NewCategoryService(cr, ps)
+	ch := category_handler.NewCategoryHandler(cs)
+
+	pmr := promotion_pg.NewPromotionPg(pg)
+	pms := promotion_service.NewPromotionService(pmr, cr, pr, cus)
+	pmh := promotion_handler.NewPromotionHandler(pms)
+
+	pp := payment_provider.NewPaymentProvider(config.NewAppConfig().PaymentProvider, config.NewAppConfig().PaymentWebhookSecret)
+
+	pyr := payment_pg.NewPaymentPg(pg)
+	ivr := invoice_pg.NewInvoicePg(pg)
+	ivs := invoice_service.NewInvoiceService(ivr)
+	ivh := invoice_handler.NewInvoiceHandler(ivs)
+
+	pys := payment_service.NewPaymentService(pyr, pp, nj, ivs)
+	pyh := payment_handler.NewPaymentHandler(pys)
+
+	txr := tax_pg.NewTaxPg(pg)
+	txs := tax_service.NewTaxService(txr)
+	txh := tax_handler.NewTaxHandler(txs)
+	tc := tax_calculator.NewTaxCalculator(config.NewAppConfig().TaxCalculator, txr)
+
+	sr := shipping_pg.NewShippingPg(pg)
+	ss := shipping_service.NewShippingService(sr, pr, cus)
+	sh := shipping_handler.NewShippingHandler(ss)
+
 	or := order_pg.NewOrderPg(pg)
-	os := order_service.NewOrderService(or, pr)
+	tr := transaction_pg.NewTransactionPg(pg)
+
+	shr := shipment_pg.NewShipmentPg(pg)
+
+	os := order_service.NewOrderService(or, pr, tr, pys, cus, bj, shr)
 	oh := order_handler.NewOrderHandler(os)
 
-	tr := transaction_pg.NewTransactionPg(pg)
-	ts := transaction_service.NewTransactionService(tr, or)
+	ts := transaction_service.NewTransactionService(tr, or, pr, pms, pp, cus, tc, ss, ir, al, nj)
 	th := transaction_handler.NewTransactionHandler(ts)
 
+	shs := shipment_service.NewShipmentService(shr, or, nj)
+	shh := shipment_handler.NewShipmentHandler(shs)
+
+	rr := rma_pg.NewRmaPg(pg)
+	rs := rma_service.NewRmaService(rr, or, tr, pys, bj)
+	rh := rma_handler.NewRmaHandler(rs)
+
 	// user routes
 	r.Group(func(r chi.Router) {
 		r.Post("/user/signup", uh.SignUp)
 		r.Post("/user/signin", uh.SignIn)
+		r.Post("/user/forgot-password", uh.ForgotPassword)
+		r.Post("/user/reset-password", uh.ResetPassword)
 
 		r.Group(func(r chi.Router) {
 			r.Use(us.Authentication)
 			r.Get("/user", uh.Profile)
 			r.Patch("/user", uh.Modify)
 			r.Patch("/user/change-password", uh.ChangePassword)
+			r.Get("/user/wishlist", wh.Fetch)
+			r.Post("/user/wishlist", wh.Add)
+			r.Delete("/user/wishlist/{productId}", wh.Remove)
 		})
 	})
 
@@ -98,18 +256,84 @@ func StartApplication() {
 	r.Group(func(r chi.Router) {
 		r.Get("/products", ph.Fetch)
 		r.Get("/products/{id}", ph.FetchById)
+		r.Get("/products/slug/{slug}", ph.FetchBySlug)
 
 		r.Group(func(r chi.Router) {
 			r.Use(us.Authentication, us.Authorization)
 			r.Post("/products", ph.Add)
 			r.Delete("/products/{id}", ph.Delete)
 			r.Patch("/products/{id}", ph.Modify)
+			r.Get("/products/{id}/price-history", ph.PriceHistory)
+			r.Get("/admin/products/{id}/stock-movements", ph.StockMovements)
+			r.Post("/admin/products/{id}/stock-adjustments", ph.AdjustStock)
+			r.Post("/admin/products/import", ph.Import)
+			r.Get("/admin/products/imports/{id}", ph.FetchImport)
+			r.Get("/admin/products/export", ph.Export)
+		})
+	})
+
+	// back in stock routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication)
+		r.Post("/products/{id}/notify-me", wh.Subscribe)
+		r.Delete("/products/{id}/notify-me", wh.Unsubscribe)
+	})
+
+	// review routes
+	r.Group(func(r chi.Router) {
+		r.Get("/products/{id}/reviews", rvh.FetchByProductId)
+
+		r.Group(func(r chi.Router) {
+			r.Use(us.Authentication)
+			r.Post("/products/{id}/reviews", rvh.Add)
 		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(us.Authentication, us.Authorization)
+			r.Get("/admin/reviews", rvh.Fetch)
+			r.Post("/admin/reviews/{id}/approve", rvh.Approve)
+			r.Post("/admin/reviews/{id}/hide", rvh.Hide)
+		})
+	})
+
+	// attribute routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication, us.Authorization)
+		r.Get("/admin/attributes", ah.Fetch)
+		r.Get("/admin/attributes/{id}", ah.FetchById)
+		r.Post("/admin/attributes", ah.Add)
+		r.Patch("/admin/attributes/{id}", ah.Modify)
+		r.Delete("/admin/attributes/{id}", ah.Delete)
+	})
+
+	// inventory routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication, us.Authorization)
+		r.Get("/admin/locations", ih.FetchLocations)
+		r.Get("/admin/locations/{id}", ih.FetchLocationById)
+		r.Post("/admin/locations", ih.AddLocation)
+		r.Patch("/admin/locations/{id}", ih.ModifyLocation)
+		r.Get("/admin/products/{id}/stock-levels", ih.FetchStockLevels)
+		r.Get("/admin/stock-transfers", ih.FetchTransfers)
+		r.Post("/admin/stock-transfers", ih.Transfer)
+	})
+
+	// report routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication, us.Authorization)
+		r.Get("/admin/reports/low-stock", rph.FetchLowStock)
+		r.Get("/admin/reports/sales", rph.FetchSales)
+		r.Get("/admin/reports/top-products", rph.FetchTopProducts)
+		r.Get("/admin/reports/top-categories", rph.FetchTopCategories)
+		r.Get("/admin/reports/customers", rph.FetchCustomers)
+		r.Get("/admin/reports/conversion", rph.FetchConversion)
 	})
 
 	// category routes
 	r.Group(func(r chi.Router) {
 		r.Get("/category", ch.Fetch)
+		r.Get("/category/tree", ch.FetchTree)
+		r.Get("/category/slug/{slug}", ch.FetchBySlug)
 		r.Get("/category/{id}", ch.FetchById)
 
 		r.Group(func(r chi.Router) {
@@ -126,11 +350,19 @@ func StartApplication() {
 		r.Use(us.Authentication)
 		r.Post("/orders", oh.Add)
 		r.Get("/orders", oh.Fetch)
+		r.Post("/orders/{id}/cancel", oh.Cancel)
 
 		r.Group(func(r chi.Router) {
 			r.Use(us.Authentication, os.Authorization)
+			r.Get("/orders/{id}", oh.FetchDetail)
 			r.Patch("/orders/{id}", oh.Modify)
 			r.Delete("/orders/{id}", oh.Remove)
+			r.Get("/orders/{id}/shipments", shh.FetchByOrderId)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(us.Authorization)
+			r.Get("/admin/orders/{id}", oh.FetchDetail)
 		})
 	})
 
@@ -143,11 +375,97 @@ func StartApplication() {
 		r.Group(func(r chi.Router) {
 			r.Use(ts.Authorization)
 			r.Get("/transaction/{id}", th.FetchTransactionById)
+			r.Get("/transaction/{id}/invoice", ivh.FetchInvoice)
+			r.Get("/transaction/{id}/credit-notes", ivh.FetchDocuments)
+			r.Get("/transaction/{id}/credit-notes/{creditNoteId}", ivh.FetchCreditNote)
 		})
 
 		r.Group(func(r chi.Router) {
 			r.Use(us.Authorization)
 			r.Get("/admin/transaction", th.FetchAllTransaction)
+			r.Get("/admin/transaction/export", th.Export)
+			r.Get("/admin/transaction/{id}/invoice", ivh.FetchInvoice)
+			r.Get("/admin/transaction/{id}/credit-notes", ivh.FetchDocuments)
+			r.Get("/admin/transaction/{id}/credit-notes/{creditNoteId}", ivh.FetchCreditNote)
+			r.Post("/admin/transaction/{id}/credit-notes", ivh.IssueCreditNote)
+		})
+	})
+
+	// payment routes
+	r.Group(func(r chi.Router) {
+		r.Post("/payments/webhook", pyh.Webhook)
+	})
+
+	// return routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication)
+		r.Post("/returns", rh.Add)
+		r.Get("/returns", rh.CustomersReturn)
+
+		r.Group(func(r chi.Router) {
+			r.Use(us.Authorization)
+			r.Get("/admin/returns", rh.Fetch)
+			r.Post("/admin/returns/{id}/approve", rh.Approve)
+			r.Post("/admin/returns/{id}/reject", rh.Reject)
+			r.Post("/admin/returns/{id}/receive", rh.Receive)
+		})
+	})
+
+	// promotion routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authorization)
+		r.Get("/admin/promotions", pmh.Fetch)
+		r.Get("/admin/promotions/{id}", pmh.FetchById)
+		r.Post("/admin/promotions", pmh.Add)
+		r.Patch("/admin/promotions/{id}", pmh.Modify)
+		r.Delete("/admin/promotions/{id}", pmh.Delete)
+	})
+
+	// currency routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication, us.Authorization)
+		r.Get("/admin/exchange-rates", cuh.FetchExchangeRates)
+		r.Put("/admin/exchange-rates/{currency}", cuh.SaveExchangeRate)
+		r.Delete("/admin/exchange-rates/{currency}", cuh.DeleteExchangeRate)
+		r.Get("/admin/products/{id}/prices", cuh.FetchProductPrices)
+		r.Put("/admin/products/{id}/prices/{currency}", cuh.SaveProductPrice)
+		r.Delete("/admin/products/{id}/prices/{currency}", cuh.DeleteProductPrice)
+	})
+
+	// shipment routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication, us.Authorization)
+		r.Get("/admin/orders/{id}/shipments", shh.FetchByOrderId)
+		r.Post("/admin/orders/{id}/shipments", shh.Add)
+		r.Patch("/admin/shipments/{id}", shh.Modify)
+	})
+
+	// tax routes
+	r.Group(func(r chi.Router) {
+		r.Use(us.Authentication, us.Authorization)
+		r.Get("/admin/tax-rates", txh.Fetch)
+		r.Get("/admin/tax-rates/{id}", txh.FetchById)
+		r.Post("/admin/tax-rates", txh.Add)
+		r.Patch("/admin/tax-rates/{id}", txh.Modify)
+		r.Delete("/admin/tax-rates/{id}", txh.Delete)
+	})
+
+	// shipping routes
+	r.Group(func(r chi.Router) {
+		r.Post("/checkout/shipping-rates", sh.Rates)
+
+		r.Group(func(r chi.Router) {
+			r.Use(us.Authentication, us.Authorization)
+			r.Get("/admin/shipping-zones", sh.FetchZones)
+			r.Get("/admin/shipping-zones/{id}", sh.FetchZoneById)
+			r.Post("/admin/shipping-zones", sh.AddZone)
+			r.Patch("/admin/shipping-zones/{id}", sh.ModifyZone)
+			r.Delete("/admin/shipping-zones/{id}", sh.DeleteZone)
+			r.Get("/admin/shipping-methods", sh.FetchMethods)
+			r.Get("/admin/shipping-methods/{id}", sh.FetchMethodById)
+			r.Post("/admin/shipping-methods", sh.AddMethod)
+			r.Patch("/admin/shipping-methods/{id}", sh.ModifyMethod)
+			r.Delete("/admin/shipping-methods/{id}", sh.DeleteMethod)
 		})
 	})
 
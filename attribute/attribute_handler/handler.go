@@ -0,0 +1,152 @@
+package attribute_handler
+
+import (
+	"encoding/json"
+	"fashion-api/attribute/attribute_service"
+	"fashion-api/dto"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type attributeHandler struct {
+	as attribute_service.AttributeService
+}
+
+type AttributeHandler interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	Fetch(w http.ResponseWriter, r *http.Request)
+	FetchById(w http.ResponseWriter, r *http.Request)
+	Modify(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+}
+
+func NewAttributeHandler(as attribute_service.AttributeService) AttributeHandler {
+	return &attributeHandler{
+		as: as,
+	}
+}
+
+// Add implements AttributeHandler.
+func (ah *attributeHandler) Add(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	payload := &dto.AttributePayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ah.as.Add(payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Fetch implements AttributeHandler.
+func (ah *attributeHandler) Fetch(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	res, err := ah.as.Fetch()
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchById implements AttributeHandler.
+func (ah *attributeHandler) FetchById(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ah.as.FetchById(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Modify implements AttributeHandler.
+func (ah *attributeHandler) Modify(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	payload := &dto.AttributePayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ah.as.Modify(id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Delete implements AttributeHandler.
+func (ah *attributeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ah.as.Delete(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -0,0 +1,34 @@
+package attribute_pg
+
+import (
+	"fashion-api/entity"
+
+	"github.com/lib/pq"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAttribute(row scanner) (*entity.Attribute, error) {
+
+	attribute := entity.Attribute{}
+	options := pq.StringArray{}
+
+	if err := row.Scan(
+		&attribute.Id,
+		&attribute.Code,
+		&attribute.Name,
+		&attribute.Type,
+		&options,
+		&attribute.Filterable,
+		&attribute.CreatedAt,
+		&attribute.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	attribute.Options = []string(options)
+
+	return &attribute, nil
+}
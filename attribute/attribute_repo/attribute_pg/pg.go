@@ -0,0 +1,142 @@
+package attribute_pg
+
+import (
+	"database/sql"
+	"fashion-api/attribute/attribute_repo"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+type attributePg struct {
+	db *sql.DB
+}
+
+const (
+	addAttributeQuery = `insert into attribute (code, name, type, options, filterable) values ($1, $2, $3, $4, $5)`
+
+	fetchAttributesQuery = `select id, code, name, type, options, filterable, created_at, updated_at from attribute order by name asc`
+
+	fetchAttributeByIdQuery = `select id, code, name, type, options, filterable, created_at, updated_at from attribute where id = $1`
+
+	modifyAttributeQuery = `update attribute set code = $2, name = $3, type = $4, options = $5, filterable = $6, updated_at = now() where id = $1`
+
+	// values stored for the attribute go with it
+	deleteAttributeQuery = `delete from attribute where id = $1`
+
+	duplicateCodeError = `pq: duplicate key value violates unique constraint "attribute_code_key"`
+)
+
+func NewAttributePg(db *sql.DB) attribute_repo.AttributeRepo {
+	return &attributePg{
+		db: db,
+	}
+}
+
+// Add implements attribute_repo.AttributeRepo.
+func (pg *attributePg) Add(attribute *entity.Attribute) exception.Exception {
+
+	if _, err := pg.db.Exec(
+		addAttributeQuery,
+		attribute.Code,
+		attribute.Name,
+		attribute.Type,
+		pq.Array(attribute.Options),
+		attribute.Filterable,
+	); err != nil {
+		if err.Error() == duplicateCodeError {
+			log.Println(err.Error())
+			return exception.NewConflictError("attribute code is already in use")
+		}
+
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Fetch implements attribute_repo.AttributeRepo.
+func (pg *attributePg) Fetch() ([]*entity.Attribute, exception.Exception) {
+
+	attributes := []*entity.Attribute{}
+
+	rows, err := pg.db.Query(fetchAttributesQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		attribute, err := scanAttribute(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		attributes = append(attributes, attribute)
+	}
+
+	return attributes, nil
+}
+
+// FetchById implements attribute_repo.AttributeRepo.
+func (pg *attributePg) FetchById(id int) (*entity.Attribute, exception.Exception) {
+
+	attribute, err := scanAttribute(pg.db.QueryRow(fetchAttributeByIdQuery, id))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("attribute not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return attribute, nil
+}
+
+// Modify implements attribute_repo.AttributeRepo.
+func (pg *attributePg) Modify(id int, attribute *entity.Attribute) exception.Exception {
+
+	if _, err := pg.db.Exec(
+		modifyAttributeQuery,
+		id,
+		attribute.Code,
+		attribute.Name,
+		attribute.Type,
+		pq.Array(attribute.Options),
+		attribute.Filterable,
+	); err != nil {
+		if err.Error() == duplicateCodeError {
+			log.Println(err.Error())
+			return exception.NewConflictError("attribute code is already in use")
+		}
+
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Delete implements attribute_repo.AttributeRepo.
+func (pg *attributePg) Delete(id int) exception.Exception {
+
+	if _, err := pg.db.Exec(deleteAttributeQuery, id); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
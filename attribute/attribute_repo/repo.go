@@ -0,0 +1,14 @@
+package attribute_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type AttributeRepo interface {
+	Add(attribute *entity.Attribute) exception.Exception
+	Fetch() ([]*entity.Attribute, exception.Exception)
+	FetchById(id int) (*entity.Attribute, exception.Exception)
+	Modify(id int, attribute *entity.Attribute) exception.Exception
+	Delete(id int) exception.Exception
+}
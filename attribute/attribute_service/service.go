@@ -0,0 +1,171 @@
+package attribute_service
+
+import (
+	"fashion-api/attribute/attribute_repo"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// codes end up in query strings as attr.<code>
+var attributeCodePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type attributeService struct {
+	ar attribute_repo.AttributeRepo
+}
+
+type AttributeService interface {
+	Add(payload *dto.AttributePayload) (*helper.ResponseBody, exception.Exception)
+	Fetch() (*helper.ResponseBody, exception.Exception)
+	FetchById(id int) (*helper.ResponseBody, exception.Exception)
+	Modify(id int, payload *dto.AttributePayload) (*helper.ResponseBody, exception.Exception)
+	Delete(id int) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewAttributeService(ar attribute_repo.AttributeRepo) AttributeService {
+	return &attributeService{
+		ar: ar,
+	}
+}
+
+// Add implements AttributeService.
+func (as *attributeService) Add(payload *dto.AttributePayload) (*helper.ResponseBody, exception.Exception) {
+
+	attribute, err := payloadToAttribute(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := as.ar.Add(attribute); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "attribute successfully added",
+		Data:    nil,
+	}, nil
+}
+
+// Fetch implements AttributeService.
+func (as *attributeService) Fetch() (*helper.ResponseBody, exception.Exception) {
+
+	attributes, err := as.ar.Fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "attributes successfully fetched",
+		Data:    attributes,
+	}, nil
+}
+
+// FetchById implements AttributeService.
+func (as *attributeService) FetchById(id int) (*helper.ResponseBody, exception.Exception) {
+
+	attribute, err := as.ar.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "attribute successfully fetched",
+		Data:    attribute,
+	}, nil
+}
+
+// Modify implements AttributeService.
+func (as *attributeService) Modify(id int, payload *dto.AttributePayload) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := as.ar.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	attribute, err := payloadToAttribute(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := as.ar.Modify(id, attribute); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "attribute successfully modified",
+		Data:    nil,
+	}, nil
+}
+
+// Delete implements AttributeService.
+func (as *attributeService) Delete(id int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := as.ar.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	if err := as.ar.Delete(id); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "attribute successfully deleted",
+		Data:    nil,
+	}, nil
+}
+
+func payloadToAttribute(payload *dto.AttributePayload) (*entity.Attribute, exception.Exception) {
+
+	code := strings.ToLower(strings.TrimSpace(payload.Code))
+
+	if !attributeCodePattern.MatchString(code) {
+		return nil, exception.NewBadRequestError("Code may only contain lowercase letters, digits and underscores")
+	}
+
+	if code == entity.FacetCodeTags {
+		return nil, exception.NewBadRequestError("Code " + code + " is reserved")
+	}
+
+	options := []string{}
+	seen := map[string]bool{}
+
+	for _, option := range payload.Options {
+
+		option = strings.TrimSpace(option)
+
+		if option == "" || seen[strings.ToLower(option)] {
+			continue
+		}
+
+		seen[strings.ToLower(option)] = true
+		options = append(options, option)
+	}
+
+	if payload.Type == entity.AttributeTypeSelect && len(options) == 0 {
+		return nil, exception.NewBadRequestError("select attributes need at least one option")
+	}
+
+	if payload.Type != entity.AttributeTypeSelect && len(options) != 0 {
+		return nil, exception.NewBadRequestError("only select attributes have options")
+	}
+
+	return &entity.Attribute{
+		Code:       code,
+		Name:       strings.TrimSpace(payload.Name),
+		Type:       payload.Type,
+		Options:    options,
+		Filterable: payload.Filterable,
+	}, nil
+}
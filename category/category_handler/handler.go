@@ -25,6 +25,8 @@ type CategoryHandler interface {
 	Fetch(w http.ResponseWriter, r *http.Request)
 	FetchById(w http.ResponseWriter, r *http.Request)
 	Modify(w http.ResponseWriter, r *http.Request)
+	FetchTree(w http.ResponseWriter, r *http.Request)
+	FetchBySlug(w http.ResponseWriter, r *http.Request)
 }
 
 func NewCategoryHandler(cs category_service.CategoryService) CategoryHandler {
@@ -75,7 +77,9 @@ func (ch *categoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	id, _ := strconv.Atoi(path[2])
 
-	res, err := ch.cs.Delete(id)
+	cascade, _ := strconv.ParseBool(r.URL.Query().Get("cascade"))
+
+	res, err := ch.cs.Delete(id, cascade)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -113,7 +117,23 @@ func (ch *categoryHandler) FetchById(w http.ResponseWriter, r *http.Request) {
 
 	id, _ := strconv.Atoi(path[2])
 
-	res, err := ch.cs.FetchById(id)
+	currency, err := helper.RequestCurrency(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	query, err := helper.ProductQuery(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ch.cs.FetchById(id, currency, query)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -163,3 +183,55 @@ func (ch *categoryHandler) Modify(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(res.Status)
 	w.Write(helper.ResponseJSON(res))
 }
+
+// FetchTree implements CategoryHandler.
+func (ch *categoryHandler) FetchTree(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	res, err := ch.cs.FetchTree()
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchBySlug implements CategoryHandler.
+func (ch *categoryHandler) FetchBySlug(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	currency, err := helper.RequestCurrency(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	query, err := helper.ProductQuery(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ch.cs.FetchBySlug(chi.URLParam(r, "slug"), currency, query)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	helper.SetRedirectLocation(w, r, res)
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -14,17 +14,46 @@ type categoryPg struct {
 }
 
 const (
-	addCategoryQuery = `insert into "category" (type) values ($1)`
+	addCategoryQuery = `insert into "category" (type, parent_id, slug) values ($1, $2, $3)`
 
 	deleteCategoryQuery = `update "category" set updated_at = now(), deleted_at = now() where id = $1`
 
-	fetchCategoriesQuery = `select id, type, created_at, updated_at from "category" where deleted_at is null`
+	// subtreeQuery selects the ids of a category and all of its descendants
+	subtreeQuery = `with recursive subtree as (
+			select id from "category" where id = $1 and deleted_at is null
+			union all
+			select c.id from "category" as c join subtree as s on c.parent_id = s.id where c.deleted_at is null
+		)`
 
-	fetchCategoryByIdQuery = `select c.id, c.type, p.id, p.name, p.price, p.stock, p.sold, c.created_at, c.updated_at from "category" as c left join product as p on c.id = p.category_id where c.id = $1 and c.deleted_at is null`
+	deleteSubtreeProductsQuery = subtreeQuery + ` update product set updated_at = now(), deleted_at = now() where category_id in (select id from subtree) and deleted_at is null`
 
-	fetchIdCategoryQuery = `select id from category where id = $1`
+	deleteSubtreeQuery = subtreeQuery + ` update "category" set updated_at = now(), deleted_at = now() where id in (select id from subtree)`
 
-	modifyCategoryQuery = `update "category" set type = $2, updated_at = now() where id = $1`
+	fetchCategoriesQuery = `select id, parent_id, type, slug, created_at, updated_at from "category" where deleted_at is null order by id asc`
+
+	fetchCategoryByIdQuery = `select id, parent_id, type, slug, created_at, updated_at from "category" where id = $1 and deleted_at is null`
+
+	fetchCategoryBySlugQuery = `select id, parent_id, type, slug, created_at, updated_at from "category" where slug = $1 and deleted_at is null`
+
+	slugTakenQuery = `select exists (select 1 from "category" where slug = $1 and id <> $2)`
+
+	addSlugRedirectQuery = `insert into slug_redirect (entity, old_slug, entity_id) select 'category', slug, id from "category" where id = $1 and slug <> $2 on conflict (entity, old_slug) do update set entity_id = excluded.entity_id, created_at = now()`
+
+	releaseSlugRedirectQuery = `delete from slug_redirect where entity = 'category' and old_slug = $1`
+
+	fetchSlugRedirectQuery = `select c.slug from slug_redirect as r join "category" as c on r.entity_id = c.id where r.entity = 'category' and r.old_slug = $1 and c.deleted_at is null`
+
+	duplicateSlugError = `pq: duplicate key value violates unique constraint "category_slug_key"`
+
+	fetchIdCategoryQuery = `select id, parent_id from category where id = $1 and deleted_at is null`
+
+	fetchDescendantIdsQuery = subtreeQuery + ` select id from subtree where id <> $1`
+
+	countDependentsQuery = `select (select count(*) from "category" where parent_id = $1 and deleted_at is null), (select count(*) from product where category_id = $1 and deleted_at is null)`
+
+	modifyCategoryQuery = `update "category" set type = $2, parent_id = $3, slug = $4, updated_at = now() where id = $1`
+
+	duplicateCategoryError = `pq: duplicate key value violates unique constraint "category_parent_type_key"`
 )
 
 func NewCategoryPg(db *sql.DB) category_repo.CategoryRepo {
@@ -52,13 +81,19 @@ func (pg *categoryPg) Add(category *entity.Category) exception.Exception {
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(category.Type); err != nil {
-		if err.Error() == `pq: duplicate key value violates unique constraint "category_type_key"` {
+	if _, err := stmt.Exec(category.Type, category.ParentId, category.Slug); err != nil {
+		if err.Error() == duplicateCategoryError {
 			tx.Rollback()
 			log.Println(err.Error())
 			return exception.NewConflictError("type has been created")
 		}
 
+		if err.Error() == duplicateSlugError {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewConflictError("slug is already in use")
+		}
+
 		tx.Rollback()
 		log.Println(err.Error())
 		return exception.NewInternalServerError("something went wrong")
@@ -74,7 +109,7 @@ func (pg *categoryPg) Add(category *entity.Category) exception.Exception {
 }
 
 // Delete implements category_repo.CategoryRepo.
-func (pg *categoryPg) Delete(id int) exception.Exception {
+func (pg *categoryPg) Delete(id int, cascade bool) exception.Exception {
 
 	tx, err := pg.db.Begin()
 
@@ -84,18 +119,20 @@ func (pg *categoryPg) Delete(id int) exception.Exception {
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	stmt, err := tx.Prepare(deleteCategoryQuery)
+	queries := []string{deleteCategoryQuery}
 
-	if err != nil {
-		tx.Rollback()
-		log.Println(err.Error())
-		return exception.NewInternalServerError("something went wrong")
+	// a cascading delete takes the whole subtree and its products with it,
+	// products go first while the subtree can still be walked
+	if cascade {
+		queries = []string{deleteSubtreeProductsQuery, deleteSubtreeQuery}
 	}
 
-	if _, err := stmt.Exec(id); err != nil {
-		tx.Rollback()
-		log.Println(err.Error())
-		return exception.NewInternalServerError("something went wrong")
+	for _, query := range queries {
+		if _, err := tx.Exec(query, id); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -124,7 +161,9 @@ func (pg *categoryPg) Fetch() ([]*entity.Category, exception.Exception) {
 
 		if err := rows.Scan(
 			&category.Id,
+			&category.ParentId,
 			&category.Type,
+			&category.Slug,
 			&category.CreatedAt,
 			&category.UpdatedAt,
 		); err != nil {
@@ -139,48 +178,68 @@ func (pg *categoryPg) Fetch() ([]*entity.Category, exception.Exception) {
 }
 
 // FetchById implements category_repo.CategoryRepo.
-func (pg *categoryPg) FetchById(id int) (*category_repo.CategoryWithProduct, exception.Exception) {
+func (pg *categoryPg) FetchById(id int) (*entity.Category, exception.Exception) {
+	return pg.fetchOne(fetchCategoryByIdQuery, id)
+}
 
-	categoriesProducts := []*category_repo.CategoryProduct{}
-	categoryWithProducts := &category_repo.CategoryWithProduct{}
+// FetchBySlug implements category_repo.CategoryRepo.
+func (pg *categoryPg) FetchBySlug(slug string) (*entity.Category, exception.Exception) {
+	return pg.fetchOne(fetchCategoryBySlugQuery, slug)
+}
 
-	stmt, err := pg.db.Prepare(fetchCategoryByIdQuery)
+// SlugTaken implements category_repo.CategoryRepo.
+func (pg *categoryPg) SlugTaken(slug string, excludeId int) (bool, exception.Exception) {
 
-	if err != nil {
+	taken := false
+
+	if err := pg.db.QueryRow(slugTakenQuery, slug, excludeId).Scan(&taken); err != nil {
 		log.Println(err.Error())
-		return nil, exception.NewInternalServerError("something went wrong")
+		return false, exception.NewInternalServerError("something went wrong")
 	}
 
-	rows, err := stmt.Query(id)
+	return taken, nil
+}
+
+// FetchSlugRedirect implements category_repo.CategoryRepo.
+func (pg *categoryPg) FetchSlugRedirect(slug string) (string, exception.Exception) {
+
+	current := ""
+
+	if err := pg.db.QueryRow(fetchSlugRedirectQuery, slug).Scan(&current); err != nil {
+
+		if err == sql.ErrNoRows {
+			return "", exception.NewNotFoundError("category not found")
+		}
 
-	if err != nil {
 		log.Println(err.Error())
-		return nil, exception.NewInternalServerError("something went wrong")
+		return "", exception.NewInternalServerError("something went wrong")
 	}
 
-	for rows.Next() {
+	return current, nil
+}
 
-		categoryProduct := categoryWithProduct{}
+func (pg *categoryPg) fetchOne(query string, arg any) (*entity.Category, exception.Exception) {
 
-		if err := rows.Scan(
-			&categoryProduct.categoryId,
-			&categoryProduct.categoryType,
-			&categoryProduct.productId,
-			&categoryProduct.productName,
-			&categoryProduct.productPrice,
-			&categoryProduct.productStock,
-			&categoryProduct.productSold,
-			&categoryProduct.categoryCreatedAt,
-			&categoryProduct.categoryUpdatedAt,
-		); err != nil {
+	category := entity.Category{}
+
+	if err := pg.db.QueryRow(query, arg).Scan(
+		&category.Id,
+		&category.ParentId,
+		&category.Type,
+		&category.Slug,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
 			log.Println(err.Error())
-			return nil, exception.NewInternalServerError("something went wrong")
+			return nil, exception.NewNotFoundError("category not found")
 		}
 
-		categoriesProducts = append(categoriesProducts, categoryProduct.categoryWithProductToAggregate())
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	return categoryWithProducts.HandleCategoryWithProduct(categoriesProducts), nil
+	return &category, nil
 }
 
 // FetchId implements category_repo.CategoryRepo.
@@ -196,6 +255,7 @@ func (pg *categoryPg) FetchId(id int) (*entity.Category, exception.Exception) {
 
 	if err := stmt.QueryRow(id).Scan(
 		&category.Id,
+		&category.ParentId,
 	); err != nil {
 		if err == sql.ErrNoRows {
 			log.Println(err.Error())
@@ -220,6 +280,18 @@ func (pg *categoryPg) Modify(id int, category *entity.Category) exception.Except
 		return exception.NewInternalServerError("something went wrong")
 	}
 
+	if _, err := tx.Exec(addSlugRedirectQuery, id, category.Slug); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(releaseSlugRedirectQuery, category.Slug); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
 	stmt, err := tx.Prepare(modifyCategoryQuery)
 
 	if err != nil {
@@ -228,7 +300,19 @@ func (pg *categoryPg) Modify(id int, category *entity.Category) exception.Except
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(id, category.Type); err != nil {
+	if _, err := stmt.Exec(id, category.Type, category.ParentId, category.Slug); err != nil {
+		if err.Error() == duplicateCategoryError {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewConflictError("type has been created")
+		}
+
+		if err.Error() == duplicateSlugError {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewConflictError("slug is already in use")
+		}
+
 		tx.Rollback()
 		log.Println(err.Error())
 		return exception.NewInternalServerError("something went wrong")
@@ -242,3 +326,45 @@ func (pg *categoryPg) Modify(id int, category *entity.Category) exception.Except
 
 	return nil
 }
+
+// FetchDescendantIds implements category_repo.CategoryRepo.
+func (pg *categoryPg) FetchDescendantIds(id int) ([]int, exception.Exception) {
+
+	ids := []int{}
+
+	rows, err := pg.db.Query(fetchDescendantIdsQuery, id)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		descendantId := 0
+
+		if err := rows.Scan(&descendantId); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		ids = append(ids, descendantId)
+	}
+
+	return ids, nil
+}
+
+// CountDependents implements category_repo.CategoryRepo.
+func (pg *categoryPg) CountDependents(id int) (int, int, exception.Exception) {
+
+	children, products := 0, 0
+
+	if err := pg.db.QueryRow(countDependentsQuery, id).Scan(&children, &products); err != nil {
+		log.Println(err.Error())
+		return 0, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return children, products, nil
+}
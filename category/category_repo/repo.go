@@ -8,8 +8,13 @@ import (
 type CategoryRepo interface {
 	Add(category *entity.Category) exception.Exception
 	Fetch() ([]*entity.Category, exception.Exception)
-	FetchById(id int) (*CategoryWithProduct, exception.Exception)
+	FetchById(id int) (*entity.Category, exception.Exception)
+	FetchBySlug(slug string) (*entity.Category, exception.Exception)
+	SlugTaken(slug string, excludeId int) (bool, exception.Exception)
+	FetchSlugRedirect(slug string) (string, exception.Exception)
 	FetchId(id int) (*entity.Category, exception.Exception)
 	Modify(id int, category *entity.Category) exception.Exception
-	Delete(id int) exception.Exception
+	Delete(id int, cascade bool) exception.Exception
+	FetchDescendantIds(id int) ([]int, exception.Exception)
+	CountDependents(id int) (int, int, exception.Exception)
 }
@@ -6,33 +6,58 @@ import (
 	"fashion-api/entity"
 	"fashion-api/pkg/exception"
 	"fashion-api/pkg/helper"
+	"fashion-api/pkg/slug"
+	"fashion-api/product/product_service"
 
 	"net/http"
 )
 
 type categoryService struct {
 	cr category_repo.CategoryRepo
+	ps product_service.ProductService
 }
 
 type CategoryService interface {
 	Add(payload *dto.CategoryPayload) (*helper.ResponseBody, exception.Exception)
 	Fetch() (*helper.ResponseBody, exception.Exception)
-	FetchById(id int) (*helper.ResponseBody, exception.Exception)
+	FetchById(id int, currency string, query *dto.ProductQuery) (*helper.ResponseBody, exception.Exception)
 	Modify(id int, payload *dto.CategoryPayload) (*helper.ResponseBody, exception.Exception)
-	Delete(id int) (*helper.ResponseBody, exception.Exception)
+	Delete(id int, cascade bool) (*helper.ResponseBody, exception.Exception)
+	FetchTree() (*helper.ResponseBody, exception.Exception)
+	FetchBySlug(slug string, currency string, query *dto.ProductQuery) (*helper.ResponseBody, exception.Exception)
 }
 
-func NewCategoryService(cr category_repo.CategoryRepo) CategoryService {
+func NewCategoryService(cr category_repo.CategoryRepo, ps product_service.ProductService) CategoryService {
 	return &categoryService{
 		cr: cr,
+		ps: ps,
 	}
 }
 
 // Add implements CategoryService.
 func (cs *categoryService) Add(payload *dto.CategoryPayload) (*helper.ResponseBody, exception.Exception) {
-	if err := cs.cr.Add(&entity.Category{
+
+	category := &entity.Category{
 		Type: payload.Type,
-	}); err != nil {
+	}
+
+	if payload.ParentId != nil && *payload.ParentId != 0 {
+		if _, err := cs.cr.FetchId(*payload.ParentId); err != nil {
+			return nil, err
+		}
+
+		category.ParentId = payload.ParentId
+	}
+
+	categorySlug, err := cs.resolveSlug(0, payload.Slug, payload.Type, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	category.Slug = categorySlug
+
+	if err := cs.cr.Add(category); err != nil {
 		return nil, err
 	}
 
@@ -44,15 +69,25 @@ func (cs *categoryService) Add(payload *dto.CategoryPayload) (*helper.ResponseBo
 }
 
 // Delete implements CategoryService.
-func (cs *categoryService) Delete(id int) (*helper.ResponseBody, exception.Exception) {
+func (cs *categoryService) Delete(id int, cascade bool) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := cs.cr.FetchId(id); err != nil {
+		return nil, err
+	}
 
-	_, err := cs.FetchById(id)
+	children, products, err := cs.cr.CountDependents(id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if err := cs.cr.Delete(id); err != nil {
+	// deleting a category that is still in use has to be asked for, it
+	// takes its subcategories and their products down with it
+	if !cascade && (children > 0 || products > 0) {
+		return nil, exception.NewConflictError("category still has subcategories or products, delete with cascade=true to remove them as well")
+	}
+
+	if err := cs.cr.Delete(id, cascade); err != nil {
 		return nil, err
 	}
 
@@ -80,31 +115,80 @@ func (cs *categoryService) Fetch() (*helper.ResponseBody, exception.Exception) {
 }
 
 // FetchById implements CategoryService.
-func (cs *categoryService) FetchById(id int) (*helper.ResponseBody, exception.Exception) {
+func (cs *categoryService) FetchById(id int, currency string, query *dto.ProductQuery) (*helper.ResponseBody, exception.Exception) {
 
-	catergory, err := cs.cr.FetchById(id)
+	category, err := cs.cr.FetchById(id)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if catergory == nil {
-		return nil, exception.NewNotFoundError("category not found")
+	return cs.categoryWithProducts(category, currency, query)
+}
+
+// FetchBySlug implements CategoryService.
+func (cs *categoryService) FetchBySlug(categorySlug string, currency string, query *dto.ProductQuery) (*helper.ResponseBody, exception.Exception) {
+
+	category, err := cs.cr.FetchBySlug(categorySlug)
+
+	if err != nil {
+
+		if err.Status() != http.StatusNotFound {
+			return nil, err
+		}
+
+		// a slug that has since been changed points to the current one
+		current, redirectErr := cs.cr.FetchSlugRedirect(categorySlug)
+
+		if redirectErr != nil {
+			return nil, redirectErr
+		}
+
+		return &helper.ResponseBody{
+			Status:  http.StatusMovedPermanently,
+			Message: "category has moved",
+			Data: &dto.SlugRedirectData{
+				Slug:     current,
+				Location: "/category/slug/" + current,
+			},
+		}, nil
 	}
 
-	return &helper.ResponseBody{
-		Status:  http.StatusOK,
-		Message: "category successfully fetched",
-		Data:    catergory,
-	}, nil
+	return cs.categoryWithProducts(category, currency, query)
 }
 
 // Modify implements CategoryService.
 func (cs *categoryService) Modify(id int, payload *dto.CategoryPayload) (*helper.ResponseBody, exception.Exception) {
 
-	if err := cs.cr.Modify(id, &entity.Category{
-		Type: payload.Type,
-		}); err != nil {
+	current, err := cs.cr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	category := &entity.Category{
+		Type:     payload.Type,
+		ParentId: current.ParentId,
+	}
+
+	if category.Slug, err = cs.resolveSlug(id, payload.Slug, payload.Type, current.Slug); err != nil {
+		return nil, err
+	}
+
+	if payload.ParentId != nil {
+
+		category.ParentId = nil
+
+		if *payload.ParentId != 0 {
+			if err := cs.checkParent(id, *payload.ParentId); err != nil {
+				return nil, err
+			}
+
+			category.ParentId = payload.ParentId
+		}
+	}
+
+	if err := cs.cr.Modify(id, category); err != nil {
 		return nil, err
 	}
 
@@ -114,3 +198,72 @@ func (cs *categoryService) Modify(id int, payload *dto.CategoryPayload) (*helper
 		Data:    nil,
 	}, nil
 }
+
+// FetchTree implements CategoryService.
+func (cs *categoryService) FetchTree() (*helper.ResponseBody, exception.Exception) {
+
+	categories, err := cs.cr.Fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "category tree successfully fetched",
+		Data:    entity.BuildCategoryTree(categories),
+	}, nil
+}
+
+// checkParent makes sure moving the category under parentId won't create a
+// cycle, a category can't become a child of itself or of its descendants.
+func (cs *categoryService) checkParent(id int, parentId int) exception.Exception {
+
+	if parentId == id {
+		return exception.NewBadRequestError("category can't be its own parent")
+	}
+
+	if _, err := cs.cr.FetchId(parentId); err != nil {
+		return err
+	}
+
+	descendantIds, err := cs.cr.FetchDescendantIds(id)
+
+	if err != nil {
+		return err
+	}
+
+	for _, descendantId := range descendantIds {
+		if descendantId == parentId {
+			return exception.NewBadRequestError("category can't be moved under its own subcategory")
+		}
+	}
+
+	return nil
+}
+
+func (cs *categoryService) categoryWithProducts(category *entity.Category, currency string, query *dto.ProductQuery) (*helper.ResponseBody, exception.Exception) {
+
+	query.CategoryId = category.Id
+
+	products, err := cs.ps.FetchPage(currency, query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "category successfully fetched",
+		Data: &dto.CategoryData{
+			Category: category,
+			Products: products,
+		},
+	}, nil
+}
+
+func (cs *categoryService) resolveSlug(id int, requested string, name string, current string) (string, exception.Exception) {
+	return slug.Resolve(requested, name, current, "category", func(candidate string) (bool, exception.Exception) {
+		return cs.cr.SlugTaken(candidate, id)
+	})
+}
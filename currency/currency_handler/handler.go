@@ -0,0 +1,170 @@
+package currency_handler
+
+import (
+	"encoding/json"
+	"fashion-api/currency/currency_service"
+	"fashion-api/dto"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type currencyHandler struct {
+	cs currency_service.CurrencyService
+}
+
+type CurrencyHandler interface {
+	FetchExchangeRates(w http.ResponseWriter, r *http.Request)
+	SaveExchangeRate(w http.ResponseWriter, r *http.Request)
+	DeleteExchangeRate(w http.ResponseWriter, r *http.Request)
+	FetchProductPrices(w http.ResponseWriter, r *http.Request)
+	SaveProductPrice(w http.ResponseWriter, r *http.Request)
+	DeleteProductPrice(w http.ResponseWriter, r *http.Request)
+}
+
+func NewCurrencyHandler(cs currency_service.CurrencyService) CurrencyHandler {
+	return &currencyHandler{
+		cs: cs,
+	}
+}
+
+// FetchExchangeRates implements CurrencyHandler.
+func (ch *currencyHandler) FetchExchangeRates(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	res, err := ch.cs.FetchExchangeRates()
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// SaveExchangeRate implements CurrencyHandler.
+func (ch *currencyHandler) SaveExchangeRate(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	payload := &dto.ExchangeRatePayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ch.cs.SaveExchangeRate(chi.URLParam(r, "currency"), payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// DeleteExchangeRate implements CurrencyHandler.
+func (ch *currencyHandler) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	res, err := ch.cs.DeleteExchangeRate(chi.URLParam(r, "currency"))
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchProductPrices implements CurrencyHandler.
+func (ch *currencyHandler) FetchProductPrices(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ch.cs.FetchProductPrices(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// SaveProductPrice implements CurrencyHandler.
+func (ch *currencyHandler) SaveProductPrice(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	payload := &dto.ProductPricePayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ch.cs.SaveProductPrice(id, chi.URLParam(r, "currency"), payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// DeleteProductPrice implements CurrencyHandler.
+func (ch *currencyHandler) DeleteProductPrice(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ch.cs.DeleteProductPrice(id, chi.URLParam(r, "currency"))
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -0,0 +1,30 @@
+package currency_pg
+
+import "fashion-api/entity"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProductPrice(row scanner) (*entity.ProductPrice, error) {
+
+	price := entity.ProductPrice{}
+
+	if err := row.Scan(
+		&price.Id,
+		&price.ProductId,
+		&price.Currency,
+		&price.Price.Amount,
+		&price.CompareAtPrice.Amount,
+		&price.SalePrice.Amount,
+		&price.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	price.Price.Currency = price.Currency
+	price.CompareAtPrice.Currency = price.Currency
+	price.SalePrice.Currency = price.Currency
+
+	return &price, nil
+}
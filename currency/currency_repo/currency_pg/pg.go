@@ -0,0 +1,207 @@
+package currency_pg
+
+import (
+	"database/sql"
+	"fashion-api/currency/currency_repo"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+type currencyPg struct {
+	db *sql.DB
+}
+
+const (
+	fetchExchangeRatesQuery = `select currency, rate::text, updated_at from exchange_rate order by currency asc`
+
+	saveExchangeRateQuery = `insert into exchange_rate (currency, rate) values ($1, $2) on conflict (currency) do update set rate = excluded.rate, updated_at = now()`
+
+	deleteExchangeRateQuery = `delete from exchange_rate where currency = $1`
+
+	fetchProductPricesQuery = `select id, product_id, currency, price, compare_at_price, sale_price, updated_at from product_price where product_id = $1 order by currency asc`
+
+	fetchProductPriceQuery = `select id, product_id, currency, price, compare_at_price, sale_price, updated_at from product_price where product_id = $1 and currency = $2`
+
+	fetchProductPricesInQuery = `select id, product_id, currency, price, compare_at_price, sale_price, updated_at from product_price where product_id = any($1) and currency = $2`
+
+	saveProductPriceQuery = `insert into product_price (product_id, currency, price, compare_at_price, sale_price) values ($1, $2, $3, $4, $5) on conflict (product_id, currency) do update set price = excluded.price, compare_at_price = excluded.compare_at_price, sale_price = excluded.sale_price, updated_at = now()`
+
+	deleteProductPriceQuery = `delete from product_price where product_id = $1 and currency = $2`
+)
+
+func NewCurrencyPg(db *sql.DB) currency_repo.CurrencyRepo {
+	return &currencyPg{
+		db: db,
+	}
+}
+
+// FetchExchangeRates implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) FetchExchangeRates() ([]*entity.ExchangeRate, exception.Exception) {
+
+	rates := []*entity.ExchangeRate{}
+
+	rows, err := pg.db.Query(fetchExchangeRatesQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		rate := entity.ExchangeRate{}
+
+		if err := rows.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		rates = append(rates, &rate)
+	}
+
+	return rates, nil
+}
+
+// SaveExchangeRate implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) SaveExchangeRate(rate *entity.ExchangeRate) exception.Exception {
+
+	if _, err := pg.db.Exec(saveExchangeRateQuery, rate.Currency, rate.Rate); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// DeleteExchangeRate implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) DeleteExchangeRate(currency string) exception.Exception {
+
+	res, err := pg.db.Exec(deleteExchangeRateQuery, currency)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return exception.NewNotFoundError("exchange rate not found")
+	}
+
+	return nil
+}
+
+// FetchProductPrices implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) FetchProductPrices(productId int) ([]*entity.ProductPrice, exception.Exception) {
+
+	prices := []*entity.ProductPrice{}
+
+	rows, err := pg.db.Query(fetchProductPricesQuery, productId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		price, err := scanProductPrice(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		prices = append(prices, price)
+	}
+
+	return prices, nil
+}
+
+// FetchProductPrice implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) FetchProductPrice(productId int, currency string) (*entity.ProductPrice, exception.Exception) {
+
+	price, err := scanProductPrice(pg.db.QueryRow(fetchProductPriceQuery, productId, currency))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			return nil, exception.NewNotFoundError("product price not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return price, nil
+}
+
+// FetchProductPricesIn implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) FetchProductPricesIn(productIds []int, currency string) (map[int]*entity.ProductPrice, exception.Exception) {
+
+	prices := map[int]*entity.ProductPrice{}
+
+	rows, err := pg.db.Query(fetchProductPricesInQuery, pq.Array(productIds), currency)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		price, err := scanProductPrice(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		prices[price.ProductId] = price
+	}
+
+	return prices, nil
+}
+
+// SaveProductPrice implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) SaveProductPrice(price *entity.ProductPrice) exception.Exception {
+
+	if _, err := pg.db.Exec(
+		saveProductPriceQuery,
+		price.ProductId,
+		price.Currency,
+		price.Price.Amount,
+		price.CompareAtPrice.Amount,
+		price.SalePrice.Amount,
+	); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// DeleteProductPrice implements currency_repo.CurrencyRepo.
+func (pg *currencyPg) DeleteProductPrice(productId int, currency string) exception.Exception {
+
+	res, err := pg.db.Exec(deleteProductPriceQuery, productId, currency)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return exception.NewNotFoundError("product price not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package currency_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type CurrencyRepo interface {
+	FetchExchangeRates() ([]*entity.ExchangeRate, exception.Exception)
+	SaveExchangeRate(rate *entity.ExchangeRate) exception.Exception
+	DeleteExchangeRate(currency string) exception.Exception
+	FetchProductPrices(productId int) ([]*entity.ProductPrice, exception.Exception)
+	FetchProductPrice(productId int, currency string) (*entity.ProductPrice, exception.Exception)
+	FetchProductPricesIn(productIds []int, currency string) (map[int]*entity.ProductPrice, exception.Exception)
+	SaveProductPrice(price *entity.ProductPrice) exception.Exception
+	DeleteProductPrice(productId int, currency string) exception.Exception
+}
@@ -0,0 +1,308 @@
+package currency_service
+
+import (
+	"fashion-api/currency/currency_repo"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/infra/config"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"fashion-api/product/product_repo"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type currencyService struct {
+	cur currency_repo.CurrencyRepo
+	pr  product_repo.ProductRepo
+}
+
+type CurrencyService interface {
+	FetchExchangeRates() (*helper.ResponseBody, exception.Exception)
+	SaveExchangeRate(currency string, payload *dto.ExchangeRatePayload) (*helper.ResponseBody, exception.Exception)
+	DeleteExchangeRate(currency string) (*helper.ResponseBody, exception.Exception)
+	FetchProductPrices(productId int) (*helper.ResponseBody, exception.Exception)
+	SaveProductPrice(productId int, currency string, payload *dto.ProductPricePayload) (*helper.ResponseBody, exception.Exception)
+	DeleteProductPrice(productId int, currency string) (*helper.ResponseBody, exception.Exception)
+	Convert(amount money.Money, currency string) (money.Money, exception.Exception)
+	Converter() (*money.Converter, exception.Exception)
+	Localize(product *entity.Product, currency string) exception.Exception
+	LocalizeAll(products []*entity.Product, currency string) exception.Exception
+}
+
+func NewCurrencyService(cur currency_repo.CurrencyRepo, pr product_repo.ProductRepo) CurrencyService {
+	return &currencyService{
+		cur: cur,
+		pr:  pr,
+	}
+}
+
+// FetchExchangeRates implements CurrencyService.
+func (cs *currencyService) FetchExchangeRates() (*helper.ResponseBody, exception.Exception) {
+
+	rates, err := cs.cur.FetchExchangeRates()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "exchange rates successfully fetched",
+		Data: &dto.ExchangeRateData{
+			Base:  config.NewAppConfig().BaseCurrency,
+			Rates: rates,
+		},
+	}, nil
+}
+
+// SaveExchangeRate implements CurrencyService.
+func (cs *currencyService) SaveExchangeRate(currency string, payload *dto.ExchangeRatePayload) (*helper.ResponseBody, exception.Exception) {
+
+	currency, err := validateCurrency(currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if currency == config.NewAppConfig().BaseCurrency {
+		return nil, exception.NewBadRequestError("the base currency always has a rate of 1")
+	}
+
+	rate, ok := new(big.Rat).SetString(payload.Rate)
+
+	if !ok || rate.Sign() <= 0 {
+		return nil, exception.NewBadRequestError("rate must be greater than zero")
+	}
+
+	if err := cs.cur.SaveExchangeRate(&entity.ExchangeRate{Currency: currency, Rate: payload.Rate}); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "exchange rate successfully saved",
+		Data:    nil,
+	}, nil
+}
+
+// DeleteExchangeRate implements CurrencyService.
+func (cs *currencyService) DeleteExchangeRate(currency string) (*helper.ResponseBody, exception.Exception) {
+
+	if err := cs.cur.DeleteExchangeRate(strings.ToUpper(currency)); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "exchange rate successfully deleted",
+		Data:    nil,
+	}, nil
+}
+
+// FetchProductPrices implements CurrencyService.
+func (cs *currencyService) FetchProductPrices(productId int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := cs.pr.FetchById(productId); err != nil {
+		return nil, err
+	}
+
+	prices, err := cs.cur.FetchProductPrices(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "product prices successfully fetched",
+		Data:    prices,
+	}, nil
+}
+
+// SaveProductPrice implements CurrencyService.
+func (cs *currencyService) SaveProductPrice(productId int, currency string, payload *dto.ProductPricePayload) (*helper.ResponseBody, exception.Exception) {
+
+	currency, err := validateCurrency(currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := cs.pr.FetchById(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if currency == product.Price.Currency {
+		return nil, exception.NewBadRequestError("use PATCH /products/{id} to change the price in the product's own currency")
+	}
+
+	if payload.Price <= 0 || payload.CompareAtPrice < 0 || payload.SalePrice < 0 {
+		return nil, exception.NewBadRequestError("prices can't be negative")
+	}
+
+	if payload.SalePrice >= payload.Price && payload.SalePrice != 0 {
+		return nil, exception.NewBadRequestError("sale price must be lower than price")
+	}
+
+	price := &entity.ProductPrice{
+		ProductId:      productId,
+		Currency:       currency,
+		Price:          money.New(payload.Price, currency),
+		CompareAtPrice: money.New(payload.CompareAtPrice, currency),
+		SalePrice:      money.New(payload.SalePrice, currency),
+	}
+
+	if err := cs.cur.SaveProductPrice(price); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "product price successfully saved",
+		Data:    nil,
+	}, nil
+}
+
+// DeleteProductPrice implements CurrencyService.
+func (cs *currencyService) DeleteProductPrice(productId int, currency string) (*helper.ResponseBody, exception.Exception) {
+
+	if err := cs.cur.DeleteProductPrice(productId, strings.ToUpper(currency)); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "product price successfully deleted",
+		Data:    nil,
+	}, nil
+}
+
+// Convert implements CurrencyService.
+func (cs *currencyService) Convert(amount money.Money, currency string) (money.Money, exception.Exception) {
+
+	if currency == "" || amount.Currency == currency {
+		return amount, nil
+	}
+
+	converter, err := cs.Converter()
+
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	converted, convertErr := converter.Convert(amount, currency)
+
+	if convertErr != nil {
+		return money.Money{}, exception.NewBadRequestError("no exchange rate between " + amount.Currency + " and " + currency)
+	}
+
+	return converted, nil
+}
+
+// Localize implements CurrencyService.
+func (cs *currencyService) Localize(product *entity.Product, currency string) exception.Exception {
+	return cs.LocalizeAll([]*entity.Product{product}, currency)
+}
+
+// LocalizeAll implements CurrencyService. The price list and the exchange
+// rates are read once for the whole batch, not once per product.
+func (cs *currencyService) LocalizeAll(products []*entity.Product, currency string) exception.Exception {
+
+	foreign := []*entity.Product{}
+	productIds := []int{}
+
+	for _, product := range products {
+
+		if currency != "" && currency != product.Price.Currency {
+			foreign = append(foreign, product)
+			productIds = append(productIds, product.Id)
+		}
+	}
+
+	if len(foreign) > 0 {
+
+		prices, err := cs.cur.FetchProductPricesIn(productIds, currency)
+
+		if err != nil {
+			return err
+		}
+
+		var converter *money.Converter
+
+		for _, product := range foreign {
+
+			// an explicit price list wins over a converted price, so merchants
+			// can keep round numbers in every market
+			if price, ok := prices[product.Id]; ok {
+				product.Price = price.Price
+				product.CompareAtPrice = price.CompareAtPrice
+				product.SalePrice = price.SalePrice
+				continue
+			}
+
+			if converter == nil {
+				if converter, err = cs.Converter(); err != nil {
+					return err
+				}
+			}
+
+			for _, amount := range []*money.Money{&product.Price, &product.CompareAtPrice, &product.SalePrice} {
+
+				converted, convertErr := converter.Convert(*amount, currency)
+
+				if convertErr != nil {
+					return exception.NewBadRequestError("no exchange rate between " + amount.Currency + " and " + currency)
+				}
+
+				*amount = converted
+			}
+		}
+	}
+
+	now := time.Now()
+
+	for _, product := range products {
+		product.EffectivePrice = product.PriceAt(now)
+	}
+
+	return nil
+}
+
+// Converter implements CurrencyService. It snapshots the current rates, so
+// callers converting many amounts read them once.
+func (cs *currencyService) Converter() (*money.Converter, exception.Exception) {
+
+	rates, err := cs.cur.FetchExchangeRates()
+
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := map[string]*big.Rat{}
+
+	for _, rate := range rates {
+
+		if value, ok := new(big.Rat).SetString(rate.Rate); ok {
+			parsed[rate.Currency] = value
+		}
+	}
+
+	return money.NewConverter(config.NewAppConfig().BaseCurrency, parsed), nil
+}
+
+func validateCurrency(currency string) (string, exception.Exception) {
+
+	currency = strings.ToUpper(currency)
+
+	if !money.IsSupported(currency) {
+		return "", exception.NewBadRequestError("currency " + currency + " is not supported")
+	}
+
+	return currency, nil
+}
@@ -0,0 +1,9 @@
+package dto
+
+type AttributePayload struct {
+	Code       string   `json:"code" valid:"required~Code can't be empty"`
+	Name       string   `json:"name" valid:"required~Name can't be empty"`
+	Type       string   `json:"type" valid:"required~Type can't be empty,in(text|number|boolean|select)~Type must be text, number, boolean or select"`
+	Options    []string `json:"options"`
+	Filterable bool     `json:"filterable"`
+}
@@ -1,5 +1,22 @@
 package dto
 
+import "fashion-api/entity"
+
+// CategoryPayload leaves the parent as it is when parent_id is left out,
+// a parent_id of 0 moves the category to the root.
 type CategoryPayload struct {
-	Type string `json:"type" valid:"required~Type can't be empty"`
+	Type     string `json:"type" valid:"required~Type can't be empty"`
+	ParentId *int   `json:"parent_id"`
+	Slug     string `json:"slug"`
+}
+
+type CategoryData struct {
+	*entity.Category
+	Products *ProductPage `json:"products"`
+}
+
+// SlugRedirectData answers a request for a slug that has since been changed.
+type SlugRedirectData struct {
+	Slug     string `json:"slug"`
+	Location string `json:"location"`
 }
@@ -0,0 +1,18 @@
+package dto
+
+import "fashion-api/entity"
+
+type ExchangeRatePayload struct {
+	Rate string `json:"rate" valid:"required~Rate can't be empty,float~Rate must be a decimal number"`
+}
+
+type ProductPricePayload struct {
+	Price          int64 `json:"price" valid:"required~Price can't be empty"`
+	CompareAtPrice int64 `json:"compare_at_price"`
+	SalePrice      int64 `json:"sale_price"`
+}
+
+type ExchangeRateData struct {
+	Base  string                 `json:"base"`
+	Rates []*entity.ExchangeRate `json:"rates"`
+}
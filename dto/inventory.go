@@ -0,0 +1,21 @@
+package dto
+
+type LocationPayload struct {
+	Code     string `json:"code" valid:"required~Code can't be empty"`
+	Name     string `json:"name" valid:"required~Name can't be empty"`
+	Type     string `json:"type" valid:"required~Type can't be empty,in(warehouse|store)~Type must be warehouse or store"`
+	Country  string `json:"country" valid:"required~Country can't be empty,length(2|2)~Country must be a 2 letter ISO code"`
+	Region   string `json:"region"`
+	Priority int    `json:"priority"`
+	Active   bool   `json:"active"`
+}
+
+// StockTransferPayload moves Qty units of a product that aren't reserved
+// from one location to another.
+type StockTransferPayload struct {
+	ProductId      int    `json:"product_id" valid:"required~Product id can't be empty"`
+	FromLocationId int    `json:"from_location_id" valid:"required~From location id can't be empty"`
+	ToLocationId   int    `json:"to_location_id" valid:"required~To location id can't be empty"`
+	Qty            int    `json:"qty" valid:"required~Qty can't be empty"`
+	Reason         string `json:"reason" valid:"required~Reason can't be empty"`
+}
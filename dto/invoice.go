@@ -0,0 +1,15 @@
+package dto
+
+import "fashion-api/entity"
+
+// CreditNotePayload credits Amount, in minor units, of the invoice. The
+// whole amount still left to credit when Amount is 0.
+type CreditNotePayload struct {
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason" valid:"required~Reason can't be empty"`
+}
+
+type InvoiceDocuments struct {
+	Invoice     *entity.Invoice   `json:"invoice"`
+	CreditNotes []*entity.Invoice `json:"credit_notes"`
+}
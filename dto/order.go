@@ -1,5 +1,10 @@
 package dto
 
+import (
+	"fashion-api/entity"
+	"time"
+)
+
 type AddOrderPayload struct {
 	ProductId int `json:"product_id" valid:"required~Product id can't be empty"`
 	Qty       int `json:"qty" valid:"required~Qty can't be empty"`
@@ -8,3 +13,22 @@ type AddOrderPayload struct {
 type ModifyOrderPayload struct {
 	Qty int `json:"qty" valid:"required~Qty can't be empty"`
 }
+
+type CancelOrderPayload struct {
+	Reason string `json:"reason"`
+}
+
+// HistoryQuery filters the order and transaction history of a customer, To
+// is exclusive.
+type HistoryQuery struct {
+	From   *time.Time
+	To     *time.Time
+	Status string
+	Page   int
+	Limit  int
+}
+
+type OrderPage struct {
+	Orders     []*entity.OrderWithProductMapped `json:"orders"`
+	Pagination *PageInfo                        `json:"pagination"`
+}
@@ -0,0 +1,17 @@
+package dto
+
+type PageInfo struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Total      int `json:"total"`
+	TotalPages int `json:"total_pages"`
+}
+
+func NewPageInfo(page int, limit int, total int) *PageInfo {
+	return &PageInfo{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: (total + limit - 1) / limit,
+	}
+}
@@ -1,23 +1,95 @@
 package dto
 
-import "time"
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/money"
+	"time"
+)
 
 type ProductPayload struct {
-	Name        string `json:"name" valid:"required~Name can't be empty"`
-	Description string `json:"description" valid:"required~Description can't be empty"`
-	CategoryId  int    `json:"category_id" valid:"required~Category id can't be empty"`
-	Price       int    `json:"price" valid:"required~Price can't be empty"`
-	Stock       int    `json:"stock" valid:"required~Stock can't be empty"`
+	Name             string            `json:"name" valid:"required~Name can't be empty"`
+	Slug             string            `json:"slug"`
+	Sku              string            `json:"sku"`
+	Description      string            `json:"description" valid:"required~Description can't be empty"`
+	CategoryId       int               `json:"category_id" valid:"required~Category id can't be empty"`
+	TaxClass         string            `json:"tax_class"`
+	Price            int               `json:"price" valid:"required~Price can't be empty"`
+	CompareAtPrice   int               `json:"compare_at_price"`
+	SalePrice        int               `json:"sale_price"`
+	SaleStartsAt     *time.Time        `json:"sale_starts_at"`
+	SaleEndsAt       *time.Time        `json:"sale_ends_at"`
+	WeightGrams      int               `json:"weight_grams"`
+	LengthCm         int               `json:"length_cm"`
+	WidthCm          int               `json:"width_cm"`
+	HeightCm         int               `json:"height_cm"`
+	Stock            int               `json:"stock" valid:"required~Stock can't be empty"`
+	ReorderThreshold int               `json:"reorder_threshold"`
+	Tags             []string          `json:"tags"`
+	Attributes       map[string]string `json:"attributes"`
 }
 
 type ProductData struct {
-	Id          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CategoryId  int       `json:"category_id"`
-	Price       int       `json:"price"`
-	Stock       int       `json:"stock"`
-	Sold        int       `json:"sold"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Id               int               `json:"id"`
+	Name             string            `json:"name"`
+	Slug             string            `json:"slug"`
+	Sku              string            `json:"sku"`
+	Description      string            `json:"description"`
+	CategoryId       int               `json:"category_id"`
+	TaxClass         string            `json:"tax_class"`
+	Price            money.Money       `json:"price"`
+	CompareAtPrice   money.Money       `json:"compare_at_price"`
+	SalePrice        money.Money       `json:"sale_price"`
+	SaleStartsAt     *time.Time        `json:"sale_starts_at"`
+	SaleEndsAt       *time.Time        `json:"sale_ends_at"`
+	EffectivePrice   money.Money       `json:"effective_price"`
+	WeightGrams      int               `json:"weight_grams"`
+	LengthCm         int               `json:"length_cm"`
+	WidthCm          int               `json:"width_cm"`
+	HeightCm         int               `json:"height_cm"`
+	Stock            int               `json:"stock"`
+	Sold             int               `json:"sold"`
+	ReorderThreshold int               `json:"reorder_threshold"`
+	RatingAverage    float64           `json:"rating_average"`
+	ReviewCount      int               `json:"review_count"`
+	Tags             []string          `json:"tags"`
+	Attributes       map[string]string `json:"attributes"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// ProductQuery is the filter vocabulary shared by every product listing.
+// Prices are minor units of the currency the listing is requested in.
+// A product must carry every tag and, per attribute, any of the values.
+type ProductQuery struct {
+	Search     string
+	CategoryId int
+	MinPrice   int64
+	MaxPrice   int64
+	InStock    bool
+	Tags       []string
+	Attributes map[string][]string
+	Sort       string
+	Page       int
+	Limit      int
+}
+
+type ProductPage struct {
+	Products   []*entity.Product `json:"products"`
+	Facets     []*entity.Facet   `json:"facets"`
+	Pagination *PageInfo         `json:"pagination"`
+}
+
+// StockAdjustmentPayload changes the stock on hand by Qty, negative to
+// write units off, at LocationId or the primary location when it is 0.
+type StockAdjustmentPayload struct {
+	Qty        int    `json:"qty" valid:"required~Qty can't be empty"`
+	Type       string `json:"type" valid:"in(restock|adjustment)~Type must be restock or adjustment"`
+	Reason     string `json:"reason" valid:"required~Reason can't be empty"`
+	LocationId int    `json:"location_id"`
+}
+
+type StockMovementPage struct {
+	*entity.StockLedger
+	Movements  []*entity.StockMovement `json:"movements"`
+	Pagination *PageInfo               `json:"pagination"`
 }
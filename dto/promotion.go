@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+type PromotionPayload struct {
+	Name         string     `json:"name" valid:"required~Name can't be empty"`
+	Code         string     `json:"code"`
+	Type         string     `json:"type" valid:"required~Type can't be empty,in(percentage|fixed|buy_x_get_y)~Type must be percentage, fixed or buy_x_get_y"`
+	Currency     string     `json:"currency"`
+	Value        int        `json:"value"`
+	BuyQty       int        `json:"buy_qty"`
+	GetQty       int        `json:"get_qty"`
+	MinSpend     int        `json:"min_spend"`
+	UsageLimit   int        `json:"usage_limit"`
+	PerUserLimit int        `json:"per_user_limit"`
+	CategoryId   int        `json:"category_id"`
+	ProductId    int        `json:"product_id"`
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	Active       bool       `json:"active"`
+}
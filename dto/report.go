@@ -0,0 +1,156 @@
+package dto
+
+import (
+	"fashion-api/entity"
+	"strconv"
+	"time"
+)
+
+const reportDateLayout = "2006-01-02"
+
+type LowStockReport struct {
+	WindowDays int                    `json:"window_days"`
+	Products   []*entity.LowStockItem `json:"products"`
+}
+
+// ReportQuery is the filter vocabulary shared by the sales reports. To is
+// exclusive, the day after the last day asked for.
+type ReportQuery struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+	Currency string
+	Rank     string
+	Limit    int
+	Format   string
+}
+
+// CSVReport is a report that can be downloaded as CSV.
+type CSVReport interface {
+	CSV() ([]string, [][]string)
+}
+
+// ReportRange echoes the dates a report covers, both days included.
+type ReportRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func NewReportRange(query *ReportQuery) ReportRange {
+	return ReportRange{
+		From: query.From.Format(reportDateLayout),
+		To:   query.To.AddDate(0, 0, -1).Format(reportDateLayout),
+	}
+}
+
+type SalesReport struct {
+	ReportRange
+	Interval string                `json:"interval"`
+	Currency string                `json:"currency"`
+	Totals   *entity.Sales         `json:"totals"`
+	Buckets  []*entity.SalesBucket `json:"buckets"`
+}
+
+type TopSellerReport struct {
+	ReportRange
+	Currency string              `json:"currency"`
+	Rank     string              `json:"rank"`
+	Items    []*entity.TopSeller `json:"items"`
+}
+
+type CustomerReport struct {
+	ReportRange
+	Interval string                   `json:"interval"`
+	Totals   *entity.Customers        `json:"totals"`
+	Buckets  []*entity.CustomerBucket `json:"buckets"`
+}
+
+type ConversionReport struct {
+	ReportRange
+	Interval string                     `json:"interval"`
+	Totals   *entity.Conversion         `json:"totals"`
+	Buckets  []*entity.ConversionBucket `json:"buckets"`
+}
+
+// CSV implements CSVReport.
+func (r *SalesReport) CSV() ([]string, [][]string) {
+
+	header := []string{"period", "orders", "gross_revenue", "refunds", "revenue", "discount", "tax", "shipping", "average_order_value", "currency"}
+	records := [][]string{}
+
+	for _, bucket := range r.Buckets {
+		records = append(records, []string{
+			bucket.Period.Format(reportDateLayout),
+			strconv.Itoa(bucket.Orders),
+			bucket.GrossRevenue.Decimal(),
+			bucket.Refunds.Decimal(),
+			bucket.Revenue.Decimal(),
+			bucket.Discount.Decimal(),
+			bucket.Tax.Decimal(),
+			bucket.Shipping.Decimal(),
+			bucket.AverageOrderValue.Decimal(),
+			r.Currency,
+		})
+	}
+
+	return header, records
+}
+
+// CSV implements CSVReport.
+func (r *TopSellerReport) CSV() ([]string, [][]string) {
+
+	header := []string{"rank", "id", "name", "units", "orders", "revenue", "currency"}
+	records := [][]string{}
+
+	for i, item := range r.Items {
+		records = append(records, []string{
+			strconv.Itoa(i + 1),
+			strconv.Itoa(item.Id),
+			item.Name,
+			strconv.Itoa(item.Units),
+			strconv.Itoa(item.Orders),
+			item.Revenue.Decimal(),
+			r.Currency,
+		})
+	}
+
+	return header, records
+}
+
+// CSV implements CSVReport.
+func (r *CustomerReport) CSV() ([]string, [][]string) {
+
+	header := []string{"period", "customers", "new", "returning"}
+	records := [][]string{}
+
+	for _, bucket := range r.Buckets {
+		records = append(records, []string{
+			bucket.Period.Format(reportDateLayout),
+			strconv.Itoa(bucket.Total),
+			strconv.Itoa(bucket.New),
+			strconv.Itoa(bucket.Returning),
+		})
+	}
+
+	return header, records
+}
+
+// CSV implements CSVReport.
+func (r *ConversionReport) CSV() ([]string, [][]string) {
+
+	header := []string{"period", "orders", "checked_out", "paid", "checkout_rate", "paid_rate"}
+	records := [][]string{}
+
+	for _, bucket := range r.Buckets {
+		records = append(records, []string{
+			bucket.Period.Format(reportDateLayout),
+			strconv.Itoa(bucket.Orders),
+			strconv.Itoa(bucket.CheckedOut),
+			strconv.Itoa(bucket.Paid),
+			strconv.FormatFloat(bucket.CheckoutRate, 'f', 2, 64),
+			strconv.FormatFloat(bucket.PaidRate, 'f', 2, 64),
+		})
+	}
+
+	return header, records
+}
@@ -0,0 +1,14 @@
+package dto
+
+import "fashion-api/entity"
+
+type AddReviewPayload struct {
+	Rating int    `json:"rating" valid:"required~Rating can't be empty"`
+	Title  string `json:"title" valid:"required~Title can't be empty,length(1|100)~Title can't be longer than 100 characters"`
+	Body   string `json:"body" valid:"required~Body can't be empty"`
+}
+
+type ReviewPage struct {
+	Reviews    []*entity.Review `json:"reviews"`
+	Pagination *PageInfo        `json:"pagination"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+type AddReturnPayload struct {
+	OrderId int    `json:"order_id" valid:"required~Order id can't be empty"`
+	Qty     int    `json:"qty" valid:"required~Qty can't be empty"`
+	Reason  string `json:"reason" valid:"required~Reason can't be empty"`
+}
+
+// ApproveReturnPayload takes the refund in minor units of the currency the
+// transaction was paid in.
+type ApproveReturnPayload struct {
+	RefundAmount int64  `json:"refund_amount"`
+	Note         string `json:"note"`
+}
+
+type RejectReturnPayload struct {
+	Note string `json:"note" valid:"required~Note can't be empty"`
+}
+
+type ReceiveReturnPayload struct {
+	Restock bool   `json:"restock"`
+	Note    string `json:"note"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+import "time"
+
+type ShipmentItemPayload struct {
+	ProductId int `json:"product_id" valid:"required~Product id can't be empty"`
+	Qty       int `json:"qty" valid:"required~Qty can't be empty"`
+}
+
+// AddShipmentPayload ships the listed items, or everything not yet shipped
+// when Items is empty.
+type AddShipmentPayload struct {
+	Carrier        string                 `json:"carrier" valid:"required~Carrier can't be empty"`
+	TrackingNumber string                 `json:"tracking_number" valid:"required~Tracking number can't be empty"`
+	ShippedAt      *time.Time             `json:"shipped_at"`
+	Items          []*ShipmentItemPayload `json:"items"`
+}
+
+type ModifyShipmentPayload struct {
+	Carrier        string     `json:"carrier"`
+	TrackingNumber string     `json:"tracking_number"`
+	Status         string     `json:"status" valid:"in(shipped|in_transit|delivered)~Status must be shipped, in_transit or delivered"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "fashion-api/entity"
+
+type ShippingZoneLocationPayload struct {
+	Country string `json:"country" valid:"required~Country can't be empty,length(2|2)~Country must be a 2 letter ISO code"`
+	Region  string `json:"region"`
+}
+
+type ShippingZonePayload struct {
+	Name      string                         `json:"name" valid:"required~Name can't be empty"`
+	Locations []*ShippingZoneLocationPayload `json:"locations" valid:"required~Locations can't be empty"`
+}
+
+type ShippingMethodPayload struct {
+	ZoneId         int    `json:"zone_id" valid:"required~Zone id can't be empty"`
+	Name           string `json:"name" valid:"required~Name can't be empty"`
+	Type           string `json:"type" valid:"required~Type can't be empty,in(flat|weight_based|free_over)~Type must be flat, weight_based or free_over"`
+	Currency       string `json:"currency"`
+	Rate           int    `json:"rate"`
+	PerKgRate      int    `json:"per_kg_rate"`
+	FreeThreshold  int    `json:"free_threshold"`
+	MaxWeightGrams int    `json:"max_weight_grams"`
+	Active         bool   `json:"active"`
+}
+
+type ShippingRatesItemPayload struct {
+	ProductId int `json:"product_id" valid:"required~Product id can't be empty"`
+	Qty       int `json:"qty" valid:"required~Qty can't be empty"`
+}
+
+type ShippingRatesPayload struct {
+	Items           []*ShippingRatesItemPayload `json:"items" valid:"required~Items can't be empty"`
+	ShippingAddress *AddressPayload             `json:"shipping_address" valid:"required~Shipping address can't be empty"`
+}
+
+type ShippingRatesData struct {
+	Parcel  *entity.Parcel          `json:"parcel"`
+	Options []*entity.ShippingQuote `json:"options"`
+}
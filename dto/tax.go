@@ -0,0 +1,20 @@
+package dto
+
+type TaxRatePayload struct {
+	Name      string `json:"name" valid:"required~Name can't be empty"`
+	Country   string `json:"country" valid:"required~Country can't be empty,length(2|2)~Country must be a 2 letter ISO code"`
+	Region    string `json:"region"`
+	TaxClass  string `json:"tax_class"`
+	Rate      int    `json:"rate" valid:"required~Rate can't be empty"`
+	Inclusive bool   `json:"inclusive"`
+	Active    bool   `json:"active"`
+}
+
+type AddressPayload struct {
+	Recipient  string `json:"recipient" valid:"required~Recipient can't be empty"`
+	Line1      string `json:"line1" valid:"required~Address line can't be empty"`
+	City       string `json:"city" valid:"required~City can't be empty"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country" valid:"required~Country can't be empty,length(2|2)~Country must be a 2 letter ISO code"`
+}
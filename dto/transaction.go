@@ -1,5 +1,41 @@
 package dto
 
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
+
 type AddTransactionPayload struct {
-	OrderId int `json:"order_id" valid:"required~Order id can't be empty"`
+	OrderId          int             `json:"order_id" valid:"required~Order id can't be empty"`
+	CouponCode       string          `json:"coupon_code"`
+	ShippingMethodId int             `json:"shipping_method_id"`
+	ShippingAddress  *AddressPayload `json:"shipping_address" valid:"required~Shipping address can't be empty"`
+}
+
+type TransactionPaymentData struct {
+	TransactionId int         `json:"transaction_id"`
+	Status        string      `json:"status"`
+	Provider      string      `json:"provider"`
+	IntentId      string      `json:"intent_id"`
+	ClientSecret  string      `json:"client_secret"`
+	Amount        money.Money `json:"amount"`
+	Discount      money.Money `json:"discount"`
+	Tax           money.Money `json:"tax"`
+	Shipping      money.Money `json:"shipping"`
+}
+
+// TransactionQuery filters the admin transaction list. Amounts are minor
+// units of the grand total, To is exclusive and Cursor is the next_cursor
+// of the previous page.
+type TransactionQuery struct {
+	From       *time.Time
+	To         *time.Time
+	UserId     int
+	ProductId  int
+	CategoryId int
+	Status     string
+	MinAmount  int64
+	MaxAmount  int64
+	Cursor     string
+	Limit      int
 }
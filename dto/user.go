@@ -9,12 +9,16 @@ type UserSignUpPayload struct {
 	FullName string `valid:"required~Full name can't be empty" example:"Jhon Doe" json:"full_name"`
 	Email    string `valid:"required~Email can't be empty, email" example:"example@email.com" json:"email"`
 	Password string `valid:"required~Password can't be empty" example:"secret" json:"password"`
+	// Locale is the language emails are sent in, the default locale when
+	// left out
+	Locale string `example:"en" json:"locale"`
 }
 
 type UserModifyPayload struct {
 	FullName string `valid:"required~Full name can't be empty" example:"Jhon Doe" json:"full_name"`
 	Email    string `valid:"required~Email can't be empty, email" example:"example@email.com" json:"email"`
 	Address  string `json:"address"`
+	Locale   string `example:"id" json:"locale"`
 }
 
 type UserChangePasswordPayload struct {
@@ -23,6 +27,16 @@ type UserChangePasswordPayload struct {
 	ConfirmNewPassword string `valid:"required~Confirm new password can't be empty" example:"newpassword" json:"confirm_new_password"`
 }
 
+type UserForgotPasswordPayload struct {
+	Email string `valid:"required~Email can't be empty, email" example:"example@email.com" json:"email"`
+}
+
+type UserResetPasswordPayload struct {
+	Token              string `valid:"required~Token can't be empty" json:"token"`
+	NewPassword        string `valid:"required~New password can't be empty" example:"newpassword" json:"new_password"`
+	ConfirmNewPassword string `valid:"required~Confirm new password can't be empty" example:"newpassword" json:"confirm_new_password"`
+}
+
 type TokenString struct {
 	Token string `json:"token"`
 }
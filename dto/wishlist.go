@@ -0,0 +1,5 @@
+package dto
+
+type AddWishlistPayload struct {
+	ProductId int `json:"product_id" valid:"required~Product id can't be empty"`
+}
@@ -0,0 +1,12 @@
+package entity
+
+// Address is where an order ships to. Country is an ISO 3166-1 alpha-2
+// code and Region the state or province within it.
+type Address struct {
+	Recipient  string `json:"recipient"`
+	Line1      string `json:"line1"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	AttributeTypeText    = "text"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeSelect  = "select"
+
+	// FacetCodeTags is the facet of product tags, no attribute may use it
+	FacetCodeTags = "tags"
+)
+
+// Attribute defines a typed product property such as brand or material.
+// Select attributes only accept one of their Options, filterable ones are
+// offered as facets on product listings.
+type Attribute struct {
+	Id         int       `json:"id"`
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Options    []string  `json:"options"`
+	Filterable bool      `json:"filterable"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+type Facet struct {
+	Code   string        `json:"code"`
+	Name   string        `json:"name"`
+	Values []*FacetValue `json:"values"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Normalize returns value in the form it is stored and filtered by, false
+// when the value doesn't fit the attribute type.
+func (a *Attribute) Normalize(value string) (string, bool) {
+
+	value = strings.TrimSpace(value)
+
+	if value == "" {
+		return "", false
+	}
+
+	switch a.Type {
+	case AttributeTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return "", false
+		}
+
+		return strconv.FormatFloat(number, 'f', -1, 64), true
+	case AttributeTypeBoolean:
+		boolean, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return "", false
+		}
+
+		return strconv.FormatBool(boolean), true
+	case AttributeTypeSelect:
+		for _, option := range a.Options {
+			if strings.EqualFold(option, value) {
+				return option, true
+			}
+		}
+
+		return "", false
+	}
+
+	return value, true
+}
@@ -4,8 +4,51 @@ import "time"
 
 type Category struct {
 	Id        int       `json:"id"`
+	ParentId  *int      `json:"parent_id"`
 	Type      string    `json:"type"`
+	Slug      string    `json:"slug"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt time.Time `json:"deleted_at"`
 }
+
+type CategoryNode struct {
+	Id       int             `json:"id"`
+	Type     string          `json:"type"`
+	Slug     string          `json:"slug"`
+	Children []*CategoryNode `json:"children"`
+}
+
+// BuildCategoryTree nests the categories under their parents and returns the
+// roots. Categories whose parent isn't in the list are treated as roots.
+func BuildCategoryTree(categories []*Category) []*CategoryNode {
+
+	nodes := map[int]*CategoryNode{}
+
+	for _, category := range categories {
+		nodes[category.Id] = &CategoryNode{
+			Id:       category.Id,
+			Type:     category.Type,
+			Slug:     category.Slug,
+			Children: []*CategoryNode{},
+		}
+	}
+
+	roots := []*CategoryNode{}
+
+	for _, category := range categories {
+
+		node := nodes[category.Id]
+
+		if category.ParentId != nil {
+			if parent, ok := nodes[*category.ParentId]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+
+		roots = append(roots, node)
+	}
+
+	return roots
+}
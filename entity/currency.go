@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
+
+// ExchangeRate is how many units of Currency one unit of the base currency
+// buys. Rate is kept as a decimal string so no precision is lost.
+type ExchangeRate struct {
+	Currency  string    `json:"currency"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProductPrice overrides the converted price of a product in one currency.
+// The sale window of the product itself still applies.
+type ProductPrice struct {
+	Id             int         `json:"id"`
+	ProductId      int         `json:"product_id"`
+	Currency       string      `json:"currency"`
+	Price          money.Money `json:"price"`
+	CompareAtPrice money.Money `json:"compare_at_price"`
+	SalePrice      money.Money `json:"sale_price"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "time"
+
+const (
+	LocationTypeWarehouse = "warehouse"
+	LocationTypeStore     = "store"
+)
+
+// Location is a place stock is held at. Checkout allocates from active
+// locations, lower Priority first.
+type Location struct {
+	Id        int       `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Type      string    `json:"type"`
+	Country   string    `json:"country"`
+	Region    string    `json:"region"`
+	Priority  int       `json:"priority"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StockLevel is the stock of a product at one location, product.stock and
+// product.reserved are the sums over all locations.
+type StockLevel struct {
+	ProductId    int    `json:"product_id"`
+	LocationId   int    `json:"location_id"`
+	LocationCode string `json:"location_code"`
+	LocationName string `json:"location_name"`
+	Country      string `json:"country"`
+	Region       string `json:"region"`
+	Priority     int    `json:"priority"`
+	Active       bool   `json:"active"`
+	Stock        int    `json:"stock"`
+	Reserved     int    `json:"reserved"`
+}
+
+// Available returns the units that can still be allocated.
+func (sl *StockLevel) Available() int {
+	return sl.Stock - sl.Reserved
+}
+
+// Allocation is the part of an order reserved at, and later shipped from,
+// one location.
+type Allocation struct {
+	OrderId    int `json:"order_id"`
+	LocationId int `json:"location_id"`
+	Qty        int `json:"qty"`
+}
+
+type StockTransfer struct {
+	Id             int       `json:"id"`
+	ProductId      int       `json:"product_id"`
+	FromLocationId int       `json:"from_location_id"`
+	ToLocationId   int       `json:"to_location_id"`
+	Qty            int       `json:"qty"`
+	Reason         string    `json:"reason"`
+	ActorId        int       `json:"actor_id"`
+	ActorName      string    `json:"actor_name"`
+	CreatedAt      time.Time `json:"created_at"`
+}
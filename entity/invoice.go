@@ -0,0 +1,145 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"fmt"
+	"time"
+)
+
+const (
+	InvoiceKindInvoice    = "invoice"
+	InvoiceKindCreditNote = "credit_note"
+
+	InvoiceFormatHTML = "html"
+	InvoiceFormatPDF  = "pdf"
+	InvoiceFormatJSON = "json"
+)
+
+// invoiceNumberPrefixes start the number of each kind, every kind is
+// numbered in its own gap-free series
+var invoiceNumberPrefixes = map[string]string{
+	InvoiceKindInvoice:    "INV",
+	InvoiceKindCreditNote: "CN",
+}
+
+// Invoice is an issued invoice or credit note. Everything printed on it is
+// a snapshot taken when it was issued, issued documents never change and
+// corrections are issued as credit notes against the invoice.
+type Invoice struct {
+	Id            int               `json:"id"`
+	Kind          string            `json:"kind"`
+	Number        string            `json:"number"`
+	TransactionId int               `json:"transaction_id"`
+	UserId        int               `json:"user_id"`
+	InvoiceId     int               `json:"invoice_id,omitempty"`
+	InvoiceNumber string            `json:"invoice_number,omitempty"`
+	Seller        *InvoiceParty     `json:"seller"`
+	Buyer         *InvoiceParty     `json:"buyer"`
+	Currency      string            `json:"currency"`
+	Lines         []*InvoiceLine    `json:"lines"`
+	TaxLines      []*InvoiceTaxLine `json:"tax_lines"`
+	Subtotal      money.Money       `json:"subtotal"`
+	DiscountTotal money.Money       `json:"discount_total"`
+	TaxTotal      money.Money       `json:"tax_total"`
+	ShippingTotal money.Money       `json:"shipping_total"`
+	GrandTotal    money.Money       `json:"grand_total"`
+	Reason        string            `json:"reason,omitempty"`
+	IssuedAt      time.Time         `json:"issued_at"`
+}
+
+type InvoiceParty struct {
+	Name    string   `json:"name"`
+	Email   string   `json:"email,omitempty"`
+	TaxId   string   `json:"tax_id,omitempty"`
+	Address []string `json:"address,omitempty"`
+}
+
+type InvoiceLine struct {
+	Description string      `json:"description"`
+	Qty         int         `json:"qty"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Subtotal    money.Money `json:"subtotal"`
+	Discount    money.Money `json:"discount"`
+	Tax         money.Money `json:"tax"`
+	Total       money.Money `json:"total"`
+}
+
+// InvoiceTaxLine sums one tax rate over the whole invoice.
+type InvoiceTaxLine struct {
+	Name          string      `json:"name"`
+	Rate          int         `json:"rate"`
+	Inclusive     bool        `json:"inclusive"`
+	TaxableAmount money.Money `json:"taxable_amount"`
+	Amount        money.Money `json:"amount"`
+}
+
+// FormatInvoiceNumber turns the sequence number of a kind into the number
+// printed on the document, e.g. INV-000042.
+func FormatInvoiceNumber(kind string, sequence int) string {
+	return fmt.Sprintf("%s-%06d", invoiceNumberPrefixes[kind], sequence)
+}
+
+// Title is the heading of the printed document.
+func (i *Invoice) Title() string {
+
+	if i.Kind == InvoiceKindCreditNote {
+		return "Credit note"
+	}
+
+	return "Invoice"
+}
+
+// CreditNote credits amount of the invoice for reason. Every total is
+// prorated from the invoice, the subtotal takes up the rounding so the
+// note adds up to exactly amount.
+func (i *Invoice) CreditNote(amount money.Money, reason string) *Invoice {
+
+	prorate := func(m money.Money) money.Money {
+		return m.MulDivRound(int(amount.Amount), int(i.GrandTotal.Amount))
+	}
+
+	note := &Invoice{
+		Kind:          InvoiceKindCreditNote,
+		TransactionId: i.TransactionId,
+		UserId:        i.UserId,
+		InvoiceId:     i.Id,
+		InvoiceNumber: i.Number,
+		Seller:        i.Seller,
+		Buyer:         i.Buyer,
+		Currency:      i.Currency,
+		TaxLines:      []*InvoiceTaxLine{},
+		DiscountTotal: prorate(i.DiscountTotal),
+		ShippingTotal: prorate(i.ShippingTotal),
+		TaxTotal:      money.New(0, i.Currency),
+		GrandTotal:    amount,
+		Reason:        reason,
+	}
+
+	for _, line := range i.TaxLines {
+
+		taxLine := &InvoiceTaxLine{
+			Name:          line.Name,
+			Rate:          line.Rate,
+			Inclusive:     line.Inclusive,
+			TaxableAmount: prorate(line.TaxableAmount),
+			Amount:        prorate(line.Amount),
+		}
+
+		note.TaxLines = append(note.TaxLines, taxLine)
+		note.TaxTotal = note.TaxTotal.Add(taxLine.Amount)
+	}
+
+	note.Subtotal = amount.Add(note.DiscountTotal).Sub(note.TaxTotal).Sub(note.ShippingTotal)
+
+	note.Lines = []*InvoiceLine{{
+		Description: "Correction of invoice " + i.Number,
+		Qty:         1,
+		UnitPrice:   note.Subtotal,
+		Subtotal:    note.Subtotal,
+		Discount:    note.DiscountTotal,
+		Tax:         note.TaxTotal,
+		Total:       amount.Sub(note.ShippingTotal),
+	}}
+
+	return note
+}
@@ -0,0 +1,69 @@
+package entity
+
+import "time"
+
+const (
+	NotificationSignUp          = "sign_up"
+	NotificationOrderPlaced     = "order_placed"
+	NotificationPaymentReceived = "payment_received"
+	NotificationShipped         = "shipped"
+	NotificationRefund          = "refund"
+	NotificationPasswordReset   = "password_reset"
+	NotificationBackInStock     = "back_in_stock"
+	NotificationLowStock        = "low_stock"
+
+	LocaleEnglish    = "en"
+	LocaleIndonesian = "id"
+)
+
+// NotificationKinds lists every notification that has templates.
+var NotificationKinds = []string{
+	NotificationSignUp,
+	NotificationOrderPlaced,
+	NotificationPaymentReceived,
+	NotificationShipped,
+	NotificationRefund,
+	NotificationPasswordReset,
+	NotificationBackInStock,
+	NotificationLowStock,
+}
+
+// Notification is an email to a customer waiting to be rendered and sent.
+// It goes to UserId, or to the customer of TransactionId when the sender
+// only knows the transaction. Data is what the template of Kind renders.
+type Notification struct {
+	Id            int
+	Kind          string
+	UserId        int
+	TransactionId int
+	Data          any
+	Attempts      int
+}
+
+// Recipient is the customer a notification is rendered for.
+type Recipient struct {
+	Id       int    `json:"id"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Locale   string `json:"locale"`
+}
+
+// PasswordReset is the data of a password reset email, the token itself
+// is only ever sent to the customer.
+type PasswordReset struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// BackInStock is the data of the email to a customer waiting on a product.
+type BackInStock struct {
+	ProductId int
+	Name      string
+	Slug      string
+}
+
+// LowStockAlert is the data of the email to an admin about the products
+// that fell to their reorder threshold.
+type LowStockAlert struct {
+	Items []*LowStockItem
+}
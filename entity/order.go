@@ -1,42 +1,177 @@
 package entity
 
-import "time"
+import (
+	"fashion-api/pkg/money"
+	"sort"
+	"time"
+)
+
+const (
+	OrderStatusPending         = "pending"
+	OrderStatusAwaitingPayment = "awaiting_payment"
+	OrderStatusPaid            = "paid"
+	OrderStatusShipped         = "shipped"
+	OrderStatusDelivered       = "delivered"
+	OrderStatusCancelled       = "cancelled"
+
+	OrderEventPlaced        = "placed"
+	OrderEventCheckedOut    = "checked_out"
+	OrderEventPaid          = "paid"
+	OrderEventPaymentFailed = "payment_failed"
+	OrderEventRefunded      = "refunded"
+	OrderEventShipped       = "shipped"
+	OrderEventDelivered     = "delivered"
+	OrderEventCancelled     = "cancelled"
+)
 
 type Order struct {
-	Id         int       `json:"id"`
-	UserId     int       `json:"user_id"`
-	ProductId  int       `json:"product_id"`
-	Qty        int       `json:"qty"`
-	TotalPrice int       `json:"total_price"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
-	DeletedAt  time.Time `json:"deleted_at"`
+	Id                 int         `json:"id"`
+	UserId             int         `json:"user_id"`
+	ProductId          int         `json:"product_id"`
+	Qty                int         `json:"qty"`
+	TotalPrice         money.Money `json:"total_price"`
+	ShippingMethodId   int         `json:"shipping_method_id"`
+	ShippingMethodName string      `json:"shipping_method_name"`
+	ShippingCost       money.Money `json:"shipping_cost"`
+	Status             string      `json:"status"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
+	DeletedAt          time.Time   `json:"deleted_at"`
 }
 
 type OrderProduct struct {
-	Id    int    `json:"id"`
-	Name  string `json:"name"`
-	Price int    `json:"price"`
+	Id    int         `json:"id"`
+	Name  string      `json:"name"`
+	Price money.Money `json:"price"`
 }
 
 type OrderWithProductMapped struct {
-	Id         int           `json:"id"`
-	UserId     int           `json:"user_id"`
-	Product    *OrderProduct `json:"product"`
-	Qty        int           `json:"qty"`
-	TotalPrice int           `json:"total_price"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
+	Id         int            `json:"id"`
+	UserId     int            `json:"user_id"`
+	Product    *OrderProduct  `json:"product"`
+	Qty        int            `json:"qty"`
+	TotalPrice money.Money    `json:"total_price"`
+	Shipping   *OrderShipping `json:"shipping,omitempty"`
+	TaxLines   []*TaxLine     `json:"tax_lines"`
+	Status     string         `json:"status"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+}
+
+// OrderShipping is the shipping method chosen at checkout, absent until the
+// order has been checked out with one.
+type OrderShipping struct {
+	Method string      `json:"method"`
+	Cost   money.Money `json:"cost"`
 }
 
 type OrderWithProduct struct {
-	Id           int       `json:"id"`
-	UserId       int       `json:"user_id"`
-	ProductId    int       `json:"product_id"`
-	ProductName  string    `json:"product_name"`
-	ProductPrice int       `json:"product_price"`
-	Qty          int       `json:"qty"`
-	TotalPrice   int       `json:"total_price"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	Id                 int         `json:"id"`
+	UserId             int         `json:"user_id"`
+	ProductId          int         `json:"product_id"`
+	ProductName        string      `json:"product_name"`
+	ProductPrice       money.Money `json:"product_price"`
+	Qty                int         `json:"qty"`
+	TotalPrice         money.Money `json:"total_price"`
+	ShippingMethodName string      `json:"shipping_method_name"`
+	ShippingCost       money.Money `json:"shipping_cost"`
+	Status             string      `json:"status"`
+	CreatedAt          time.Time   `json:"created_at"`
+	UpdatedAt          time.Time   `json:"updated_at"`
+}
+
+// OrderDetail is everything a customer sees about one order. Items are the
+// snapshot taken at checkout, or the ordered product as priced when the
+// order was placed until it is checked out.
+type OrderDetail struct {
+	Id              int              `json:"id"`
+	UserId          int              `json:"user_id"`
+	Status          string           `json:"status"`
+	Currency        string           `json:"currency"`
+	Items           []*OrderItem     `json:"items"`
+	Subtotal        money.Money      `json:"subtotal"`
+	DiscountTotal   money.Money      `json:"discount_total"`
+	TaxTotal        money.Money      `json:"tax_total"`
+	ShippingTotal   money.Money      `json:"shipping_total"`
+	GrandTotal      money.Money      `json:"grand_total"`
+	TaxLines        []*TaxLine       `json:"tax_lines"`
+	Shipping        *OrderShipping   `json:"shipping,omitempty"`
+	ShippingAddress *Address         `json:"shipping_address,omitempty"`
+	TransactionId   int              `json:"transaction_id,omitempty"`
+	Payments        []*Payment       `json:"payments"`
+	Refunds         []*PaymentRefund `json:"refunds"`
+	Shipments       []*Shipment      `json:"shipments"`
+	Timeline        []*OrderEvent    `json:"timeline"`
+	CancelReason    string           `json:"cancel_reason,omitempty"`
+	CancelledAt     *time.Time       `json:"cancelled_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+type OrderItem struct {
+	ProductId   int         `json:"product_id"`
+	ProductName string      `json:"product_name"`
+	UnitPrice   money.Money `json:"unit_price"`
+	Qty         int         `json:"qty"`
+	Subtotal    money.Money `json:"subtotal"`
+	Discount    money.Money `json:"discount"`
+	Tax         money.Money `json:"tax"`
+	Total       money.Money `json:"total"`
+}
+
+// OrderEvent is one step in the life of an order.
+type OrderEvent struct {
+	Event string    `json:"event"`
+	Note  string    `json:"note,omitempty"`
+	At    time.Time `json:"at"`
+}
+
+// BuildTimeline derives the order history from the timestamps recorded on
+// the order, its payments, refunds and shipments, oldest first.
+func (d *OrderDetail) BuildTimeline(checkedOutAt *time.Time) {
+
+	events := []*OrderEvent{{Event: OrderEventPlaced, At: d.CreatedAt}}
+
+	if checkedOutAt != nil {
+		events = append(events, &OrderEvent{Event: OrderEventCheckedOut, At: *checkedOutAt})
+	}
+
+	for _, payment := range d.Payments {
+
+		at := payment.UpdatedAt
+
+		if payment.SettledAt != nil {
+			at = *payment.SettledAt
+		}
+
+		switch payment.Status {
+		case PaymentStatusCaptured, PaymentStatusRefunded, PaymentStatusPartiallyRefunded:
+			events = append(events, &OrderEvent{Event: OrderEventPaid, Note: payment.Amount.String() + " via " + payment.Provider, At: at})
+		case PaymentStatusFailed:
+			events = append(events, &OrderEvent{Event: OrderEventPaymentFailed, Note: payment.Provider, At: at})
+		}
+	}
+
+	for _, refund := range d.Refunds {
+		events = append(events, &OrderEvent{Event: OrderEventRefunded, Note: refund.Amount.String(), At: refund.CreatedAt})
+	}
+
+	for _, shipment := range d.Shipments {
+
+		events = append(events, &OrderEvent{Event: OrderEventShipped, Note: shipment.Carrier + " " + shipment.TrackingNumber, At: shipment.ShippedAt})
+
+		if shipment.DeliveredAt != nil {
+			events = append(events, &OrderEvent{Event: OrderEventDelivered, Note: shipment.Carrier + " " + shipment.TrackingNumber, At: *shipment.DeliveredAt})
+		}
+	}
+
+	if d.CancelledAt != nil {
+		events = append(events, &OrderEvent{Event: OrderEventCancelled, Note: d.CancelReason, At: *d.CancelledAt})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].At.Before(events[j].At)
+	})
+
+	d.Timeline = events
 }
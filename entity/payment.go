@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
+
+const (
+	PaymentStatusPending   = "pending"
+	PaymentStatusCaptured  = "captured"
+	PaymentStatusFailed    = "failed"
+	PaymentStatusRefunded  = "refunded"
+	PaymentStatusCancelled = "cancelled"
+
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+)
+
+type Payment struct {
+	Id             int         `json:"id"`
+	TransactionId  int         `json:"transaction_id"`
+	Provider       string      `json:"provider"`
+	IntentId       string      `json:"intent_id"`
+	Amount         money.Money `json:"amount"`
+	RefundedAmount money.Money `json:"refunded_amount"`
+	Status         string      `json:"status"`
+	// SettledAt is when the provider reported the payment captured or
+	// failed, only filled in on order details
+	SettledAt *time.Time `json:"settled_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type PaymentWebhookEvent struct {
+	Id         int       `json:"id"`
+	EventId    string    `json:"event_id"`
+	Type       string    `json:"type"`
+	IntentId   string    `json:"intent_id"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+type PaymentRefund struct {
+	Id               int         `json:"id"`
+	PaymentId        int         `json:"payment_id"`
+	ProviderRefundId string      `json:"provider_refund_id"`
+	Amount           money.Money `json:"amount"`
+	Reason           string      `json:"reason"`
+	CreatedAt        time.Time   `json:"created_at"`
+}
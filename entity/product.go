@@ -1,16 +1,114 @@
 package entity
 
-import "time"
+import (
+	"fashion-api/pkg/money"
+	"strings"
+	"time"
+)
+
+const (
+	ProductSortNewest      = "newest"
+	ProductSortPriceAsc    = "price_asc"
+	ProductSortPriceDesc   = "price_desc"
+	ProductSortBestSelling = "best_selling"
+	ProductSortName        = "name"
+)
 
 type Product struct {
-	Id          int       `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	CategoryId  int       `json:"category_id"`
-	Price       int       `json:"price"`
-	Stock       int       `json:"stock"`
-	Sold        int       `json:"sold"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	DeletedAt   time.Time `json:"deleted_at"`
+	Id               int               `json:"id"`
+	Name             string            `json:"name"`
+	Slug             string            `json:"slug"`
+	Sku              string            `json:"sku"`
+	Description      string            `json:"description"`
+	CategoryId       int               `json:"category_id"`
+	TaxClass         string            `json:"tax_class"`
+	Price            money.Money       `json:"price"`
+	CompareAtPrice   money.Money       `json:"compare_at_price"`
+	SalePrice        money.Money       `json:"sale_price"`
+	SaleStartsAt     *time.Time        `json:"sale_starts_at"`
+	SaleEndsAt       *time.Time        `json:"sale_ends_at"`
+	EffectivePrice   money.Money       `json:"effective_price"`
+	WeightGrams      int               `json:"weight_grams"`
+	LengthCm         int               `json:"length_cm"`
+	WidthCm          int               `json:"width_cm"`
+	HeightCm         int               `json:"height_cm"`
+	Stock            int               `json:"stock"`
+	Reserved         int               `json:"reserved"`
+	Sold             int               `json:"sold"`
+	ReorderThreshold int               `json:"reorder_threshold"`
+	RatingAverage    float64           `json:"rating_average"`
+	ReviewCount      int               `json:"review_count"`
+	Tags             []string          `json:"tags"`
+	Attributes       map[string]string `json:"attributes"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	DeletedAt        time.Time         `json:"deleted_at"`
+}
+
+type ProductPriceHistory struct {
+	Id             int         `json:"id"`
+	ProductId      int         `json:"product_id"`
+	Price          money.Money `json:"price"`
+	CompareAtPrice money.Money `json:"compare_at_price"`
+	SalePrice      money.Money `json:"sale_price"`
+	SaleStartsAt   *time.Time  `json:"sale_starts_at"`
+	SaleEndsAt     *time.Time  `json:"sale_ends_at"`
+	ChangedBy      int         `json:"changed_by"`
+	ChangedByName  string      `json:"changed_by_name"`
+	CreatedAt      time.Time   `json:"created_at"`
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and repeated ones.
+func NormalizeTags(tags []string) []string {
+
+	normalized, seen := []string{}, map[string]bool{}
+
+	for _, tag := range tags {
+
+		tag = strings.ToLower(strings.TrimSpace(tag))
+
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+// IsOnSale reports whether the scheduled sale price is in effect at the
+// given time. A sale without a start or end is open on that side.
+func (p *Product) IsOnSale(now time.Time) bool {
+
+	if !p.SalePrice.IsPositive() {
+		return false
+	}
+
+	if p.SaleStartsAt != nil && now.Before(*p.SaleStartsAt) {
+		return false
+	}
+
+	if p.SaleEndsAt != nil && !now.Before(*p.SaleEndsAt) {
+		return false
+	}
+
+	return true
+}
+
+// Available is the stock that can still be ordered, units held by
+// checkouts waiting for payment aren't.
+func (p *Product) Available() int {
+	return p.Stock - p.Reserved
+}
+
+// PriceAt returns the price a customer pays for one unit at the given time.
+func (p *Product) PriceAt(now time.Time) money.Money {
+
+	if p.IsOnSale(now) {
+		return p.SalePrice
+	}
+
+	return p.Price
 }
@@ -0,0 +1,47 @@
+package entity
+
+import "time"
+
+const (
+	ProductImportQueued    = "queued"
+	ProductImportRunning   = "running"
+	ProductImportCompleted = "completed"
+	ProductImportFailed    = "failed"
+
+	// ProductImportHeartbeat is how often the server running an import
+	// reports that it is still alive
+	ProductImportHeartbeat = 30 * time.Second
+
+	// ProductImportStaleAfter without a heartbeat an import is taken to have
+	// died with its server
+	ProductImportStaleAfter = 3 * ProductImportHeartbeat
+)
+
+// ProductImport tracks a CSV import of products, upserted by SKU. Dry runs
+// are only validated and never stored. InstanceId is the server the import
+// runs on, it keeps HeartbeatAt fresh until the import is done.
+type ProductImport struct {
+	Id            int                   `json:"id"`
+	Status        string                `json:"status"`
+	DryRun        bool                  `json:"dry_run"`
+	TotalRows     int                   `json:"total_rows"`
+	ProcessedRows int                   `json:"processed_rows"`
+	Created       int                   `json:"created"`
+	Updated       int                   `json:"updated"`
+	Failed        int                   `json:"failed"`
+	Errors        []*ProductImportError `json:"errors"`
+	CreatedBy     int                   `json:"created_by"`
+	InstanceId    string                `json:"instance_id"`
+	HeartbeatAt   *time.Time            `json:"heartbeat_at"`
+	CreatedAt     time.Time             `json:"created_at"`
+	StartedAt     *time.Time            `json:"started_at"`
+	FinishedAt    *time.Time            `json:"finished_at"`
+}
+
+// ProductImportError is why a row was skipped. Line counts the header as
+// line 1, like a spreadsheet does.
+type ProductImportError struct {
+	Line    int    `json:"line"`
+	Sku     string `json:"sku"`
+	Message string `json:"message"`
+}
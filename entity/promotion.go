@@ -0,0 +1,161 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
+
+const (
+	PromotionTypePercentage = "percentage"
+	PromotionTypeFixed      = "fixed"
+	PromotionTypeBuyXGetY   = "buy_x_get_y"
+)
+
+// Promotion is either a coupon, when it has a code, or an automatic
+// promotion that is evaluated for every checkout. Fixed values and the
+// minimum spend are minor units of Currency. A promotion on CategoryId
+// covers its SubcategoryIds too, they are resolved before Apply.
+type Promotion struct {
+	Id             int        `json:"id"`
+	Name           string     `json:"name"`
+	Code           string     `json:"code"`
+	Type           string     `json:"type"`
+	Currency       string     `json:"currency"`
+	Value          int        `json:"value"`
+	BuyQty         int        `json:"buy_qty"`
+	GetQty         int        `json:"get_qty"`
+	MinSpend       int        `json:"min_spend"`
+	UsageLimit     int        `json:"usage_limit"`
+	PerUserLimit   int        `json:"per_user_limit"`
+	CategoryId     int        `json:"category_id"`
+	SubcategoryIds []int      `json:"-"`
+	ProductId      int        `json:"product_id"`
+	StartsAt       *time.Time `json:"starts_at"`
+	EndsAt         *time.Time `json:"ends_at"`
+	Active         bool       `json:"active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type TransactionPromotion struct {
+	PromotionId int         `json:"promotion_id"`
+	Name        string      `json:"name"`
+	Code        string      `json:"code"`
+	Discount    money.Money `json:"discount"`
+}
+
+// IsRunning reports whether the promotion is enabled and inside its
+// validity window at the given time.
+func (p *Promotion) IsRunning(now time.Time) bool {
+
+	if !p.Active {
+		return false
+	}
+
+	if p.StartsAt != nil && now.Before(*p.StartsAt) {
+		return false
+	}
+
+	if p.EndsAt != nil && !now.Before(*p.EndsAt) {
+		return false
+	}
+
+	return true
+}
+
+func (p *Promotion) appliesTo(item *TransactionItem) bool {
+
+	if p.ProductId != 0 && p.ProductId != item.ProductId {
+		return false
+	}
+
+	if p.CategoryId != 0 && !p.inCategory(item.CategoryId) {
+		return false
+	}
+
+	return true
+}
+
+func (p *Promotion) inCategory(categoryId int) bool {
+
+	if categoryId == p.CategoryId {
+		return true
+	}
+
+	for _, subcategoryId := range p.SubcategoryIds {
+		if subcategoryId == categoryId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply adds the promotion's discount to the eligible items and returns the
+// total it granted. Fixed values and the minimum spend must already be in
+// the items' currency. Discounts never push a line below zero, and items
+// priced in more than one currency get no discount.
+func (p *Promotion) Apply(items []*TransactionItem) money.Money {
+
+	eligible := []*TransactionItem{}
+	currency := ""
+	eligibleSubtotal, subtotal := int64(0), int64(0)
+
+	for _, item := range items {
+
+		if currency == "" {
+			currency = item.UnitPrice.Currency
+		}
+
+		if zero := money.New(0, currency); !zero.SameCurrency(item.UnitPrice) || !zero.SameCurrency(item.Discount) {
+			return zero
+		}
+
+		lineSubtotal := item.UnitPrice.Mul(item.Qty).Amount
+		subtotal += lineSubtotal
+
+		if p.appliesTo(item) {
+			eligible = append(eligible, item)
+			eligibleSubtotal += lineSubtotal
+		}
+	}
+
+	total := money.New(0, currency)
+
+	if len(eligible) == 0 || eligibleSubtotal == 0 || subtotal < int64(p.MinSpend) {
+		return total
+	}
+
+	fixedLeft := money.New(int64(p.Value), currency)
+
+	for i, item := range eligible {
+
+		lineSubtotal := item.UnitPrice.Mul(item.Qty)
+		discount := money.New(0, currency)
+
+		switch p.Type {
+		case PromotionTypePercentage:
+			discount = lineSubtotal.Percent(p.Value)
+		case PromotionTypeFixed:
+			// spread a fixed amount over the eligible lines by their share,
+			// the last line takes whatever rounding left over
+			if i == len(eligible)-1 {
+				discount = fixedLeft
+			} else {
+				discount = money.New(int64(p.Value)*lineSubtotal.Amount/eligibleSubtotal, currency)
+				fixedLeft = fixedLeft.Sub(discount)
+			}
+		case PromotionTypeBuyXGetY:
+			if group := p.BuyQty + p.GetQty; p.GetQty > 0 && group > 0 {
+				discount = item.UnitPrice.Mul(item.Qty / group * p.GetQty)
+			}
+		}
+
+		discount = discount.Min(lineSubtotal.Sub(item.Discount))
+
+		item.Discount = item.Discount.Add(discount)
+		total = total.Add(discount)
+	}
+
+	return total
+}
@@ -0,0 +1,144 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"testing"
+)
+
+func TestPromotionAppliesToSubcategories(t *testing.T) {
+
+	cases := []struct {
+		name       string
+		categoryId int
+		discount   int64
+	}{
+		{name: "the promotion's category", categoryId: 1, discount: 1000},
+		{name: "a subcategory", categoryId: 2, discount: 1000},
+		{name: "a subcategory of a subcategory", categoryId: 3, discount: 1000},
+		{name: "another category", categoryId: 4, discount: 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			promotion := &Promotion{
+				Type:           PromotionTypePercentage,
+				Currency:       "USD",
+				Value:          10,
+				CategoryId:     1,
+				SubcategoryIds: []int{2, 3},
+			}
+
+			item := &TransactionItem{CategoryId: c.categoryId, UnitPrice: money.New(5000, "USD"), Qty: 2}
+
+			if discount := promotion.Apply([]*TransactionItem{item}); discount != money.New(c.discount, "USD") {
+				t.Fatalf("discount %s, want %s", discount, money.New(c.discount, "USD"))
+			}
+		})
+	}
+}
+
+func TestPromotionApply(t *testing.T) {
+
+	usd := func(amount int64) money.Money { return money.New(amount, "USD") }
+
+	cases := []struct {
+		name      string
+		promotion *Promotion
+		items     []*TransactionItem
+		total     money.Money
+		discounts []money.Money
+	}{
+		{
+			name:      "percentage of every line, rounded down",
+			promotion: &Promotion{Type: PromotionTypePercentage, Currency: "USD", Value: 10},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(5000), Qty: 2},
+				{ProductId: 2, UnitPrice: usd(2995), Qty: 1},
+			},
+			total:     usd(1299),
+			discounts: []money.Money{usd(1000), usd(299)},
+		},
+		{
+			name:      "fixed value spread by share, the last line takes the rounding",
+			promotion: &Promotion{Type: PromotionTypeFixed, Currency: "USD", Value: 1000},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(6000), Qty: 1},
+				{ProductId: 2, UnitPrice: usd(3000), Qty: 1},
+			},
+			total:     usd(1000),
+			discounts: []money.Money{usd(666), usd(334)},
+		},
+		{
+			name:      "fixed value larger than the line",
+			promotion: &Promotion{Type: PromotionTypeFixed, Currency: "USD", Value: 5000},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(3000), Qty: 1},
+			},
+			total:     usd(3000),
+			discounts: []money.Money{usd(3000)},
+		},
+		{
+			name:      "buy two get one for every full group",
+			promotion: &Promotion{Type: PromotionTypeBuyXGetY, Currency: "USD", BuyQty: 2, GetQty: 1},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(1000), Qty: 7},
+			},
+			total:     usd(2000),
+			discounts: []money.Money{usd(2000)},
+		},
+		{
+			name:      "only the promotion's product",
+			promotion: &Promotion{Type: PromotionTypePercentage, Currency: "USD", Value: 50, ProductId: 2},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(1000), Qty: 1},
+				{ProductId: 2, UnitPrice: usd(1000), Qty: 1},
+			},
+			total:     usd(500),
+			discounts: []money.Money{{}, usd(500)},
+		},
+		{
+			name:      "earlier discounts cap the line",
+			promotion: &Promotion{Type: PromotionTypePercentage, Currency: "USD", Value: 50},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(1000), Qty: 1, Discount: usd(900)},
+			},
+			total:     usd(100),
+			discounts: []money.Money{usd(1000)},
+		},
+		{
+			name:      "minimum spend not met",
+			promotion: &Promotion{Type: PromotionTypePercentage, Currency: "USD", Value: 10, MinSpend: 10000},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(4500), Qty: 2},
+			},
+			total:     usd(0),
+			discounts: []money.Money{{}},
+		},
+		{
+			name:      "items in more than one currency",
+			promotion: &Promotion{Type: PromotionTypePercentage, Currency: "USD", Value: 10},
+			items: []*TransactionItem{
+				{ProductId: 1, UnitPrice: usd(5000), Qty: 1},
+				{ProductId: 2, UnitPrice: money.New(50000, "IDR"), Qty: 1},
+			},
+			total:     usd(0),
+			discounts: []money.Money{{}, {}},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			if total := c.promotion.Apply(c.items); total != c.total {
+				t.Errorf("total %s, want %s", total, c.total)
+			}
+
+			for i, item := range c.items {
+				if item.Discount.Amount != c.discounts[i].Amount {
+					t.Errorf("item %d discount %s, want %s", i, item.Discount, c.discounts[i])
+				}
+			}
+		})
+	}
+}
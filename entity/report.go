@@ -0,0 +1,147 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"math"
+	"time"
+)
+
+// LowStockWindowDays is how far back sales are looked at to estimate how
+// fast a product sells, unless the report asks for another window.
+const LowStockWindowDays = 30
+
+const (
+	ReportIntervalDay   = "day"
+	ReportIntervalWeek  = "week"
+	ReportIntervalMonth = "month"
+
+	ReportRankRevenue = "revenue"
+	ReportRankUnits   = "units"
+
+	ReportFormatJSON = "json"
+	ReportFormatCSV  = "csv"
+)
+
+// LowStockItem is a product whose available stock fell to its reorder
+// threshold.
+type LowStockItem struct {
+	ProductId         int        `json:"product_id"`
+	Name              string     `json:"name"`
+	Slug              string     `json:"slug"`
+	Stock             int        `json:"stock"`
+	Reserved          int        `json:"reserved"`
+	Available         int        `json:"available"`
+	ReorderThreshold  int        `json:"reorder_threshold"`
+	Sold              int        `json:"sold"`
+	RecentSold        int        `json:"recent_sold"`
+	DailySales        float64    `json:"daily_sales"`
+	DaysUntilStockout *float64   `json:"days_until_stockout"`
+	AlertedAt         *time.Time `json:"alerted_at"`
+}
+
+// EstimateStockout works out DailySales from the units sold in the last
+// windowDays, or from Sold over the whole lifetime of a product that didn't
+// sell in the window. DaysUntilStockout stays nil if it never sold.
+func (i *LowStockItem) EstimateStockout(windowDays int, lifetimeDays float64) {
+
+	// a product younger than the window only had its lifetime to sell
+	window := math.Min(float64(windowDays), lifetimeDays)
+
+	switch {
+	case i.RecentSold > 0 && window > 0:
+		i.DailySales = float64(i.RecentSold) / window
+	case i.Sold > 0 && lifetimeDays > 0:
+		i.DailySales = float64(i.Sold) / lifetimeDays
+	default:
+		return
+	}
+
+	days := math.Round(math.Max(float64(i.Available), 0)/i.DailySales*10) / 10
+
+	i.DailySales = math.Round(i.DailySales*100) / 100
+	i.DaysUntilStockout = &days
+}
+
+// Sales sums the transactions paid in a period, whether or not they were
+// refunded later, and the refunds issued in it. Revenue is what is left
+// after the refunds. Amounts are in the currency of the report.
+type Sales struct {
+	Orders            int         `json:"orders"`
+	GrossRevenue      money.Money `json:"gross_revenue"`
+	Refunds           money.Money `json:"refunds"`
+	Revenue           money.Money `json:"revenue"`
+	Discount          money.Money `json:"discount"`
+	Tax               money.Money `json:"tax"`
+	Shipping          money.Money `json:"shipping"`
+	AverageOrderValue money.Money `json:"average_order_value"`
+}
+
+type SalesBucket struct {
+	Period time.Time `json:"period"`
+	Sales
+}
+
+// Add sums other into s and works out revenue and the average order value
+// again. The average is taken over what was sold, before refunds.
+func (s *Sales) Add(other *Sales) {
+
+	s.Orders += other.Orders
+	s.GrossRevenue = s.GrossRevenue.Add(other.GrossRevenue)
+	s.Refunds = s.Refunds.Add(other.Refunds)
+	s.Revenue = s.GrossRevenue.Sub(s.Refunds)
+	s.Discount = s.Discount.Add(other.Discount)
+	s.Tax = s.Tax.Add(other.Tax)
+	s.Shipping = s.Shipping.Add(other.Shipping)
+	s.AverageOrderValue = s.GrossRevenue.MulDivRound(1, s.Orders)
+}
+
+// TopSeller is a product or category ranked by what it sold. Units count
+// sales in every currency, Revenue only those in the report currency.
+type TopSeller struct {
+	Id      int         `json:"id"`
+	Name    string      `json:"name"`
+	Units   int         `json:"units"`
+	Orders  int         `json:"orders"`
+	Revenue money.Money `json:"revenue"`
+}
+
+// Customers splits the customers who paid into those paying for the first
+// time and those who paid before.
+type Customers struct {
+	Total     int `json:"customers"`
+	New       int `json:"new"`
+	Returning int `json:"returning"`
+}
+
+type CustomerBucket struct {
+	Period time.Time `json:"period"`
+	Customers
+}
+
+// Conversion follows placed orders, CheckedOut reached a transaction and
+// Paid got paid, refunds included. Rates are percentages of Orders.
+type Conversion struct {
+	Orders       int     `json:"orders"`
+	CheckedOut   int     `json:"checked_out"`
+	Paid         int     `json:"paid"`
+	CheckoutRate float64 `json:"checkout_rate"`
+	PaidRate     float64 `json:"paid_rate"`
+}
+
+type ConversionBucket struct {
+	Period time.Time `json:"period"`
+	Conversion
+}
+
+// Add sums other into c and works out the rates again.
+func (c *Conversion) Add(other *Conversion) {
+
+	c.Orders += other.Orders
+	c.CheckedOut += other.CheckedOut
+	c.Paid += other.Paid
+
+	if c.Orders > 0 {
+		c.CheckoutRate = math.Round(float64(c.CheckedOut)*10000/float64(c.Orders)) / 100
+		c.PaidRate = math.Round(float64(c.Paid)*10000/float64(c.Orders)) / 100
+	}
+}
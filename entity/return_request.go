@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
+
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+)
+
+type ReturnRequest struct {
+	Id            int         `json:"id"`
+	OrderId       int         `json:"order_id"`
+	TransactionId int         `json:"transaction_id"`
+	UserId        int         `json:"user_id"`
+	Qty           int         `json:"qty"`
+	Reason        string      `json:"reason"`
+	Status        string      `json:"status"`
+	RefundAmount  money.Money `json:"refund_amount"`
+	Restocked     bool        `json:"restocked"`
+	AdminNote     string      `json:"admin_note"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package entity
+
+import "time"
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusHidden   = "hidden"
+)
+
+const (
+	ReviewSortNewest     = "newest"
+	ReviewSortOldest     = "oldest"
+	ReviewSortRatingDesc = "rating_desc"
+	ReviewSortRatingAsc  = "rating_asc"
+)
+
+// Review is a customer's rating of a product they bought. Only approved
+// reviews are shown and counted towards the product rating.
+type Review struct {
+	Id        int       `json:"id"`
+	ProductId int       `json:"product_id"`
+	UserId    int       `json:"user_id"`
+	UserName  string    `json:"user_name"`
+	Rating    int       `json:"rating"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
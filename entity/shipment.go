@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+const (
+	ShipmentStatusShipped   = "shipped"
+	ShipmentStatusInTransit = "in_transit"
+	ShipmentStatusDelivered = "delivered"
+)
+
+// Shipment is one parcel sent out for an order. An order is shipped once
+// shipments cover its whole qty and delivered once all of them arrived.
+type Shipment struct {
+	Id             int             `json:"id"`
+	OrderId        int             `json:"order_id"`
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"tracking_number"`
+	Status         string          `json:"status"`
+	Items          []*ShipmentItem `json:"items"`
+	ShippedAt      time.Time       `json:"shipped_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type ShipmentItem struct {
+	Id         int `json:"id"`
+	ShipmentId int `json:"shipment_id"`
+	ProductId  int `json:"product_id"`
+	Qty        int `json:"qty"`
+}
+
+// CanMoveTo reports whether the shipment may progress to status, shipments
+// only ever move forward.
+func (s *Shipment) CanMoveTo(status string) bool {
+
+	order := map[string]int{
+		ShipmentStatusShipped:   0,
+		ShipmentStatusInTransit: 1,
+		ShipmentStatusDelivered: 2,
+	}
+
+	next, ok := order[status]
+
+	return ok && next >= order[s.Status] && s.Status != ShipmentStatusDelivered
+}
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
+
+const (
+	ShippingMethodFlat        = "flat"
+	ShippingMethodWeightBased = "weight_based"
+	ShippingMethodFreeOver    = "free_over"
+)
+
+type ShippingZone struct {
+	Id        int                     `json:"id"`
+	Name      string                  `json:"name"`
+	Locations []*ShippingZoneLocation `json:"locations"`
+	CreatedAt time.Time               `json:"created_at"`
+	UpdatedAt time.Time               `json:"updated_at"`
+}
+
+// ShippingZoneLocation covers a whole country, or one region of it when
+// Region is set.
+type ShippingZoneLocation struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+}
+
+// ShippingMethod prices a parcel shipped within its zone. Rate is the flat
+// or base price, PerKgRate is added per started kilogram for weight based
+// methods and FreeThreshold is the subtotal from which free_over methods
+// cost nothing. Amounts are minor units of Currency.
+type ShippingMethod struct {
+	Id             int       `json:"id"`
+	ZoneId         int       `json:"zone_id"`
+	Name           string    `json:"name"`
+	Type           string    `json:"type"`
+	Currency       string    `json:"currency"`
+	Rate           int       `json:"rate"`
+	PerKgRate      int       `json:"per_kg_rate"`
+	FreeThreshold  int       `json:"free_threshold"`
+	MaxWeightGrams int       `json:"max_weight_grams"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Parcel is what a shipping method is quoted for.
+type Parcel struct {
+	WeightGrams int         `json:"weight_grams"`
+	Subtotal    money.Money `json:"subtotal"`
+}
+
+type ShippingQuote struct {
+	MethodId    int         `json:"method_id"`
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	WeightGrams int         `json:"weight_grams"`
+	Cost        money.Money `json:"cost"`
+}
+
+// ChargeableWeight returns the weight carriers bill a product by, the
+// larger of its actual weight and its volumetric weight at 5000 cm3/kg.
+func (p *Product) ChargeableWeight() int {
+
+	volumetric := p.LengthCm * p.WidthCm * p.HeightCm / 5
+
+	if volumetric > p.WeightGrams {
+		return volumetric
+	}
+
+	return p.WeightGrams
+}
+
+// Cost prices the parcel, with Rate, PerKgRate and FreeThreshold already
+// expressed in the parcel's currency.
+func (m *ShippingMethod) Cost(parcel *Parcel) money.Money {
+
+	currency := parcel.Subtotal.Currency
+	rate := money.New(int64(m.Rate), currency)
+
+	switch m.Type {
+	case ShippingMethodWeightBased:
+		kilograms := (parcel.WeightGrams + 999) / 1000
+		return rate.Add(money.New(int64(m.PerKgRate), currency).Mul(kilograms))
+	case ShippingMethodFreeOver:
+		if parcel.Subtotal.Amount >= int64(m.FreeThreshold) {
+			return money.New(0, currency)
+		}
+	}
+
+	return rate
+}
+
+// Accepts reports whether the parcel is within the method's weight limit.
+func (m *ShippingMethod) Accepts(parcel *Parcel) bool {
+	return m.MaxWeightGrams == 0 || parcel.WeightGrams <= m.MaxWeightGrams
+}
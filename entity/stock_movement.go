@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+const (
+	StockMovementSale        = "sale"
+	StockMovementRestock     = "restock"
+	StockMovementAdjustment  = "adjustment"
+	StockMovementReturn      = "return"
+	StockMovementReservation = "reservation"
+	StockMovementTransfer    = "transfer"
+)
+
+// StockMovement is one entry of the append-only inventory ledger. Qty
+// changes the stock on hand and ReservedQty the units held for checkouts,
+// StockAfter and ReservedAfter are the balances at the location right
+// after it. Movements recorded before locations existed have none.
+type StockMovement struct {
+	Id            int       `json:"id"`
+	ProductId     int       `json:"product_id"`
+	LocationId    int       `json:"location_id"`
+	LocationCode  string    `json:"location_code"`
+	Type          string    `json:"type"`
+	Qty           int       `json:"qty"`
+	ReservedQty   int       `json:"reserved_qty"`
+	StockAfter    int       `json:"stock_after"`
+	ReservedAfter int       `json:"reserved_after"`
+	Reason        string    `json:"reason"`
+	Reference     string    `json:"reference"`
+	ActorId       int       `json:"actor_id"`
+	ActorName     string    `json:"actor_name"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// StockLedger compares the balances stored on a product with the sums of
+// its movements and of its location stock levels, they only differ if
+// stock was changed off the ledger.
+type StockLedger struct {
+	Stock            int  `json:"stock"`
+	Reserved         int  `json:"reserved"`
+	LedgerStock      int  `json:"ledger_stock"`
+	LedgerReserved   int  `json:"ledger_reserved"`
+	LocationStock    int  `json:"location_stock"`
+	LocationReserved int  `json:"location_reserved"`
+	Reconciled       bool `json:"reconciled"`
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
+
+const TaxClassStandard = "standard"
+
+// TaxRate applies to products of TaxClass shipped to Country, and to Region
+// within it when one is set. Rate is in basis points, 1100 is 11%.
+// Inclusive rates are already part of the listed price.
+type TaxRate struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Country   string    `json:"country"`
+	Region    string    `json:"region"`
+	TaxClass  string    `json:"tax_class"`
+	Rate      int       `json:"rate"`
+	Inclusive bool      `json:"inclusive"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaxLine is one tax charged on one line item, kept separately from the
+// item so finance can reconcile every rate that was applied.
+type TaxLine struct {
+	Id            int         `json:"id"`
+	OrderId       int         `json:"order_id"`
+	TransactionId int         `json:"transaction_id"`
+	ProductId     int         `json:"product_id"`
+	TaxRateId     int         `json:"tax_rate_id"`
+	Name          string      `json:"name"`
+	Rate          int         `json:"rate"`
+	Inclusive     bool        `json:"inclusive"`
+	TaxableAmount money.Money `json:"taxable_amount"`
+	Amount        money.Money `json:"amount"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
@@ -1,12 +1,147 @@
 package entity
 
-import "time"
+import (
+	"fashion-api/pkg/money"
+	"time"
+)
 
+const (
+	TransactionStatusPending = "pending"
+	TransactionStatusPaid    = "paid"
+	TransactionStatusFailed  = "failed"
+
+	TransactionStatusRefunded  = "refunded"
+	TransactionStatusCancelled = "cancelled"
+)
+
+// Transaction amounts are snapshots taken at checkout and never change
+// afterwards, even if the product is renamed or repriced.
 type Transaction struct {
-	Id        int       `json:"id"`
-	UserId    int       `json:"user_id"`
-	OrderId   int       `json:"order_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	DeletedAt time.Time `json:"deleted_at"`
+	Id            int                     `json:"id"`
+	UserId        int                     `json:"user_id"`
+	OrderId       int                     `json:"order_id"`
+	Status        string                  `json:"status"`
+	Currency      string                  `json:"currency"`
+	Subtotal      money.Money             `json:"subtotal"`
+	DiscountTotal money.Money             `json:"discount_total"`
+	TaxTotal      money.Money             `json:"tax_total"`
+	ShippingTotal money.Money             `json:"shipping_total"`
+	GrandTotal    money.Money             `json:"grand_total"`
+	Items         []*TransactionItem      `json:"items"`
+	Promotions    []*TransactionPromotion `json:"promotions"`
+	TaxLines      []*TaxLine              `json:"tax_lines"`
+	// ShippingAddress and ShippingMethod are written onto the order at
+	// checkout
+	ShippingAddress *Address       `json:"shipping_address"`
+	ShippingMethod  *ShippingQuote `json:"shipping_method"`
+	// Allocations are the locations the order qty is reserved at
+	Allocations []*Allocation `json:"-"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	DeletedAt   time.Time     `json:"deleted_at"`
+}
+
+type TransactionItem struct {
+	Id            int         `json:"id"`
+	TransactionId int         `json:"transaction_id"`
+	ProductId     int         `json:"product_id"`
+	ProductName   string      `json:"product_name"`
+	CategoryId    int         `json:"category_id"`
+	TaxClass      string      `json:"tax_class"`
+	UnitPrice     money.Money `json:"unit_price"`
+	Qty           int         `json:"qty"`
+	Subtotal      money.Money `json:"subtotal"`
+	Discount      money.Money `json:"discount"`
+	Tax           money.Money `json:"tax"`
+	TaxIncluded   money.Money `json:"tax_included"`
+	Total         money.Money `json:"total"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// InCurrency reports whether every price, discount, tax line and the
+// shipping cost is in the transaction's currency, which Promotion.Apply,
+// ApplyTaxLines and CalculateTotals rely on.
+func (t *Transaction) InCurrency() bool {
+
+	currency := money.New(0, t.Currency)
+
+	if !currency.SameCurrency(t.ShippingTotal) {
+		return false
+	}
+
+	for _, item := range t.Items {
+		for _, amount := range []money.Money{item.UnitPrice, item.Discount, item.Tax, item.TaxIncluded} {
+			if !currency.SameCurrency(amount) {
+				return false
+			}
+		}
+	}
+
+	for _, line := range t.TaxLines {
+		if !currency.SameCurrency(line.Amount) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ApplyTaxLines records the calculated tax lines and adds each one to the
+// item it was computed for. Tax that is included in the price is tracked
+// separately so it isn't charged twice.
+func (t *Transaction) ApplyTaxLines(lines []*TaxLine) {
+
+	zero := money.New(0, t.Currency)
+
+	for _, item := range t.Items {
+		item.Tax, item.TaxIncluded = zero, zero
+	}
+
+	for _, line := range lines {
+		for _, item := range t.Items {
+
+			if item.ProductId != line.ProductId {
+				continue
+			}
+
+			item.Tax = item.Tax.Add(line.Amount)
+
+			if line.Inclusive {
+				item.TaxIncluded = item.TaxIncluded.Add(line.Amount)
+			}
+
+			break
+		}
+	}
+
+	t.TaxLines = lines
+}
+
+// CalculateTotals derives every line and transaction amount from the item
+// snapshots, so callers only have to fill in prices, discounts and tax.
+func (t *Transaction) CalculateTotals() {
+
+	zero := money.New(0, t.Currency)
+
+	t.Subtotal, t.DiscountTotal, t.TaxTotal = zero, zero, zero
+	t.ShippingTotal = zero.Add(t.ShippingTotal)
+	t.GrandTotal = t.ShippingTotal
+
+	for _, item := range t.Items {
+		item.UnitPrice = zero.Add(item.UnitPrice)
+		item.Discount = zero.Add(item.Discount)
+		item.Tax = zero.Add(item.Tax)
+		item.TaxIncluded = zero.Add(item.TaxIncluded)
+
+		item.Subtotal = item.UnitPrice.Mul(item.Qty)
+		item.Total = item.Subtotal.Sub(item.Discount).Add(item.Tax).Sub(item.TaxIncluded)
+
+		t.Subtotal = t.Subtotal.Add(item.Subtotal)
+		t.DiscountTotal = t.DiscountTotal.Add(item.Discount)
+		t.TaxTotal = t.TaxTotal.Add(item.Tax)
+
+		// TaxTotal reports inclusive tax too, but only the line totals know
+		// which part of it is already in the price
+		t.GrandTotal = t.GrandTotal.Add(item.Total)
+	}
 }
@@ -0,0 +1,148 @@
+package entity
+
+import (
+	"fashion-api/pkg/money"
+	"testing"
+)
+
+func TestCalculateTotalsWithInclusiveAndExclusiveTax(t *testing.T) {
+
+	cases := []struct {
+		name       string
+		currency   string
+		unitPrice  int64
+		qty        int
+		discount   int64
+		shipping   int64
+		lines      []*TaxLine
+		tax        int64
+		itemTotal  int64
+		grandTotal int64
+	}{
+		{
+			name:      "exclusive tax is added to the line",
+			currency:  "USD",
+			unitPrice: 10000, qty: 2, discount: 2000, shipping: 500,
+			lines: []*TaxLine{
+				{ProductId: 1, Rate: 1000, Amount: money.New(1800, "USD")},
+			},
+			tax: 1800, itemTotal: 19800, grandTotal: 20300,
+		},
+		{
+			name:      "inclusive tax is already in the price",
+			currency:  "IDR",
+			unitPrice: 111000, qty: 1, shipping: 10000,
+			lines: []*TaxLine{
+				{ProductId: 1, Rate: 1100, Inclusive: true, Amount: money.New(11000, "IDR")},
+			},
+			tax: 11000, itemTotal: 111000, grandTotal: 121000,
+		},
+		{
+			name:      "inclusive and exclusive rates on one line",
+			currency:  "USD",
+			unitPrice: 11000, qty: 1,
+			lines: []*TaxLine{
+				{ProductId: 1, Rate: 1000, Inclusive: true, Amount: money.New(1000, "USD")},
+				{ProductId: 1, Rate: 500, Amount: money.New(550, "USD")},
+			},
+			tax: 1550, itemTotal: 11550, grandTotal: 11550,
+		},
+		{
+			name:      "lines for other products are ignored",
+			currency:  "USD",
+			unitPrice: 10000, qty: 1,
+			lines: []*TaxLine{
+				{ProductId: 2, Rate: 1000, Amount: money.New(1000, "USD")},
+			},
+			tax: 0, itemTotal: 10000, grandTotal: 10000,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			transaction := &Transaction{
+				Currency:      c.currency,
+				ShippingTotal: money.New(c.shipping, c.currency),
+				Items: []*TransactionItem{
+					{
+						ProductId: 1,
+						UnitPrice: money.New(c.unitPrice, c.currency),
+						Qty:       c.qty,
+						Discount:  money.New(c.discount, c.currency),
+					},
+				},
+			}
+
+			transaction.ApplyTaxLines(c.lines)
+			transaction.CalculateTotals()
+
+			item := transaction.Items[0]
+
+			totals := []struct {
+				name string
+				got  money.Money
+				want int64
+			}{
+				{"subtotal", transaction.Subtotal, c.unitPrice * int64(c.qty)},
+				{"discount", transaction.DiscountTotal, c.discount},
+				{"tax", transaction.TaxTotal, c.tax},
+				{"item total", item.Total, c.itemTotal},
+				{"grand total", transaction.GrandTotal, c.grandTotal},
+			}
+
+			for _, total := range totals {
+				if want := money.New(total.want, c.currency); total.got != want {
+					t.Errorf("%s %s, want %s", total.name, total.got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInCurrency(t *testing.T) {
+
+	cases := []struct {
+		name   string
+		modify func(transaction *Transaction)
+		want   bool
+	}{
+		{name: "everything in the transaction currency", modify: func(transaction *Transaction) {}, want: true},
+		{name: "zero values without a currency", modify: func(transaction *Transaction) {
+			transaction.ShippingTotal = money.Money{}
+			transaction.Items[0].Discount = money.Money{}
+		}, want: true},
+		{name: "price in another currency", modify: func(transaction *Transaction) {
+			transaction.Items[0].UnitPrice = money.New(10000, "USD")
+		}},
+		{name: "discount in another currency", modify: func(transaction *Transaction) {
+			transaction.Items[0].Discount = money.New(100, "USD")
+		}},
+		{name: "shipping in another currency", modify: func(transaction *Transaction) {
+			transaction.ShippingTotal = money.New(500, "USD")
+		}},
+		{name: "tax line in another currency", modify: func(transaction *Transaction) {
+			transaction.TaxLines = []*TaxLine{{ProductId: 1, Amount: money.New(100, "USD")}}
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			transaction := &Transaction{
+				Currency:      "IDR",
+				ShippingTotal: money.New(10000, "IDR"),
+				Items: []*TransactionItem{
+					{ProductId: 1, UnitPrice: money.New(150000, "IDR"), Qty: 1, Discount: money.New(15000, "IDR")},
+				},
+				TaxLines: []*TaxLine{{ProductId: 1, Amount: money.New(14850, "IDR")}},
+			}
+
+			c.modify(transaction)
+
+			if got := transaction.InCurrency(); got != c.want {
+				t.Fatalf("InCurrency is %t, want %t", got, c.want)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ type User struct {
 	Password  string    `json:"password"`
 	Role      string    `json:"role"`
 	Address   string    `json:"address"`
+	Locale    string    `json:"locale"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt time.Time `json:"deleted_at"`
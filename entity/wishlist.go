@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+type WishlistItem struct {
+	ProductId int       `json:"product_id"`
+	Product   *Product  `json:"product"`
+	AddedAt   time.Time `json:"added_at"`
+}
+
+// StockSubscription asks for a notification once an out of stock product
+// can be bought again, it is pending until NotifiedAt is set.
+type StockSubscription struct {
+	Id         int        `json:"id"`
+	UserId     int        `json:"user_id"`
+	ProductId  int        `json:"product_id"`
+	Email      string     `json:"email"`
+	FullName   string     `json:"full_name"`
+	NotifiedAt *time.Time `json:"notified_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -22,6 +24,34 @@ type appConfig struct {
 	RedisHost     string
 	RedisPort     string
 	RedisPass     string
+
+	PaymentProvider      string
+	PaymentWebhookSecret string
+
+	BaseCurrency string
+
+	TaxCalculator string
+
+	Notifier          string
+	NotifierOutboxDir string
+	NotificationRetry time.Duration
+	DefaultLocale     string
+	StorefrontURL     string
+	PasswordResetTTL  time.Duration
+	MailFrom          string
+	SMTPHost          string
+	SMTPPort          string
+	SMTPUsername      string
+	SMTPPassword      string
+
+	StockAllocation string
+
+	LowStockCheckInterval time.Duration
+
+	SellerName    string
+	SellerAddress string
+	SellerTaxId   string
+	SellerEmail   string
 }
 
 func LoadEnv() {
@@ -46,5 +76,52 @@ func NewAppConfig() *appConfig {
 		RedisHost:     os.Getenv("REDIS_HOST"),
 		RedisPort:     os.Getenv("REDIS_PORT"),
 		RedisPass:     os.Getenv("REDIS_PASSWORD"),
+
+		PaymentProvider:      os.Getenv("PAYMENT_PROVIDER"),
+		PaymentWebhookSecret: os.Getenv("PAYMENT_WEBHOOK_SECRET"),
+
+		BaseCurrency: strings.ToUpper(getEnvOrDefault("BASE_CURRENCY", "IDR")),
+
+		TaxCalculator: getEnvOrDefault("TAX_CALCULATOR", "table"),
+
+		Notifier:          getEnvOrDefault("NOTIFIER", "log"),
+		NotifierOutboxDir: getEnvOrDefault("NOTIFIER_OUTBOX_DIR", "outbox"),
+		NotificationRetry: getDurationOrDefault("NOTIFICATION_RETRY_DELAY", 30*time.Second),
+		DefaultLocale:     strings.ToLower(getEnvOrDefault("DEFAULT_LOCALE", "en")),
+		StorefrontURL:     strings.TrimSuffix(getEnvOrDefault("STOREFRONT_URL", "http://localhost:3000"), "/"),
+		PasswordResetTTL:  getDurationOrDefault("PASSWORD_RESET_TTL", time.Hour),
+		MailFrom:          getEnvOrDefault("MAIL_FROM", "Fashion Store <no-reply@fashion.com>"),
+		SMTPHost:          os.Getenv("SMTP_HOST"),
+		SMTPPort:          getEnvOrDefault("SMTP_PORT", "587"),
+		SMTPUsername:      os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:      os.Getenv("SMTP_PASSWORD"),
+
+		StockAllocation: getEnvOrDefault("STOCK_ALLOCATION", "priority"),
+
+		LowStockCheckInterval: getDurationOrDefault("LOW_STOCK_CHECK_INTERVAL", time.Hour),
+
+		SellerName:    getEnvOrDefault("SELLER_NAME", "Fashion Store"),
+		SellerAddress: os.Getenv("SELLER_ADDRESS"),
+		SellerTaxId:   os.Getenv("SELLER_TAX_ID"),
+		SellerEmail:   os.Getenv("SELLER_EMAIL"),
+	}
+}
+
+func getEnvOrDefault(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func getDurationOrDefault(key string, fallback time.Duration) time.Duration {
+
+	duration, err := time.ParseDuration(os.Getenv(key))
+
+	if err != nil || duration <= 0 {
+		return fallback
 	}
+
+	return duration
 }
@@ -8,7 +8,7 @@ import (
 	"fashion-api/entity"
 	"fashion-api/infra/config"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 var db *sql.DB
@@ -103,17 +103,731 @@ func handleRequiredTables() {
 			constraint fk_order_id foreign key (order_id) references "order"(id)
 		);`
 
+		alterTablePaymentStatusQuery = `
+			alter table "product" add column if not exists reserved int not null default 0;
+
+			alter table "order" add column if not exists status varchar(20) not null default 'pending';
+
+			alter table "transaction" add column if not exists status varchar(20) not null default 'paid';
+
+			update "order" set status = 'paid' where status = 'pending' and id in (select order_id from "transaction" where status = 'paid');
+		`
+
+		createTablePaymentQuery = `create table if not exists "payment" (
+			id serial primary key,
+			transaction_id int not null,
+			provider varchar(30) not null,
+			intent_id varchar(100) not null unique,
+			amount int not null,
+			refunded_amount int not null default 0,
+			status varchar(20) not null,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			constraint fk_transaction_id foreign key (transaction_id) references "transaction"(id)
+		);`
+
+		createTablePaymentWebhookEventQuery = `create table if not exists "payment_webhook_event" (
+			id serial primary key,
+			event_id varchar(100) not null unique,
+			type varchar(60) not null,
+			intent_id varchar(100) not null,
+			received_at timestamptz default now()
+		);`
+
+		createTablePaymentRefundQuery = `create table if not exists "payment_refund" (
+			id serial primary key,
+			payment_id int not null,
+			provider_refund_id varchar(100) not null,
+			amount int not null,
+			reason text,
+			created_at timestamptz default now(),
+			constraint fk_payment_id foreign key (payment_id) references payment(id)
+		);`
+
+		createTableReturnRequestQuery = `create table if not exists "return_request" (
+			id serial primary key,
+			order_id int not null,
+			transaction_id int not null,
+			user_id int not null,
+			qty int not null,
+			reason text not null,
+			status varchar(20) not null default 'requested',
+			refund_amount int not null default 0,
+			restocked boolean not null default false,
+			admin_note text,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			constraint fk_order_id foreign key (order_id) references "order"(id),
+			constraint fk_transaction_id foreign key (transaction_id) references "transaction"(id),
+			constraint fk_user_id foreign key (user_id) references "user"(id)
+		);`
+
+		alterTableTransactionAmountQuery = `
+			alter table "transaction" add column if not exists subtotal int not null default 0;
+
+			alter table "transaction" add column if not exists discount_total int not null default 0;
+
+			alter table "transaction" add column if not exists tax_total int not null default 0;
+
+			alter table "transaction" add column if not exists shipping_total int not null default 0;
+
+			alter table "transaction" add column if not exists grand_total int not null default 0;
+		`
+
+		createTableTransactionItemQuery = `create table if not exists "transaction_item" (
+			id serial primary key,
+			transaction_id int not null,
+			product_id int not null,
+			product_name varchar(60) not null,
+			unit_price int not null,
+			qty int not null,
+			subtotal int not null,
+			discount int not null default 0,
+			tax int not null default 0,
+			total int not null,
+			created_at timestamptz default now(),
+			constraint fk_transaction_id foreign key (transaction_id) references "transaction"(id),
+			constraint fk_product_id foreign key (product_id) references product(id)
+		);`
+
+		backfillTransactionSnapshotQuery = `
+			with backfilled as (
+				insert into transaction_item (transaction_id, product_id, product_name, unit_price, qty, subtotal, total, created_at)
+				select t.id, o.product_id, p.name, o.total_price / greatest(o.qty, 1), o.qty, o.total_price, o.total_price, t.created_at
+				from transaction as t join "order" as o on t.order_id = o.id join product as p on o.product_id = p.id
+				where not exists (select 1 from transaction_item as ti where ti.transaction_id = t.id)
+				returning transaction_id, subtotal, total
+			)
+			update transaction as t set subtotal = b.subtotal, grand_total = b.total
+			from backfilled as b where t.id = b.transaction_id;
+		`
+
+		createSnapshotTrigger = `
+			create or replace function preventTransactionSnapshotChange() returns trigger as $$
+			begin
+				if TG_TABLE_NAME = 'transaction_item' then
+					raise exception 'transaction items are immutable';
+				end if;
+
+				if TG_TABLE_NAME = 'tax_line' then
+					raise exception 'tax lines are immutable';
+				end if;
+
+				if (NEW.subtotal, NEW.discount_total, NEW.tax_total, NEW.shipping_total, NEW.grand_total) is distinct from
+					(OLD.subtotal, OLD.discount_total, OLD.tax_total, OLD.shipping_total, OLD.grand_total) then
+					raise exception 'transaction amounts are immutable';
+				end if;
+
+				return NEW;
+			end;
+			$$ language plpgsql;
+
+			create or replace trigger preventTransactionItemChange
+			before update on transaction_item
+			for each row
+			execute function preventTransactionSnapshotChange();
+
+			create or replace trigger preventTransactionAmountChange
+			before update on transaction
+			for each row
+			execute function preventTransactionSnapshotChange();
+		`
+
+		alterTableOrderCancellationQuery = `
+			alter table "order" add column if not exists cancelled_by int references "user"(id);
+
+			alter table "order" add column if not exists cancel_reason text;
+
+			alter table "order" add column if not exists cancelled_at timestamptz;
+		`
+
+		createTablePromotionQuery = `create table if not exists "promotion" (
+			id serial primary key,
+			name varchar(100) not null,
+			code varchar(40) unique,
+			type varchar(20) not null,
+			value int not null default 0,
+			buy_qty int not null default 0,
+			get_qty int not null default 0,
+			min_spend int not null default 0,
+			usage_limit int not null default 0,
+			per_user_limit int not null default 0,
+			category_id int,
+			product_id int,
+			starts_at timestamptz,
+			ends_at timestamptz,
+			active boolean not null default true,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			deleted_at timestamptz,
+			constraint fk_category_id foreign key (category_id) references category(id),
+			constraint fk_product_id foreign key (product_id) references product(id)
+		);`
+
+		createTablePromotionRedemptionQuery = `create table if not exists "promotion_redemption" (
+			id serial primary key,
+			promotion_id int not null,
+			transaction_id int not null,
+			user_id int not null,
+			discount int not null,
+			created_at timestamptz default now(),
+			constraint fk_promotion_id foreign key (promotion_id) references promotion(id),
+			constraint fk_transaction_id foreign key (transaction_id) references "transaction"(id),
+			constraint fk_user_id foreign key (user_id) references "user"(id)
+		);`
+
+		alterTableTransactionItemCategoryQuery = `
+			alter table transaction_item add column if not exists category_id int;
+
+			alter table transaction_item disable trigger preventTransactionItemChange;
+
+			update transaction_item as ti set category_id = p.category_id from product as p where ti.product_id = p.id and ti.category_id is null;
+
+			alter table transaction_item enable trigger preventTransactionItemChange;
+		`
+
+		alterTableProductSalePriceQuery = `
+			alter table "product" add column if not exists compare_at_price int not null default 0;
+
+			alter table "product" add column if not exists sale_price int not null default 0;
+
+			alter table "product" add column if not exists sale_starts_at timestamptz;
+
+			alter table "product" add column if not exists sale_ends_at timestamptz;
+		`
+
+		createTableProductPriceHistoryQuery = `create table if not exists "product_price_history" (
+			id serial primary key,
+			product_id int not null,
+			price int not null,
+			compare_at_price int not null default 0,
+			sale_price int not null default 0,
+			sale_starts_at timestamptz,
+			sale_ends_at timestamptz,
+			changed_by int,
+			created_at timestamptz default now(),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint fk_changed_by foreign key (changed_by) references "user"(id)
+		);
+
+		insert into product_price_history (product_id, price, compare_at_price, sale_price, sale_starts_at, sale_ends_at, created_at)
+		select p.id, p.price, p.compare_at_price, p.sale_price, p.sale_starts_at, p.sale_ends_at, p.created_at from product as p
+		where not exists (select 1 from product_price_history as h where h.product_id = p.id);
+		`
+
+		// %[1]s is the quoted base currency, every amount stored before
+		// multi-currency support was in it
+		alterTableCurrencyQuery = `
+			alter table "product" add column if not exists currency varchar(3) not null default %[1]s;
+
+			alter table "product_price_history" add column if not exists currency varchar(3) not null default %[1]s;
+
+			alter table "order" add column if not exists currency varchar(3) not null default %[1]s;
+
+			alter table "transaction" add column if not exists currency varchar(3) not null default %[1]s;
+
+			alter table "payment" add column if not exists currency varchar(3) not null default %[1]s;
+
+			alter table "promotion" add column if not exists currency varchar(3) not null default %[1]s;
+		`
+
+		createTableExchangeRateQuery = `create table if not exists "exchange_rate" (
+			currency varchar(3) primary key,
+			rate numeric(20, 10) not null check (rate > 0),
+			updated_at timestamptz default now()
+		);`
+
+		createTableProductPriceQuery = `create table if not exists "product_price" (
+			id serial primary key,
+			product_id int not null,
+			currency varchar(3) not null,
+			price int not null,
+			compare_at_price int not null default 0,
+			sale_price int not null default 0,
+			updated_at timestamptz default now(),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint product_price_product_currency_key unique (product_id, currency)
+		);`
+
+		alterTableTaxClassQuery = `
+			alter table "product" add column if not exists tax_class varchar(30) not null default 'standard';
+
+			alter table "transaction_item" add column if not exists tax_class varchar(30) not null default 'standard';
+
+			alter table "transaction_item" add column if not exists tax_included int not null default 0;
+		`
+
+		alterTableOrderShippingAddressQuery = `
+			alter table "order" add column if not exists shipping_recipient varchar(100);
+
+			alter table "order" add column if not exists shipping_line1 text;
+
+			alter table "order" add column if not exists shipping_city varchar(100);
+
+			alter table "order" add column if not exists shipping_region varchar(100);
+
+			alter table "order" add column if not exists shipping_postal_code varchar(20);
+
+			alter table "order" add column if not exists shipping_country varchar(2);
+		`
+
+		createTableTaxRateQuery = `create table if not exists "tax_rate" (
+			id serial primary key,
+			name varchar(100) not null,
+			country varchar(2) not null,
+			region varchar(100) not null default '',
+			tax_class varchar(30) not null default 'standard',
+			rate int not null check (rate >= 0),
+			inclusive boolean not null default false,
+			active boolean not null default true,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			deleted_at timestamptz
+		);`
+
+		createTableTaxLineQuery = `create table if not exists "tax_line" (
+			id serial primary key,
+			order_id int not null,
+			transaction_id int not null,
+			product_id int not null,
+			tax_rate_id int,
+			name varchar(100) not null,
+			rate int not null,
+			inclusive boolean not null default false,
+			taxable_amount int not null,
+			amount int not null,
+			created_at timestamptz default now(),
+			constraint fk_order_id foreign key (order_id) references "order"(id),
+			constraint fk_transaction_id foreign key (transaction_id) references "transaction"(id),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint fk_tax_rate_id foreign key (tax_rate_id) references tax_rate(id)
+		);`
+
+		createTaxLineTrigger = `
+			create or replace trigger preventTaxLineChange
+			before update on tax_line
+			for each row
+			execute function preventTransactionSnapshotChange();
+		`
+
+		alterTableProductDimensionQuery = `
+			alter table "product" add column if not exists weight_grams int not null default 0;
+
+			alter table "product" add column if not exists length_cm int not null default 0;
+
+			alter table "product" add column if not exists width_cm int not null default 0;
+
+			alter table "product" add column if not exists height_cm int not null default 0;
+		`
+
+		createTableShippingZoneQuery = `create table if not exists "shipping_zone" (
+			id serial primary key,
+			name varchar(100) not null,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			deleted_at timestamptz
+		);`
+
+		createTableShippingZoneLocationQuery = `create table if not exists "shipping_zone_location" (
+			id serial primary key,
+			zone_id int not null,
+			country varchar(2) not null,
+			region varchar(100) not null default '',
+			constraint fk_zone_id foreign key (zone_id) references shipping_zone(id) on delete cascade
+		);`
+
+		createTableShippingMethodQuery = `create table if not exists "shipping_method" (
+			id serial primary key,
+			zone_id int not null,
+			name varchar(100) not null,
+			type varchar(20) not null,
+			currency varchar(3) not null,
+			rate int not null default 0,
+			per_kg_rate int not null default 0,
+			free_threshold int not null default 0,
+			max_weight_grams int not null default 0,
+			active boolean not null default true,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			deleted_at timestamptz,
+			constraint fk_zone_id foreign key (zone_id) references shipping_zone(id)
+		);`
+
+		alterTableOrderShippingMethodQuery = `
+			alter table "order" add column if not exists shipping_method_id int references shipping_method(id);
+
+			alter table "order" add column if not exists shipping_method_name varchar(100);
+
+			alter table "order" add column if not exists shipping_cost int not null default 0;
+		`
+
+		createTableShipmentQuery = `create table if not exists "shipment" (
+			id serial primary key,
+			order_id int not null,
+			carrier varchar(50) not null,
+			tracking_number varchar(100) not null,
+			status varchar(20) not null default 'shipped',
+			shipped_at timestamptz not null default now(),
+			delivered_at timestamptz,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			constraint fk_order_id foreign key (order_id) references "order"(id)
+		);`
+
+		createTableShipmentItemQuery = `create table if not exists "shipment_item" (
+			id serial primary key,
+			shipment_id int not null,
+			product_id int not null,
+			qty int not null check (qty > 0),
+			constraint fk_shipment_id foreign key (shipment_id) references shipment(id) on delete cascade,
+			constraint fk_product_id foreign key (product_id) references product(id)
+		);`
+
+		// category types only have to be unique among their siblings once
+		// categories are nested, Women > Tops and Men > Tops can coexist
+		alterTableCategoryParentQuery = `
+			alter table "category" add column if not exists parent_id int references category(id);
+
+			alter table "category" drop constraint if exists category_type_key;
+
+			create unique index if not exists category_parent_type_key on "category" (coalesce(parent_id, 0), type) where deleted_at is null;
+
+			create index if not exists category_parent_id_idx on "category" (parent_id);
+		`
+
+		// existing rows get their id appended so the backfilled slugs are
+		// unique without having to look at each other
+		alterTableSlugQuery = `
+			alter table "product" add column if not exists slug varchar(160);
+
+			update "product" set slug = coalesce(nullif(trim(both '-' from regexp_replace(lower(name), '[^a-z0-9]+', '-', 'g')), ''), 'product') || '-' || id where slug is null;
+
+			alter table "product" alter column slug set not null;
+
+			create unique index if not exists product_slug_key on "product" (slug);
+
+			alter table "category" add column if not exists slug varchar(160);
+
+			update "category" set slug = coalesce(nullif(trim(both '-' from regexp_replace(lower(type), '[^a-z0-9]+', '-', 'g')), ''), 'category') || '-' || id where slug is null;
+
+			alter table "category" alter column slug set not null;
+
+			create unique index if not exists category_slug_key on "category" (slug);
+		`
+
+		createTableSlugRedirectQuery = `create table if not exists "slug_redirect" (
+			id serial primary key,
+			entity varchar(20) not null,
+			old_slug varchar(160) not null,
+			entity_id int not null,
+			created_at timestamptz default now(),
+			unique (entity, old_slug)
+		);`
+
+		createTableProductTagQuery = `create table if not exists "product_tag" (
+			product_id int not null,
+			tag varchar(50) not null,
+			primary key (product_id, tag),
+			constraint fk_product_id foreign key (product_id) references product(id)
+		);
+
+		create index if not exists product_tag_tag_idx on "product_tag" (tag);`
+
+		createTableAttributeQuery = `create table if not exists "attribute" (
+			id serial primary key,
+			code varchar(50) not null unique,
+			name varchar(100) not null,
+			type varchar(20) not null,
+			options text[] not null default '{}',
+			filterable boolean not null default true,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now()
+		);`
+
+		createTableProductAttributeQuery = `create table if not exists "product_attribute" (
+			product_id int not null,
+			attribute_id int not null,
+			value varchar(255) not null,
+			primary key (product_id, attribute_id),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint fk_attribute_id foreign key (attribute_id) references attribute(id) on delete cascade
+		);
+
+		create index if not exists product_attribute_value_idx on "product_attribute" (attribute_id, value);`
+
+		createTableReviewQuery = `create table if not exists "review" (
+			id serial primary key,
+			product_id int not null,
+			user_id int not null,
+			rating smallint not null check (rating between 1 and 5),
+			title varchar(100) not null,
+			body text not null,
+			status varchar(20) not null default 'pending',
+			created_at timestamptz default now(),
+			updated_at timestamptz default now(),
+			unique (product_id, user_id),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint fk_user_id foreign key (user_id) references "user"(id)
+		);
+
+		create index if not exists review_product_status_idx on "review" (product_id, status);`
+
+		alterTableProductRatingQuery = `
+			alter table "product" add column if not exists rating_average numeric(3, 2) not null default 0;
+
+			alter table "product" add column if not exists review_count int not null default 0;
+		`
+
+		createTableWishlistQuery = `create table if not exists "wishlist" (
+			user_id int not null,
+			product_id int not null,
+			created_at timestamptz default now(),
+			primary key (user_id, product_id),
+			constraint fk_user_id foreign key (user_id) references "user"(id),
+			constraint fk_product_id foreign key (product_id) references product(id)
+		);`
+
+		createTableStockSubscriptionQuery = `create table if not exists "stock_subscription" (
+			id serial primary key,
+			user_id int not null,
+			product_id int not null,
+			notified_at timestamptz,
+			created_at timestamptz default now(),
+			unique (user_id, product_id),
+			constraint fk_user_id foreign key (user_id) references "user"(id),
+			constraint fk_product_id foreign key (product_id) references product(id)
+		);
+
+		create index if not exists stock_subscription_pending_idx on "stock_subscription" (product_id) where notified_at is null;`
+
+		// stock_movement is the ledger behind product.stock and
+		// product.reserved, products that predate it open with their
+		// current balance
+		createTableStockMovementQuery = `create table if not exists "stock_movement" (
+			id serial primary key,
+			product_id int not null,
+			type varchar(20) not null,
+			qty int not null default 0,
+			reserved_qty int not null default 0,
+			stock_after int not null,
+			reserved_after int not null,
+			reason text not null default '',
+			reference varchar(100) not null default '',
+			actor_id int,
+			created_at timestamptz default now(),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint fk_actor_id foreign key (actor_id) references "user"(id)
+		);
+
+		create index if not exists stock_movement_product_idx on "stock_movement" (product_id, id);
+
+		create or replace function preventStockMovementChange() returns trigger as $$
+		begin
+			raise exception 'stock_movement is append-only';
+		end;
+		$$ language plpgsql;
+
+		create or replace trigger stockMovementAppendOnly
+		before update or delete on stock_movement
+		for each row
+		execute function preventStockMovementChange();
+
+		insert into stock_movement (product_id, type, qty, reserved_qty, stock_after, reserved_after, reason)
+		select p.id, 'adjustment', p.stock, p.reserved, p.stock, p.reserved, 'opening balance' from product as p
+		where not exists (select 1 from stock_movement as m where m.product_id = p.id);`
+
+		createTableLocationQuery = `create table if not exists "location" (
+			id serial primary key,
+			code varchar(30) not null unique,
+			name varchar(100) not null,
+			type varchar(20) not null default 'warehouse',
+			country varchar(2) not null default '',
+			region varchar(100) not null default '',
+			priority int not null default 0,
+			active boolean not null default true,
+			created_at timestamptz default now(),
+			updated_at timestamptz default now()
+		);
+
+		insert into location (code, name) select 'main', 'Main warehouse' where not exists (select 1 from location);`
+
+		// stock held before locations existed is moved to the first
+		// location, together with the orders reserving or shipping it
+		createTableStockLevelQuery = `create table if not exists "stock_level" (
+			product_id int not null,
+			location_id int not null,
+			stock int not null default 0,
+			reserved int not null default 0,
+			primary key (product_id, location_id),
+			constraint stock_level_balance check (reserved >= 0 and stock >= reserved),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint fk_location_id foreign key (location_id) references location(id)
+		);
+
+		create table if not exists "order_allocation" (
+			order_id int not null,
+			location_id int not null,
+			qty int not null,
+			primary key (order_id, location_id),
+			constraint fk_order_id foreign key (order_id) references "order"(id),
+			constraint fk_location_id foreign key (location_id) references location(id)
+		);
+
+		alter table "stock_movement" add column if not exists location_id int references location(id);
+
+		insert into stock_level (product_id, location_id, stock, reserved)
+		select p.id, (select id from location order by priority asc, id asc limit 1), p.stock, p.reserved from product as p
+		where not exists (select 1 from stock_level as sl where sl.product_id = p.id);
+
+		insert into order_allocation (order_id, location_id, qty)
+		select o.id, (select id from location order by priority asc, id asc limit 1), o.qty from "order" as o
+		where o.status in ('awaiting_payment', 'paid', 'shipped', 'delivered') and not exists (select 1 from order_allocation as a where a.order_id = o.id);`
+
+		createTableStockTransferQuery = `create table if not exists "stock_transfer" (
+			id serial primary key,
+			product_id int not null,
+			from_location_id int not null,
+			to_location_id int not null,
+			qty int not null check (qty > 0),
+			reason text not null,
+			actor_id int,
+			created_at timestamptz default now(),
+			constraint fk_product_id foreign key (product_id) references product(id),
+			constraint fk_from_location_id foreign key (from_location_id) references location(id),
+			constraint fk_to_location_id foreign key (to_location_id) references location(id),
+			constraint fk_actor_id foreign key (actor_id) references "user"(id)
+		);`
+
+		// low_stock_alerted_at is set once admins were alerted and cleared
+		// when the product is restocked above its threshold
+		alterTableProductReorderQuery = `
+			alter table "product" add column if not exists reorder_threshold int not null default 0 check (reorder_threshold >= 0);
+
+			alter table "product" add column if not exists low_stock_alerted_at timestamptz;
+		`
+
+		alterTableProductSkuQuery = `alter table "product" add column if not exists sku varchar(64) unique;`
+
+		createTableProductImportQuery = `create table if not exists "product_import" (
+			id serial primary key,
+			status varchar(20) not null default 'queued',
+			total_rows int not null default 0,
+			processed_rows int not null default 0,
+			created_count int not null default 0,
+			updated_count int not null default 0,
+			failed_count int not null default 0,
+			errors jsonb not null default '[]',
+			created_by int,
+			instance_id varchar(64) not null default '',
+			heartbeat_at timestamptz default now(),
+			created_at timestamptz default now(),
+			started_at timestamptz,
+			finished_at timestamptz,
+			constraint fk_created_by foreign key (created_by) references "user"(id)
+		);`
+
+		// numbers are taken from invoice_counter inside the issuing
+		// transaction, the row lock keeps every series gap-free
+		createTableInvoiceQuery = `create table if not exists "invoice_counter" (
+			kind varchar(20) primary key,
+			last_number int not null default 0
+		);
+
+		insert into invoice_counter (kind) values ('invoice'), ('credit_note') on conflict (kind) do nothing;
+
+		create table if not exists "invoice" (
+			id serial primary key,
+			kind varchar(20) not null,
+			number varchar(30) not null unique,
+			transaction_id int not null,
+			user_id int not null,
+			invoice_id int,
+			currency varchar(3) not null,
+			seller jsonb not null,
+			buyer jsonb not null,
+			lines jsonb not null,
+			tax_lines jsonb not null,
+			subtotal int not null,
+			discount_total int not null,
+			tax_total int not null,
+			shipping_total int not null,
+			grand_total int not null,
+			reason text,
+			issued_at timestamptz default now(),
+			constraint fk_transaction_id foreign key (transaction_id) references "transaction"(id),
+			constraint fk_user_id foreign key (user_id) references "user"(id),
+			constraint fk_invoice_id foreign key (invoice_id) references invoice(id)
+		);
+
+		create unique index if not exists invoice_transaction_key on invoice (transaction_id) where kind = 'invoice';
+
+		create or replace function preventInvoiceChange() returns trigger as $$
+		begin
+			raise exception 'issued invoices are immutable';
+		end;
+		$$ language plpgsql;
+
+		create or replace trigger preventInvoiceChange
+		before update or delete on invoice
+		for each row
+		execute function preventInvoiceChange();
+		`
+
+		// only a hash of the emailed token is kept, a token is spent once it
+		// is used or another reset of the same user goes through
+		createTablePasswordResetQuery = `alter table "user" add column if not exists locale varchar(10) not null default 'en';
+
+		create table if not exists "password_reset" (
+			id serial primary key,
+			user_id int not null,
+			token_hash varchar(64) not null unique,
+			expires_at timestamptz not null,
+			used_at timestamptz,
+			created_at timestamptz default now(),
+			constraint fk_user_id foreign key (user_id) references "user"(id)
+		);
+		`
+
+		// notifications wait here until they are sent, so a restart doesn't
+		// lose them. next_attempt_at is pushed back each time one is claimed
+		createTableNotificationOutboxQuery = `create table if not exists "notification_outbox" (
+			id serial primary key,
+			kind varchar(30) not null,
+			user_id int,
+			transaction_id int,
+			data jsonb,
+			attempts int not null default 0,
+			next_attempt_at timestamptz not null default now(),
+			created_at timestamptz default now()
+		);
+
+		create index if not exists notification_outbox_next_attempt_at_idx on "notification_outbox" (next_attempt_at);
+		`
+
+		// captured_at is when the money arrived, sales are reported by it.
+		// Payments captured before the column existed take the time of their
+		// first webhook, like the order detail shows
+		alterTablePaymentCapturedQuery = `
+			alter table "payment" add column if not exists captured_at timestamptz;
+
+			update "payment" as p set captured_at = coalesce((select min(e.received_at) from payment_webhook_event as e where e.intent_id = p.intent_id), p.updated_at)
+			where p.captured_at is null and p.status in ('captured', 'partially_refunded', 'refunded');
+
+			create index if not exists payment_captured_at_idx on "payment" (captured_at);
+		`
+
 		createTrigger = `
 			create or replace function removeOrderWhenTransactionSuccess() returns trigger as $$
 			begin
-				update "order" set deleted_at = now(), updated_at = now() where id = NEW.order_id;
+				update "order" set status = 'paid', deleted_at = now(), updated_at = now() where id = NEW.order_id;
 				return NEW;
 			end;
 			$$ language plpgsql;
 
 			create or replace trigger removeOrder
-			after insert on transaction
+			after insert or update of status on transaction
 			for each row
+			when (NEW.status = 'paid')
 			execute function removeOrderWhenTransactionSuccess();
 		`
 
@@ -145,6 +859,261 @@ func handleRequiredTables() {
 		return
 	}
 
+	if _, err := db.Exec(alterTablePaymentStatusQuery); err != nil {
+		log.Fatal("error occured while add payment status columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTablePaymentQuery); err != nil {
+		log.Fatal("error occured while create table payment : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTablePaymentWebhookEventQuery); err != nil {
+		log.Fatal("error occured while create table payment_webhook_event : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTablePaymentRefundQuery); err != nil {
+		log.Fatal("error occured while create table payment_refund : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableReturnRequestQuery); err != nil {
+		log.Fatal("error occured while create table return_request : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableTransactionAmountQuery); err != nil {
+		log.Fatal("error occured while add transaction amount columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableTransactionItemQuery); err != nil {
+		log.Fatal("error occured while create table transaction_item : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(backfillTransactionSnapshotQuery); err != nil {
+		log.Fatal("error occured while backfill transaction snapshot : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createSnapshotTrigger); err != nil {
+		log.Fatal("error occured while create snapshot trigger : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableOrderCancellationQuery); err != nil {
+		log.Fatal("error occured while add order cancellation columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTablePromotionQuery); err != nil {
+		log.Fatal("error occured while create table promotion : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTablePromotionRedemptionQuery); err != nil {
+		log.Fatal("error occured while create table promotion_redemption : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableTransactionItemCategoryQuery); err != nil {
+		log.Fatal("error occured while add transaction item category column : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableProductSalePriceQuery); err != nil {
+		log.Fatal("error occured while add product sale price columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableProductPriceHistoryQuery); err != nil {
+		log.Fatal("error occured while create table product_price_history : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(fmt.Sprintf(alterTableCurrencyQuery, pq.QuoteLiteral(config.NewAppConfig().BaseCurrency))); err != nil {
+		log.Fatal("error occured while add currency columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableExchangeRateQuery); err != nil {
+		log.Fatal("error occured while create table exchange_rate : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableProductPriceQuery); err != nil {
+		log.Fatal("error occured while create table product_price : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableTaxClassQuery); err != nil {
+		log.Fatal("error occured while add tax class columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableOrderShippingAddressQuery); err != nil {
+		log.Fatal("error occured while add order shipping address columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableTaxRateQuery); err != nil {
+		log.Fatal("error occured while create table tax_rate : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableTaxLineQuery); err != nil {
+		log.Fatal("error occured while create table tax_line : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTaxLineTrigger); err != nil {
+		log.Fatal("error occured while create tax_line trigger : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableProductDimensionQuery); err != nil {
+		log.Fatal("error occured while add product dimension columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableShippingZoneQuery); err != nil {
+		log.Fatal("error occured while create table shipping_zone : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableShippingZoneLocationQuery); err != nil {
+		log.Fatal("error occured while create table shipping_zone_location : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableShippingMethodQuery); err != nil {
+		log.Fatal("error occured while create table shipping_method : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableOrderShippingMethodQuery); err != nil {
+		log.Fatal("error occured while add order shipping method columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableShipmentQuery); err != nil {
+		log.Fatal("error occured while create table shipment : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableShipmentItemQuery); err != nil {
+		log.Fatal("error occured while create table shipment_item : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableCategoryParentQuery); err != nil {
+		log.Fatal("error occured while add category parent column : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableSlugQuery); err != nil {
+		log.Fatal("error occured while add slug columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableSlugRedirectQuery); err != nil {
+		log.Fatal("error occured while create table slug_redirect : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableProductTagQuery); err != nil {
+		log.Fatal("error occured while create table product_tag : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableAttributeQuery); err != nil {
+		log.Fatal("error occured while create table attribute : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableProductAttributeQuery); err != nil {
+		log.Fatal("error occured while create table product_attribute : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableReviewQuery); err != nil {
+		log.Fatal("error occured while create table review : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableProductRatingQuery); err != nil {
+		log.Fatal("error occured while add product rating columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableWishlistQuery); err != nil {
+		log.Fatal("error occured while create table wishlist : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableStockSubscriptionQuery); err != nil {
+		log.Fatal("error occured while create table stock_subscription : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableStockMovementQuery); err != nil {
+		log.Fatal("error occured while create table stock_movement : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableLocationQuery); err != nil {
+		log.Fatal("error occured while create table location : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableStockLevelQuery); err != nil {
+		log.Fatal("error occured while create table stock_level : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableStockTransferQuery); err != nil {
+		log.Fatal("error occured while create table stock_transfer : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableProductReorderQuery); err != nil {
+		log.Fatal("error occured while add product reorder columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTableProductSkuQuery); err != nil {
+		log.Fatal("error occured while add product sku columns : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableProductImportQuery); err != nil {
+		log.Fatal("error occured while create table product_import : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableInvoiceQuery); err != nil {
+		log.Fatal("error occured while create table invoice : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTablePasswordResetQuery); err != nil {
+		log.Fatal("error occured while create table password_reset : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(createTableNotificationOutboxQuery); err != nil {
+		log.Fatal("error occured while create table notification_outbox : ", err.Error())
+		return
+	}
+
+	if _, err := db.Exec(alterTablePaymentCapturedQuery); err != nil {
+		log.Fatal("error occured while alter table payment : ", err.Error())
+		return
+	}
+
 	if _, err := db.Exec(createTrigger); err != nil {
 		log.Fatal("error occured while create trigger : ", err.Error())
 		return
@@ -0,0 +1,71 @@
+package inventory_allocator
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"sort"
+)
+
+// Allocator decides which locations an order ships from. Another strategy
+// can be plugged in by implementing it and registering it in NewAllocator.
+type Allocator interface {
+	Name() string
+	Allocate(levels []*entity.StockLevel, qty int, address *entity.Address) ([]*entity.Allocation, exception.Exception)
+}
+
+func NewAllocator(name string) Allocator {
+	switch name {
+	case "closest":
+		return NewClosestAllocator()
+	default:
+		return NewPriorityAllocator()
+	}
+}
+
+// allocate fills qty from levels in the given order, skipping inactive
+// locations and the ones without available stock.
+func allocate(levels []*entity.StockLevel, qty int) ([]*entity.Allocation, exception.Exception) {
+
+	allocations := []*entity.Allocation{}
+
+	for _, level := range levels {
+
+		if qty == 0 {
+			break
+		}
+
+		if !level.Active || level.Available() <= 0 {
+			continue
+		}
+
+		take := min(level.Available(), qty)
+
+		allocations = append(allocations, &entity.Allocation{
+			LocationId: level.LocationId,
+			Qty:        take,
+		})
+
+		qty -= take
+	}
+
+	if qty > 0 {
+		return nil, exception.NewBadRequestError("qty is greater than stock")
+	}
+
+	return allocations, nil
+}
+
+func sortByPriority(levels []*entity.StockLevel) []*entity.StockLevel {
+
+	sorted := append([]*entity.StockLevel{}, levels...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Priority != sorted[j].Priority {
+			return sorted[i].Priority < sorted[j].Priority
+		}
+
+		return sorted[i].LocationId < sorted[j].LocationId
+	})
+
+	return sorted
+}
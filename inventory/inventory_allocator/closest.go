@@ -0,0 +1,49 @@
+package inventory_allocator
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"sort"
+	"strings"
+)
+
+type closestAllocator struct{}
+
+// NewClosestAllocator returns an allocator that prefers locations in the
+// region, then the country, the order ships to. Ties fall back to priority.
+func NewClosestAllocator() Allocator {
+	return &closestAllocator{}
+}
+
+// Name implements Allocator.
+func (ca *closestAllocator) Name() string {
+	return "closest"
+}
+
+// Allocate implements Allocator.
+func (ca *closestAllocator) Allocate(levels []*entity.StockLevel, qty int, address *entity.Address) ([]*entity.Allocation, exception.Exception) {
+
+	sorted := sortByPriority(levels)
+
+	if address != nil {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return distance(sorted[i], address) < distance(sorted[j], address)
+		})
+	}
+
+	return allocate(sorted, qty)
+}
+
+// distance is 0 for the same region, 1 for the same country and 2 otherwise
+func distance(level *entity.StockLevel, address *entity.Address) int {
+
+	if !strings.EqualFold(level.Country, address.Country) {
+		return 2
+	}
+
+	if level.Region != "" && strings.EqualFold(level.Region, address.Region) {
+		return 0
+	}
+
+	return 1
+}
@@ -0,0 +1,24 @@
+package inventory_allocator
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type priorityAllocator struct{}
+
+// NewPriorityAllocator returns an allocator that drains locations in
+// priority order, whatever the order ships to.
+func NewPriorityAllocator() Allocator {
+	return &priorityAllocator{}
+}
+
+// Name implements Allocator.
+func (pa *priorityAllocator) Name() string {
+	return "priority"
+}
+
+// Allocate implements Allocator.
+func (pa *priorityAllocator) Allocate(levels []*entity.StockLevel, qty int, address *entity.Address) ([]*entity.Allocation, exception.Exception) {
+	return allocate(sortByPriority(levels), qty)
+}
@@ -0,0 +1,208 @@
+package inventory_handler
+
+import (
+	"encoding/json"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/inventory/inventory_service"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type inventoryHandler struct {
+	is inventory_service.InventoryService
+}
+
+type InventoryHandler interface {
+	AddLocation(w http.ResponseWriter, r *http.Request)
+	FetchLocations(w http.ResponseWriter, r *http.Request)
+	FetchLocationById(w http.ResponseWriter, r *http.Request)
+	ModifyLocation(w http.ResponseWriter, r *http.Request)
+	FetchStockLevels(w http.ResponseWriter, r *http.Request)
+	Transfer(w http.ResponseWriter, r *http.Request)
+	FetchTransfers(w http.ResponseWriter, r *http.Request)
+}
+
+func NewInventoryHandler(is inventory_service.InventoryService) InventoryHandler {
+	return &inventoryHandler{
+		is: is,
+	}
+}
+
+// AddLocation implements InventoryHandler.
+func (ih *inventoryHandler) AddLocation(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	payload := &dto.LocationPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ih.is.AddLocation(payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchLocations implements InventoryHandler.
+func (ih *inventoryHandler) FetchLocations(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	res, err := ih.is.FetchLocations()
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchLocationById implements InventoryHandler.
+func (ih *inventoryHandler) FetchLocationById(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ih.is.FetchLocationById(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// ModifyLocation implements InventoryHandler.
+func (ih *inventoryHandler) ModifyLocation(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	payload := &dto.LocationPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ih.is.ModifyLocation(id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchStockLevels implements InventoryHandler.
+func (ih *inventoryHandler) FetchStockLevels(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ih.is.FetchStockLevels(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Transfer implements InventoryHandler.
+func (ih *inventoryHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	user := r.Context().Value("userData").(*entity.User)
+
+	payload := &dto.StockTransferPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ih.is.Transfer(user.Id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchTransfers implements InventoryHandler.
+func (ih *inventoryHandler) FetchTransfers(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	productId, _ := strconv.Atoi(r.URL.Query().Get("product_id"))
+
+	res, err := ih.is.FetchTransfers(productId)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
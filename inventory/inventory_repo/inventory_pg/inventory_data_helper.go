@@ -0,0 +1,29 @@
+package inventory_pg
+
+import "fashion-api/entity"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLocation(row scanner) (*entity.Location, error) {
+
+	location := entity.Location{}
+
+	if err := row.Scan(
+		&location.Id,
+		&location.Code,
+		&location.Name,
+		&location.Type,
+		&location.Country,
+		&location.Region,
+		&location.Priority,
+		&location.Active,
+		&location.CreatedAt,
+		&location.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &location, nil
+}
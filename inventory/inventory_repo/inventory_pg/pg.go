@@ -0,0 +1,302 @@
+package inventory_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/inventory/inventory_repo"
+	"fashion-api/pkg/exception"
+	"fmt"
+	"log"
+)
+
+type inventoryPg struct {
+	db *sql.DB
+}
+
+const (
+	addLocationQuery = `insert into location (code, name, type, country, region, priority, active) values ($1, $2, $3, $4, $5, $6, $7)`
+
+	fetchLocationsQuery = `select id, code, name, type, country, region, priority, active, created_at, updated_at from location order by priority asc, id asc`
+
+	fetchLocationByIdQuery = `select id, code, name, type, country, region, priority, active, created_at, updated_at from location where id = $1`
+
+	modifyLocationQuery = `update location set code = $2, name = $3, type = $4, country = $5, region = $6, priority = $7, active = $8, updated_at = now() where id = $1`
+
+	duplicateLocationCodeError = `pq: duplicate key value violates unique constraint "location_code_key"`
+
+	// every location is listed, the ones without stock of the product at 0
+	fetchStockLevelsQuery = `select $1::int, l.id, l.code, l.name, l.country, l.region, l.priority, l.active, coalesce(sl.stock, 0), coalesce(sl.reserved, 0) from location as l left join stock_level as sl on sl.location_id = l.id and sl.product_id = $1 order by l.priority asc, l.id asc`
+
+	fetchLocationStockQuery = `select coalesce(sum(stock), 0) from stock_level where location_id = $1`
+
+	addTransferQuery = `insert into stock_transfer (product_id, from_location_id, to_location_id, qty, reason, actor_id) values ($1, $2, $3, $4, $5, $6) returning id`
+
+	// only units that aren't reserved can leave a location
+	transferOutQuery = `with moved as (
+			update stock_level set stock = stock - $3 where product_id = $1 and location_id = $2 and stock - reserved >= $3 returning product_id, location_id, stock, reserved
+		)
+		insert into stock_movement (product_id, location_id, type, qty, stock_after, reserved_after, reason, reference, actor_id)
+		select product_id, location_id, 'transfer', -$3, stock, reserved, $4, $5, $6 from moved`
+
+	transferInQuery = `with moved as (
+			insert into stock_level (product_id, location_id, stock) values ($1, $2, $3) on conflict (product_id, location_id) do update set stock = stock_level.stock + excluded.stock returning product_id, location_id, stock, reserved
+		)
+		insert into stock_movement (product_id, location_id, type, qty, stock_after, reserved_after, reason, reference, actor_id)
+		select product_id, location_id, 'transfer', $3, stock, reserved, $4, $5, $6 from moved`
+
+	fetchTransfersQuery = `select t.id, t.product_id, t.from_location_id, t.to_location_id, t.qty, t.reason, coalesce(t.actor_id, 0), coalesce(u.full_name, ''), t.created_at from stock_transfer as t left join "user" as u on t.actor_id = u.id where ($1 = 0 or t.product_id = $1) order by t.id desc`
+)
+
+func NewInventoryPg(db *sql.DB) inventory_repo.InventoryRepo {
+	return &inventoryPg{
+		db: db,
+	}
+}
+
+// AddLocation implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) AddLocation(location *entity.Location) exception.Exception {
+
+	if _, err := pg.db.Exec(
+		addLocationQuery,
+		location.Code,
+		location.Name,
+		location.Type,
+		location.Country,
+		location.Region,
+		location.Priority,
+		location.Active,
+	); err != nil {
+		if err.Error() == duplicateLocationCodeError {
+			log.Println(err.Error())
+			return exception.NewConflictError("location code is already in use")
+		}
+
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchLocations implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) FetchLocations() ([]*entity.Location, exception.Exception) {
+
+	locations := []*entity.Location{}
+
+	rows, err := pg.db.Query(fetchLocationsQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		location, err := scanLocation(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		locations = append(locations, location)
+	}
+
+	return locations, nil
+}
+
+// FetchLocationById implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) FetchLocationById(id int) (*entity.Location, exception.Exception) {
+
+	location, err := scanLocation(pg.db.QueryRow(fetchLocationByIdQuery, id))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("location not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return location, nil
+}
+
+// ModifyLocation implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) ModifyLocation(id int, location *entity.Location) exception.Exception {
+
+	if _, err := pg.db.Exec(
+		modifyLocationQuery,
+		id,
+		location.Code,
+		location.Name,
+		location.Type,
+		location.Country,
+		location.Region,
+		location.Priority,
+		location.Active,
+	); err != nil {
+		if err.Error() == duplicateLocationCodeError {
+			log.Println(err.Error())
+			return exception.NewConflictError("location code is already in use")
+		}
+
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchStockLevels implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) FetchStockLevels(productId int) ([]*entity.StockLevel, exception.Exception) {
+
+	levels := []*entity.StockLevel{}
+
+	rows, err := pg.db.Query(fetchStockLevelsQuery, productId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		level := entity.StockLevel{}
+
+		if err := rows.Scan(
+			&level.ProductId,
+			&level.LocationId,
+			&level.LocationCode,
+			&level.LocationName,
+			&level.Country,
+			&level.Region,
+			&level.Priority,
+			&level.Active,
+			&level.Stock,
+			&level.Reserved,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		levels = append(levels, &level)
+	}
+
+	return levels, nil
+}
+
+// FetchLocationStock implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) FetchLocationStock(locationId int) (int, exception.Exception) {
+
+	stock := 0
+
+	if err := pg.db.QueryRow(fetchLocationStockQuery, locationId).Scan(&stock); err != nil {
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return stock, nil
+}
+
+// Transfer implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) Transfer(transfer *entity.StockTransfer) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	actor := sql.NullInt64{Int64: int64(transfer.ActorId), Valid: transfer.ActorId != 0}
+
+	if err := tx.QueryRow(
+		addTransferQuery,
+		transfer.ProductId,
+		transfer.FromLocationId,
+		transfer.ToLocationId,
+		transfer.Qty,
+		transfer.Reason,
+		actor,
+	).Scan(&transfer.Id); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	reference := fmt.Sprintf("transfer #%d", transfer.Id)
+
+	res, err := tx.Exec(transferOutQuery, transfer.ProductId, transfer.FromLocationId, transfer.Qty, transfer.Reason, reference, actor)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewBadRequestError("qty is greater than the available stock at the source location")
+	}
+
+	if _, err := tx.Exec(transferInQuery, transfer.ProductId, transfer.ToLocationId, transfer.Qty, transfer.Reason, reference, actor); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchTransfers implements inventory_repo.InventoryRepo.
+func (pg *inventoryPg) FetchTransfers(productId int) ([]*entity.StockTransfer, exception.Exception) {
+
+	transfers := []*entity.StockTransfer{}
+
+	rows, err := pg.db.Query(fetchTransfersQuery, productId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		transfer := entity.StockTransfer{}
+
+		if err := rows.Scan(
+			&transfer.Id,
+			&transfer.ProductId,
+			&transfer.FromLocationId,
+			&transfer.ToLocationId,
+			&transfer.Qty,
+			&transfer.Reason,
+			&transfer.ActorId,
+			&transfer.ActorName,
+			&transfer.CreatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		transfers = append(transfers, &transfer)
+	}
+
+	return transfers, nil
+}
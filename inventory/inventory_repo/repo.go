@@ -0,0 +1,17 @@
+package inventory_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type InventoryRepo interface {
+	AddLocation(location *entity.Location) exception.Exception
+	FetchLocations() ([]*entity.Location, exception.Exception)
+	FetchLocationById(id int) (*entity.Location, exception.Exception)
+	ModifyLocation(id int, location *entity.Location) exception.Exception
+	FetchStockLevels(productId int) ([]*entity.StockLevel, exception.Exception)
+	FetchLocationStock(locationId int) (int, exception.Exception)
+	Transfer(transfer *entity.StockTransfer) exception.Exception
+	FetchTransfers(productId int) ([]*entity.StockTransfer, exception.Exception)
+}
@@ -0,0 +1,212 @@
+package inventory_service
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/inventory/inventory_repo"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/product/product_repo"
+	"net/http"
+	"strings"
+)
+
+type inventoryService struct {
+	ir inventory_repo.InventoryRepo
+	pr product_repo.ProductRepo
+}
+
+type InventoryService interface {
+	AddLocation(payload *dto.LocationPayload) (*helper.ResponseBody, exception.Exception)
+	FetchLocations() (*helper.ResponseBody, exception.Exception)
+	FetchLocationById(id int) (*helper.ResponseBody, exception.Exception)
+	ModifyLocation(id int, payload *dto.LocationPayload) (*helper.ResponseBody, exception.Exception)
+	FetchStockLevels(productId int) (*helper.ResponseBody, exception.Exception)
+	Transfer(userId int, payload *dto.StockTransferPayload) (*helper.ResponseBody, exception.Exception)
+	FetchTransfers(productId int) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewInventoryService(ir inventory_repo.InventoryRepo, pr product_repo.ProductRepo) InventoryService {
+	return &inventoryService{
+		ir: ir,
+		pr: pr,
+	}
+}
+
+// AddLocation implements InventoryService.
+func (is *inventoryService) AddLocation(payload *dto.LocationPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if err := is.ir.AddLocation(payloadToLocation(payload)); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "location successfully added",
+		Data:    nil,
+	}, nil
+}
+
+// FetchLocations implements InventoryService.
+func (is *inventoryService) FetchLocations() (*helper.ResponseBody, exception.Exception) {
+
+	locations, err := is.ir.FetchLocations()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "locations successfully fetched",
+		Data:    locations,
+	}, nil
+}
+
+// FetchLocationById implements InventoryService.
+func (is *inventoryService) FetchLocationById(id int) (*helper.ResponseBody, exception.Exception) {
+
+	location, err := is.ir.FetchLocationById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "location successfully fetched",
+		Data:    location,
+	}, nil
+}
+
+// ModifyLocation implements InventoryService.
+func (is *inventoryService) ModifyLocation(id int, payload *dto.LocationPayload) (*helper.ResponseBody, exception.Exception) {
+
+	current, err := is.ir.FetchLocationById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// checkout no longer allocates from an inactive location, so whatever
+	// it holds has to be transferred out first
+	if current.Active && !payload.Active {
+
+		stock, err := is.ir.FetchLocationStock(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if stock > 0 {
+			return nil, exception.NewConflictError("location still holds stock, transfer it out before deactivating")
+		}
+	}
+
+	if err := is.ir.ModifyLocation(id, payloadToLocation(payload)); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "location successfully modified",
+		Data:    nil,
+	}, nil
+}
+
+// FetchStockLevels implements InventoryService.
+func (is *inventoryService) FetchStockLevels(productId int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := is.pr.FetchById(productId); err != nil {
+		return nil, err
+	}
+
+	levels, err := is.ir.FetchStockLevels(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "stock levels successfully fetched",
+		Data:    levels,
+	}, nil
+}
+
+// Transfer implements InventoryService.
+func (is *inventoryService) Transfer(userId int, payload *dto.StockTransferPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if payload.Qty <= 0 {
+		return nil, exception.NewBadRequestError("Qty must be greater than 0")
+	}
+
+	if payload.FromLocationId == payload.ToLocationId {
+		return nil, exception.NewBadRequestError("stock can only be transferred between two different locations")
+	}
+
+	if _, err := is.pr.FetchById(payload.ProductId); err != nil {
+		return nil, err
+	}
+
+	if _, err := is.ir.FetchLocationById(payload.FromLocationId); err != nil {
+		return nil, err
+	}
+
+	to, err := is.ir.FetchLocationById(payload.ToLocationId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !to.Active {
+		return nil, exception.NewBadRequestError("stock can't be transferred to an inactive location")
+	}
+
+	transfer := &entity.StockTransfer{
+		ProductId:      payload.ProductId,
+		FromLocationId: payload.FromLocationId,
+		ToLocationId:   payload.ToLocationId,
+		Qty:            payload.Qty,
+		Reason:         strings.TrimSpace(payload.Reason),
+		ActorId:        userId,
+	}
+
+	if err := is.ir.Transfer(transfer); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "stock successfully transferred",
+		Data:    transfer,
+	}, nil
+}
+
+// FetchTransfers implements InventoryService.
+func (is *inventoryService) FetchTransfers(productId int) (*helper.ResponseBody, exception.Exception) {
+
+	transfers, err := is.ir.FetchTransfers(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "stock transfers successfully fetched",
+		Data:    transfers,
+	}, nil
+}
+
+func payloadToLocation(payload *dto.LocationPayload) *entity.Location {
+	return &entity.Location{
+		Code:     strings.ToLower(strings.TrimSpace(payload.Code)),
+		Name:     strings.TrimSpace(payload.Name),
+		Type:     payload.Type,
+		Country:  strings.ToUpper(payload.Country),
+		Region:   strings.TrimSpace(payload.Region),
+		Priority: payload.Priority,
+		Active:   payload.Active,
+	}
+}
@@ -0,0 +1,152 @@
+package invoice_handler
+
+import (
+	"encoding/json"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/invoice/invoice_renderer"
+	"fashion-api/invoice/invoice_service"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type invoiceHandler struct {
+	is invoice_service.InvoiceService
+}
+
+type InvoiceHandler interface {
+	FetchInvoice(w http.ResponseWriter, r *http.Request)
+	FetchCreditNote(w http.ResponseWriter, r *http.Request)
+	FetchDocuments(w http.ResponseWriter, r *http.Request)
+	IssueCreditNote(w http.ResponseWriter, r *http.Request)
+}
+
+func NewInvoiceHandler(is invoice_service.InvoiceService) InvoiceHandler {
+	return &invoiceHandler{
+		is: is,
+	}
+}
+
+// FetchInvoice implements InvoiceHandler.
+func (ih *invoiceHandler) FetchInvoice(w http.ResponseWriter, r *http.Request) {
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	invoice, err := ih.is.FetchInvoice(id)
+
+	writeInvoice(w, r, invoice, err)
+}
+
+// FetchCreditNote implements InvoiceHandler.
+func (ih *invoiceHandler) FetchCreditNote(w http.ResponseWriter, r *http.Request) {
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	creditNoteId, _ := strconv.Atoi(chi.URLParam(r, "creditNoteId"))
+
+	note, err := ih.is.FetchCreditNote(id, creditNoteId)
+
+	writeInvoice(w, r, note, err)
+}
+
+// FetchDocuments implements InvoiceHandler.
+func (ih *invoiceHandler) FetchDocuments(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ih.is.FetchDocuments(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// IssueCreditNote implements InvoiceHandler.
+func (ih *invoiceHandler) IssueCreditNote(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	payload := &dto.CreditNotePayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		invalidJSONRequest := exception.NewUnprocessableEntityError("invalid JSON body request")
+
+		w.WriteHeader(invalidJSONRequest.Status())
+		w.Write(helper.ResponseJSON(invalidJSONRequest))
+
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ih.is.IssueCreditNote(id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// writeInvoice renders the invoice in the format asked for by ?format=,
+// html when it is left out.
+func writeInvoice(w http.ResponseWriter, r *http.Request, invoice *entity.Invoice, err exception.Exception) {
+
+	format := r.URL.Query().Get("format")
+
+	switch format {
+	case "":
+		format = entity.InvoiceFormatHTML
+	case entity.InvoiceFormatHTML, entity.InvoiceFormatPDF, entity.InvoiceFormatJSON:
+	default:
+		err = exception.NewBadRequestError("format must be html, pdf or json")
+	}
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if format == entity.InvoiceFormatJSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(helper.ResponseJSON(&helper.ResponseBody{
+			Status:  http.StatusOK,
+			Message: strings.ToLower(invoice.Title()) + " successfully fetched",
+			Data:    invoice,
+		}))
+		return
+	}
+
+	renderer := invoice_renderer.NewRenderer(format)
+
+	w.Header().Set("Content-Type", renderer.ContentType())
+	w.Header().Set("Content-Disposition", `inline; filename="`+invoice.Number+"."+format+`"`)
+
+	// the status line is already out, a failure only cuts the document short
+	if err := renderer.Render(w, invoice); err != nil {
+		log.Println(err.Error())
+	}
+}
@@ -0,0 +1,89 @@
+package invoice_renderer
+
+import (
+	"fashion-api/entity"
+	"html/template"
+	"io"
+)
+
+// invoiceTemplate is laid out for printing, browsers save it as PDF as well
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{"rate": formatRate}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} {{.Number}}</title>
+<style>
+	body { font-family: Helvetica, Arial, sans-serif; font-size: 13px; color: #222; max-width: 800px; margin: 32px auto; }
+	h1 { font-size: 24px; margin: 0 0 4px; }
+	.parties { display: flex; justify-content: space-between; margin: 24px 0; }
+	.parties div { width: 48%; }
+	table { width: 100%; border-collapse: collapse; margin-top: 16px; }
+	th, td { padding: 6px 4px; border-bottom: 1px solid #ddd; text-align: left; }
+	th.amount, td.amount { text-align: right; white-space: nowrap; }
+	.totals td { border: none; }
+	.totals tr:last-child td { font-weight: bold; border-top: 2px solid #222; }
+	@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+	<h1>{{.Title}} {{.Number}}</h1>
+	<div>Issued {{.IssuedAt.Format "2 January 2006"}}</div>
+	{{if .InvoiceNumber}}<div>Credits invoice {{.InvoiceNumber}}{{if .Reason}}: {{.Reason}}{{end}}</div>{{end}}
+
+	<div class="parties">
+		<div>
+			<strong>{{.Seller.Name}}</strong><br>
+			{{range .Seller.Address}}{{.}}<br>{{end}}
+			{{if .Seller.TaxId}}Tax ID {{.Seller.TaxId}}<br>{{end}}
+			{{.Seller.Email}}
+		</div>
+		<div>
+			<strong>Bill to</strong><br>
+			{{.Buyer.Name}}<br>
+			{{range .Buyer.Address}}{{.}}<br>{{end}}
+			{{.Buyer.Email}}
+		</div>
+	</div>
+
+	<table>
+		<tr><th>Description</th><th class="amount">Qty</th><th class="amount">Unit price</th><th class="amount">Discount</th><th class="amount">Tax</th><th class="amount">Total</th></tr>
+		{{range .Lines}}
+		<tr><td>{{.Description}}</td><td class="amount">{{.Qty}}</td><td class="amount">{{.UnitPrice.Decimal}}</td><td class="amount">{{.Discount.Decimal}}</td><td class="amount">{{.Tax.Decimal}}</td><td class="amount">{{.Total.Decimal}}</td></tr>
+		{{end}}
+	</table>
+
+	{{if .TaxLines}}
+	<table>
+		<tr><th>Tax</th><th class="amount">Rate</th><th class="amount">Taxable</th><th class="amount">Amount</th></tr>
+		{{range .TaxLines}}
+		<tr><td>{{.Name}}{{if .Inclusive}} (included){{end}}</td><td class="amount">{{rate .Rate}}</td><td class="amount">{{.TaxableAmount.Decimal}}</td><td class="amount">{{.Amount.Decimal}}</td></tr>
+		{{end}}
+	</table>
+	{{end}}
+
+	<table class="totals">
+		<tr><td>Subtotal</td><td class="amount">{{.Subtotal.Decimal}}</td></tr>
+		<tr><td>Discount</td><td class="amount">-{{.DiscountTotal.Decimal}}</td></tr>
+		<tr><td>Tax</td><td class="amount">{{.TaxTotal.Decimal}}</td></tr>
+		<tr><td>Shipping</td><td class="amount">{{.ShippingTotal.Decimal}}</td></tr>
+		<tr><td>{{if .InvoiceNumber}}Total credited{{else}}Total{{end}}</td><td class="amount">{{.GrandTotal.Decimal}}</td></tr>
+	</table>
+</body>
+</html>
+`))
+
+type htmlRenderer struct{}
+
+func NewHTMLRenderer() Renderer {
+	return &htmlRenderer{}
+}
+
+// ContentType implements Renderer.
+func (hr *htmlRenderer) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+// Render implements Renderer.
+func (hr *htmlRenderer) Render(w io.Writer, invoice *entity.Invoice) error {
+	return invoiceTemplate.Execute(w, invoice)
+}
@@ -0,0 +1,297 @@
+package invoice_renderer
+
+import (
+	"bytes"
+	"fashion-api/entity"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A4 in points, text is set in the standard PDF fonts so nothing has to be
+// embedded. Amounts use the monospaced Courier so they can be right aligned
+// without font metrics.
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	pdfMargin     = 50.0
+
+	pdfFontRegular = "F1"
+	pdfFontBold    = "F2"
+	pdfFontMono    = "F3"
+
+	// courier glyphs are 600/1000 of the font size wide, helvetica ones a
+	// little less on average
+	pdfMonoWidth    = 0.6
+	pdfAverageWidth = 0.55
+
+	pdfDescriptionLength = 40
+)
+
+type pdfRenderer struct{}
+
+func NewPDFRenderer() Renderer {
+	return &pdfRenderer{}
+}
+
+// ContentType implements Renderer.
+func (pr *pdfRenderer) ContentType() string {
+	return "application/pdf"
+}
+
+// Render implements Renderer.
+func (pr *pdfRenderer) Render(w io.Writer, invoice *entity.Invoice) error {
+
+	doc := &pdfDocument{}
+	doc.newPage()
+
+	doc.text(pdfFontBold, 18, pdfMargin, invoice.Title()+" "+invoice.Number)
+	doc.advance(18)
+	doc.text(pdfFontRegular, 10, pdfMargin, "Issued "+invoice.IssuedAt.Format("2 January 2006"))
+
+	if invoice.InvoiceNumber != "" {
+		doc.advance(14)
+		doc.text(pdfFontRegular, 10, pdfMargin, "Credits invoice "+invoice.InvoiceNumber)
+
+		if invoice.Reason != "" {
+			doc.advance(14)
+			doc.text(pdfFontRegular, 10, pdfMargin, "Reason: "+invoice.Reason)
+		}
+	}
+
+	doc.advance(30)
+
+	seller := append([]string{invoice.Seller.Name}, invoice.Seller.Address...)
+
+	if invoice.Seller.TaxId != "" {
+		seller = append(seller, "Tax ID "+invoice.Seller.TaxId)
+	}
+
+	buyer := append([]string{"Bill to", invoice.Buyer.Name}, invoice.Buyer.Address...)
+
+	seller = appendNonEmpty(seller, invoice.Seller.Email)
+	buyer = appendNonEmpty(buyer, invoice.Buyer.Email)
+
+	for i := 0; i < len(seller) || i < len(buyer); i++ {
+
+		if i < len(seller) {
+			doc.text(boldFirst(i), 10, pdfMargin, seller[i])
+		}
+
+		if i < len(buyer) {
+			doc.text(boldFirst(i), 10, 320, buyer[i])
+		}
+
+		doc.advance(14)
+	}
+
+	doc.advance(16)
+
+	columns := []float64{330, 400, 460, 510, 545}
+
+	doc.text(pdfFontBold, 10, pdfMargin, "Description")
+
+	for i, heading := range []string{"Qty", "Unit price", "Discount", "Tax", "Total"} {
+		doc.rightText(pdfFontBold, 10, columns[i], heading)
+	}
+
+	doc.advance(6)
+	doc.rule()
+
+	for _, line := range invoice.Lines {
+
+		doc.advance(14)
+		doc.text(pdfFontRegular, 10, pdfMargin, truncate(line.Description, pdfDescriptionLength))
+
+		for i, value := range []string{strconv.Itoa(line.Qty), line.UnitPrice.Decimal(), line.Discount.Decimal(), line.Tax.Decimal(), line.Total.Decimal()} {
+			doc.monoText(9, columns[i], value)
+		}
+	}
+
+	doc.advance(6)
+	doc.rule()
+
+	if len(invoice.TaxLines) > 0 {
+
+		doc.advance(24)
+		doc.text(pdfFontBold, 10, pdfMargin, "Tax")
+		doc.rightText(pdfFontBold, 10, columns[1], "Rate")
+		doc.rightText(pdfFontBold, 10, columns[3], "Taxable")
+		doc.rightText(pdfFontBold, 10, columns[4], "Amount")
+
+		for _, line := range invoice.TaxLines {
+
+			name := line.Name
+
+			if line.Inclusive {
+				name += " (included)"
+			}
+
+			doc.advance(14)
+			doc.text(pdfFontRegular, 10, pdfMargin, truncate(name, pdfDescriptionLength))
+			doc.monoText(9, columns[1], formatRate(line.Rate))
+			doc.monoText(9, columns[3], line.TaxableAmount.Decimal())
+			doc.monoText(9, columns[4], line.Amount.Decimal())
+		}
+	}
+
+	doc.advance(24)
+
+	total := "Total"
+
+	if invoice.InvoiceNumber != "" {
+		total = "Total credited"
+	}
+
+	for _, row := range [][2]string{
+		{"Subtotal", invoice.Subtotal.Decimal()},
+		{"Discount", "-" + invoice.DiscountTotal.Decimal()},
+		{"Tax", invoice.TaxTotal.Decimal()},
+		{"Shipping", invoice.ShippingTotal.Decimal()},
+		{total + " (" + invoice.Currency + ")", invoice.GrandTotal.Decimal()},
+	} {
+		doc.advance(14)
+		doc.text(pdfFontRegular, 10, columns[1], row[0])
+		doc.monoText(9, columns[4], row[1])
+	}
+
+	return doc.write(w)
+}
+
+// pdfDocument lays text out top to bottom, starting a new page whenever the
+// cursor reaches the bottom margin.
+type pdfDocument struct {
+	pages []*bytes.Buffer
+	y     float64
+}
+
+func (d *pdfDocument) newPage() {
+	d.pages = append(d.pages, &bytes.Buffer{})
+	d.y = pdfPageHeight - pdfMargin
+}
+
+func (d *pdfDocument) advance(height float64) {
+
+	d.y -= height
+
+	if d.y < pdfMargin {
+		d.newPage()
+	}
+}
+
+func (d *pdfDocument) text(font string, size float64, x float64, value string) {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "BT /%s %.0f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, d.y, pdfEscape(value))
+}
+
+// rightText ends value at right, approximating the width of a proportional
+// font from an average glyph width.
+func (d *pdfDocument) rightText(font string, size float64, right float64, value string) {
+	d.text(font, size, right-float64(len(value))*size*pdfAverageWidth, value)
+}
+
+func (d *pdfDocument) monoText(size float64, right float64, value string) {
+	d.text(pdfFontMono, size, right-float64(len(value))*size*pdfMonoWidth, value)
+}
+
+func (d *pdfDocument) rule() {
+	fmt.Fprintf(d.pages[len(d.pages)-1], "0.5 w %.2f %.2f m %.2f %.2f l S\n", pdfMargin, d.y, pdfPageWidth-pdfMargin, d.y)
+}
+
+// write serializes the document, objects 1 and 2 are the catalog and the
+// page tree, 3 to 5 the fonts and every page takes a page and a content
+// object after them.
+func (d *pdfDocument) write(w io.Writer) error {
+
+	out, offsets := &bytes.Buffer{}, []int{}
+
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+
+	kids := []string{}
+
+	for i := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 6+i*2))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+
+	for _, font := range []string{"Helvetica", "Helvetica-Bold", "Courier"} {
+		object("<< /Type /Font /Subtype /Type1 /BaseFont /" + font + " /Encoding /WinAnsiEncoding >>")
+	}
+
+	fonts := fmt.Sprintf("<< /%s 3 0 R /%s 4 0 R /%s 5 0 R >>", pdfFontRegular, pdfFontBold, pdfFontMono)
+
+	for i, page := range d.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font %s >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, fonts, 7+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := out.WriteTo(w)
+
+	return err
+}
+
+// pdfEscape encodes value for a PDF string in WinAnsiEncoding, characters
+// outside Latin-1 are printed as ?.
+func pdfEscape(value string) string {
+
+	escaped := []byte{}
+
+	for _, r := range value {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped = append(escaped, '\\', byte(r))
+		case r < 32 || r > 255:
+			escaped = append(escaped, '?')
+		default:
+			escaped = append(escaped, byte(r))
+		}
+	}
+
+	return string(escaped)
+}
+
+func truncate(value string, length int) string {
+
+	runes := []rune(value)
+
+	if len(runes) <= length {
+		return value
+	}
+
+	return string(runes[:length-3]) + "..."
+}
+
+func appendNonEmpty(lines []string, value string) []string {
+
+	if value == "" {
+		return lines
+	}
+
+	return append(lines, value)
+}
+
+func boldFirst(i int) string {
+
+	if i == 0 {
+		return pdfFontBold
+	}
+
+	return pdfFontRegular
+}
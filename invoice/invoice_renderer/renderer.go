@@ -0,0 +1,28 @@
+package invoice_renderer
+
+import (
+	"fashion-api/entity"
+	"io"
+	"strconv"
+)
+
+// Renderer prints an issued invoice or credit note. Another format can be
+// plugged in by implementing it and registering it in NewRenderer.
+type Renderer interface {
+	ContentType() string
+	Render(w io.Writer, invoice *entity.Invoice) error
+}
+
+func NewRenderer(format string) Renderer {
+	switch format {
+	case entity.InvoiceFormatPDF:
+		return NewPDFRenderer()
+	default:
+		return NewHTMLRenderer()
+	}
+}
+
+// formatRate prints a rate in basis points as a percentage, 1150 is 11.5%.
+func formatRate(rate int) string {
+	return strconv.FormatFloat(float64(rate)/100, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,10 @@
+package invoice_repo
+
+import "fashion-api/entity"
+
+// InvoiceSource is a transaction read as the invoice it would be issued
+// as, seller details and the number are filled in when it is issued.
+type InvoiceSource struct {
+	Status  string
+	Invoice *entity.Invoice
+}
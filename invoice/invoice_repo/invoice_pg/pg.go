@@ -0,0 +1,384 @@
+package invoice_pg
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fashion-api/entity"
+	"fashion-api/invoice/invoice_repo"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+	"log"
+	"strings"
+)
+
+type invoicePg struct {
+	db *sql.DB
+}
+
+const (
+	fetchInvoiceSourceQuery = `select t.id, t.user_id, t.status, t.currency, t.subtotal, t.discount_total, t.tax_total, t.shipping_total, t.grand_total, u.full_name, u.email,
+			coalesce(o.shipping_recipient, ''), coalesce(o.shipping_line1, ''), coalesce(o.shipping_city, ''), coalesce(o.shipping_region, ''), coalesce(o.shipping_postal_code, ''), coalesce(o.shipping_country, '')
+		from transaction as t join "user" as u on t.user_id = u.id join "order" as o on t.order_id = o.id where t.id = $1 and t.deleted_at is null`
+
+	fetchInvoiceSourceLinesQuery = `select product_name, qty, unit_price, subtotal, discount, tax, total from transaction_item where transaction_id = $1 order by id`
+
+	fetchInvoiceSourceTaxLinesQuery = `select name, rate, inclusive, sum(taxable_amount), sum(amount) from tax_line where transaction_id = $1 group by name, rate, inclusive order by name, rate`
+
+	nextInvoiceNumberQuery = `update invoice_counter set last_number = last_number + 1 where kind = $1 returning last_number`
+
+	// nothing is inserted when a credit note would credit more than is left
+	// of its invoice, the counter lock keeps notes from racing past it
+	addInvoiceQuery = `insert into invoice (kind, number, transaction_id, user_id, invoice_id, currency, seller, buyer, lines, tax_lines, subtotal, discount_total, tax_total, shipping_total, grand_total, reason)
+		select $1::varchar, $2::varchar, $3::int, $4::int, $5::int, $6::varchar, $7::jsonb, $8::jsonb, $9::jsonb, $10::jsonb, $11::int, $12::int, $13::int, $14::int, $15::int, nullif($16::text, '')
+		where $5::int is null or $15::int <= (select i.grand_total - coalesce((select sum(c.grand_total) from invoice as c where c.invoice_id = i.id), 0) from invoice as i where i.id = $5::int)
+		returning id, issued_at`
+
+	fetchInvoiceQuery = `select i.id, i.kind, i.number, i.transaction_id, i.user_id, coalesce(i.invoice_id, 0), coalesce(c.number, ''), i.currency, i.seller, i.buyer, i.lines, i.tax_lines,
+			i.subtotal, i.discount_total, i.tax_total, i.shipping_total, i.grand_total, coalesce(i.reason, ''), i.issued_at
+		from invoice as i left join invoice as c on i.invoice_id = c.id`
+
+	fetchInvoiceByIdQuery = fetchInvoiceQuery + ` where i.id = $1`
+
+	fetchInvoiceByTransactionIdQuery = fetchInvoiceQuery + ` where i.transaction_id = $1 and i.kind = 'invoice'`
+
+	fetchCreditNotesQuery = fetchInvoiceQuery + ` where i.invoice_id = $1 and i.kind = 'credit_note' order by i.id`
+
+	duplicateInvoiceError = `pq: duplicate key value violates unique constraint "invoice_transaction_key"`
+)
+
+func NewInvoicePg(db *sql.DB) invoice_repo.InvoiceRepo {
+	return &invoicePg{
+		db: db,
+	}
+}
+
+// FetchSource implements invoice_repo.InvoiceRepo.
+func (pg *invoicePg) FetchSource(transactionId int) (*invoice_repo.InvoiceSource, exception.Exception) {
+
+	source, invoice := invoice_repo.InvoiceSource{}, entity.Invoice{Kind: entity.InvoiceKindInvoice}
+	buyer, address := entity.InvoiceParty{}, entity.Address{}
+
+	if err := pg.db.QueryRow(fetchInvoiceSourceQuery, transactionId).Scan(
+		&invoice.TransactionId,
+		&invoice.UserId,
+		&source.Status,
+		&invoice.Currency,
+		&invoice.Subtotal.Amount,
+		&invoice.DiscountTotal.Amount,
+		&invoice.TaxTotal.Amount,
+		&invoice.ShippingTotal.Amount,
+		&invoice.GrandTotal.Amount,
+		&buyer.Name,
+		&buyer.Email,
+		&address.Recipient,
+		&address.Line1,
+		&address.City,
+		&address.Region,
+		&address.PostalCode,
+		&address.Country,
+	); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("transaction not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	invoice.Subtotal.Currency = invoice.Currency
+	invoice.DiscountTotal.Currency = invoice.Currency
+	invoice.TaxTotal.Currency = invoice.Currency
+	invoice.ShippingTotal.Currency = invoice.Currency
+	invoice.GrandTotal.Currency = invoice.Currency
+
+	// the buyer is invoiced at the address the order shipped to
+	for _, line := range []string{address.Recipient, address.Line1, address.City, address.Region + " " + address.PostalCode, address.Country} {
+		if line = strings.TrimSpace(line); line != "" {
+			buyer.Address = append(buyer.Address, line)
+		}
+	}
+
+	invoice.Buyer = &buyer
+
+	lines, err := pg.fetchSourceLines(transactionId, invoice.Currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	taxLines, err := pg.fetchSourceTaxLines(transactionId, invoice.Currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	invoice.Lines, invoice.TaxLines = lines, taxLines
+	source.Invoice = &invoice
+
+	return &source, nil
+}
+
+func (pg *invoicePg) fetchSourceLines(transactionId int, currency string) ([]*entity.InvoiceLine, exception.Exception) {
+
+	lines := []*entity.InvoiceLine{}
+
+	rows, err := pg.db.Query(fetchInvoiceSourceLinesQuery, transactionId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		zero := money.New(0, currency)
+		line := entity.InvoiceLine{UnitPrice: zero, Subtotal: zero, Discount: zero, Tax: zero, Total: zero}
+
+		if err := rows.Scan(
+			&line.Description,
+			&line.Qty,
+			&line.UnitPrice.Amount,
+			&line.Subtotal.Amount,
+			&line.Discount.Amount,
+			&line.Tax.Amount,
+			&line.Total.Amount,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		lines = append(lines, &line)
+	}
+
+	return lines, nil
+}
+
+func (pg *invoicePg) fetchSourceTaxLines(transactionId int, currency string) ([]*entity.InvoiceTaxLine, exception.Exception) {
+
+	lines := []*entity.InvoiceTaxLine{}
+
+	rows, err := pg.db.Query(fetchInvoiceSourceTaxLinesQuery, transactionId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		zero := money.New(0, currency)
+		line := entity.InvoiceTaxLine{TaxableAmount: zero, Amount: zero}
+
+		if err := rows.Scan(&line.Name, &line.Rate, &line.Inclusive, &line.TaxableAmount.Amount, &line.Amount.Amount); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		lines = append(lines, &line)
+	}
+
+	return lines, nil
+}
+
+// Issue implements invoice_repo.InvoiceRepo.
+func (pg *invoicePg) Issue(invoice *entity.Invoice) exception.Exception {
+
+	seller, err := json.Marshal(invoice.Seller)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	buyer, err := json.Marshal(invoice.Buyer)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	lines, err := json.Marshal(invoice.Lines)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	taxLines, err := json.Marshal(invoice.TaxLines)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	// the counter row stays locked until commit, a rolled back issue gives
+	// its number back
+	sequence := 0
+
+	if err := tx.QueryRow(nextInvoiceNumberQuery, invoice.Kind).Scan(&sequence); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	invoice.Number = entity.FormatInvoiceNumber(invoice.Kind, sequence)
+
+	if err := tx.QueryRow(
+		addInvoiceQuery,
+		invoice.Kind,
+		invoice.Number,
+		invoice.TransactionId,
+		invoice.UserId,
+		sql.NullInt64{Int64: int64(invoice.InvoiceId), Valid: invoice.InvoiceId != 0},
+		invoice.Currency,
+		seller,
+		buyer,
+		lines,
+		taxLines,
+		invoice.Subtotal.Amount,
+		invoice.DiscountTotal.Amount,
+		invoice.TaxTotal.Amount,
+		invoice.ShippingTotal.Amount,
+		invoice.GrandTotal.Amount,
+		invoice.Reason,
+	).Scan(&invoice.Id, &invoice.IssuedAt); err != nil {
+		tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return exception.NewBadRequestError("amount is more than is left to credit on invoice " + invoice.InvoiceNumber)
+		}
+
+		if err.Error() == duplicateInvoiceError {
+			return exception.NewConflictError("transaction already has an invoice")
+		}
+
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchById implements invoice_repo.InvoiceRepo.
+func (pg *invoicePg) FetchById(id int) (*entity.Invoice, exception.Exception) {
+	return pg.fetchOne(fetchInvoiceByIdQuery, id)
+}
+
+// FetchByTransactionId implements invoice_repo.InvoiceRepo.
+func (pg *invoicePg) FetchByTransactionId(transactionId int) (*entity.Invoice, exception.Exception) {
+	return pg.fetchOne(fetchInvoiceByTransactionIdQuery, transactionId)
+}
+
+// FetchCreditNotes implements invoice_repo.InvoiceRepo.
+func (pg *invoicePg) FetchCreditNotes(invoiceId int) ([]*entity.Invoice, exception.Exception) {
+
+	notes := []*entity.Invoice{}
+
+	rows, err := pg.db.Query(fetchCreditNotesQuery, invoiceId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		note, err := scanInvoice(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+func (pg *invoicePg) fetchOne(query string, arg any) (*entity.Invoice, exception.Exception) {
+
+	invoice, err := scanInvoice(pg.db.QueryRow(query, arg))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("invoice not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return invoice, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanInvoice(row scanner) (*entity.Invoice, error) {
+
+	invoice := entity.Invoice{}
+	seller, buyer, lines, taxLines := []byte{}, []byte{}, []byte{}, []byte{}
+
+	if err := row.Scan(
+		&invoice.Id,
+		&invoice.Kind,
+		&invoice.Number,
+		&invoice.TransactionId,
+		&invoice.UserId,
+		&invoice.InvoiceId,
+		&invoice.InvoiceNumber,
+		&invoice.Currency,
+		&seller,
+		&buyer,
+		&lines,
+		&taxLines,
+		&invoice.Subtotal.Amount,
+		&invoice.DiscountTotal.Amount,
+		&invoice.TaxTotal.Amount,
+		&invoice.ShippingTotal.Amount,
+		&invoice.GrandTotal.Amount,
+		&invoice.Reason,
+		&invoice.IssuedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	for target, value := range map[any][]byte{&invoice.Seller: seller, &invoice.Buyer: buyer, &invoice.Lines: lines, &invoice.TaxLines: taxLines} {
+		if err := json.Unmarshal(value, target); err != nil {
+			return nil, err
+		}
+	}
+
+	invoice.Subtotal.Currency = invoice.Currency
+	invoice.DiscountTotal.Currency = invoice.Currency
+	invoice.TaxTotal.Currency = invoice.Currency
+	invoice.ShippingTotal.Currency = invoice.Currency
+	invoice.GrandTotal.Currency = invoice.Currency
+
+	return &invoice, nil
+}
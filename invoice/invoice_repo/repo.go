@@ -0,0 +1,14 @@
+package invoice_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type InvoiceRepo interface {
+	FetchSource(transactionId int) (*InvoiceSource, exception.Exception)
+	Issue(invoice *entity.Invoice) exception.Exception
+	FetchById(id int) (*entity.Invoice, exception.Exception)
+	FetchByTransactionId(transactionId int) (*entity.Invoice, exception.Exception)
+	FetchCreditNotes(invoiceId int) ([]*entity.Invoice, exception.Exception)
+}
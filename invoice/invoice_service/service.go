@@ -0,0 +1,177 @@
+package invoice_service
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/infra/config"
+	"fashion-api/invoice/invoice_repo"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"net/http"
+	"strings"
+)
+
+type invoiceService struct {
+	ir invoice_repo.InvoiceRepo
+}
+
+type InvoiceService interface {
+	Issue(transactionId int) (*entity.Invoice, exception.Exception)
+	FetchInvoice(transactionId int) (*entity.Invoice, exception.Exception)
+	FetchCreditNote(transactionId int, id int) (*entity.Invoice, exception.Exception)
+	FetchDocuments(transactionId int) (*helper.ResponseBody, exception.Exception)
+	IssueCreditNote(transactionId int, payload *dto.CreditNotePayload) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewInvoiceService(ir invoice_repo.InvoiceRepo) InvoiceService {
+	return &invoiceService{
+		ir: ir,
+	}
+}
+
+// Issue implements InvoiceService. It is called once the payment of the
+// transaction is captured, issuing again returns the invoice already issued.
+func (is *invoiceService) Issue(transactionId int) (*entity.Invoice, exception.Exception) {
+
+	source, err := is.ir.FetchSource(transactionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// refunded transactions were paid, the refund is corrected by a credit
+	// note rather than by not invoicing
+	if source.Status != entity.TransactionStatusPaid && source.Status != entity.TransactionStatusRefunded {
+		return nil, exception.NewBadRequestError("invoices are issued once the transaction is paid")
+	}
+
+	invoice := source.Invoice
+	invoice.Seller = seller()
+
+	if err := is.ir.Issue(invoice); err != nil {
+
+		// issued by a redelivered webhook in the meantime
+		if err.Status() == http.StatusConflict {
+			return is.ir.FetchByTransactionId(transactionId)
+		}
+
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+// FetchInvoice implements InvoiceService.
+func (is *invoiceService) FetchInvoice(transactionId int) (*entity.Invoice, exception.Exception) {
+	return is.ir.FetchByTransactionId(transactionId)
+}
+
+// FetchCreditNote implements InvoiceService.
+func (is *invoiceService) FetchCreditNote(transactionId int, id int) (*entity.Invoice, exception.Exception) {
+
+	note, err := is.ir.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if note.Kind != entity.InvoiceKindCreditNote || note.TransactionId != transactionId {
+		return nil, exception.NewNotFoundError("credit note not found")
+	}
+
+	return note, nil
+}
+
+// FetchDocuments implements InvoiceService.
+func (is *invoiceService) FetchDocuments(transactionId int) (*helper.ResponseBody, exception.Exception) {
+
+	invoice, err := is.FetchInvoice(transactionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := is.ir.FetchCreditNotes(invoice.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "invoice successfully fetched",
+		Data: &dto.InvoiceDocuments{
+			Invoice:     invoice,
+			CreditNotes: notes,
+		},
+	}, nil
+}
+
+// IssueCreditNote implements InvoiceService.
+func (is *invoiceService) IssueCreditNote(transactionId int, payload *dto.CreditNotePayload) (*helper.ResponseBody, exception.Exception) {
+
+	invoice, err := is.FetchInvoice(transactionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := is.ir.FetchCreditNotes(invoice.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := invoice.GrandTotal
+
+	for _, note := range notes {
+		remaining = remaining.Sub(note.GrandTotal)
+	}
+
+	if remaining.Amount <= 0 {
+		return nil, exception.NewConflictError("invoice " + invoice.Number + " is fully credited")
+	}
+
+	amount := money.New(payload.Amount, invoice.Currency)
+
+	if payload.Amount == 0 {
+		amount = remaining
+	}
+
+	if amount.Amount < 0 || amount.Amount > remaining.Amount {
+		return nil, exception.NewBadRequestError("amount must be between 1 and " + remaining.Decimal() + " " + invoice.Currency)
+	}
+
+	note := invoice.CreditNote(amount, strings.TrimSpace(payload.Reason))
+
+	if err := is.ir.Issue(note); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "credit note successfully issued",
+		Data:    note,
+	}, nil
+}
+
+// seller is printed on every invoice as configured when it is issued.
+func seller() *entity.InvoiceParty {
+
+	appConfig := config.NewAppConfig()
+
+	party := &entity.InvoiceParty{
+		Name:  appConfig.SellerName,
+		Email: appConfig.SellerEmail,
+		TaxId: appConfig.SellerTaxId,
+	}
+
+	for _, line := range strings.Split(appConfig.SellerAddress, "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			party.Address = append(party.Address, line)
+		}
+	}
+
+	return party
+}
@@ -10,6 +10,7 @@ type UserData struct {
 	Email     string    `json:"email"`
 	Address   string    `json:"address"`
 	Role      string    `json:"role"`
+	Locale    string    `json:"locale"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,214 @@
+package notification_job
+
+import (
+	"encoding/json"
+	"fashion-api/entity"
+	"fashion-api/notification/notification_repo"
+	"fashion-api/notification/notification_template"
+	"fashion-api/notification/notifier"
+	"fashion-api/pkg/exception"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	// notificationBatchSize notifications are claimed from the outbox at a
+	// time, the rest wait for the next batch
+	notificationBatchSize = 20
+
+	// notificationPollInterval is how often the outbox is checked for
+	// retries that came due and for notifications queued by another server
+	notificationPollInterval = 10 * time.Second
+
+	// maxNotificationAttempts bounds retries, the delay doubles after each
+	// failed attempt so the last one happens about 15 retry delays in
+	maxNotificationAttempts = 5
+)
+
+type notificationJob struct {
+	nr         notification_repo.NotificationRepo
+	t          notification_template.Templates
+	n          notifier.Notifier
+	storeName  string
+	url        string
+	retryDelay time.Duration
+	wake       chan struct{}
+}
+
+// NotificationJob renders and sends customer emails in the background so a
+// slow or unavailable mail server never holds up a request. Notifications
+// are kept in an outbox table until they are sent, so a restart doesn't
+// lose them. Failed deliveries are retried with a growing delay and
+// dropped after maxNotificationAttempts.
+type NotificationJob interface {
+	Queue(notification *entity.Notification)
+	Run()
+}
+
+func NewNotificationJob(nr notification_repo.NotificationRepo, t notification_template.Templates, n notifier.Notifier, storeName string, url string, retryDelay time.Duration) NotificationJob {
+	return &notificationJob{
+		nr:         nr,
+		t:          t,
+		n:          n,
+		storeName:  storeName,
+		url:        url,
+		retryDelay: retryDelay,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Queue implements NotificationJob.
+func (j *notificationJob) Queue(notification *entity.Notification) {
+
+	if err := j.nr.AddToOutbox(notification); err != nil {
+		log.Printf("[notification] %s can't be queued: %s", notification.Kind, err.Message())
+		return
+	}
+
+	// a worker that is already awake picks it up with the next batch, so a
+	// wake-up that doesn't fit is dropped rather than waited for
+	select {
+	case j.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run implements NotificationJob.
+func (j *notificationJob) Run() {
+
+	ticker := time.NewTicker(notificationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		j.drain()
+
+		select {
+		case <-j.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain sends every notification that is due, one batch at a time.
+func (j *notificationJob) drain() {
+
+	for {
+
+		notifications, err := j.nr.ClaimDue(notificationBatchSize, j.retryDelay)
+
+		if err != nil {
+			log.Println(err.Message())
+			return
+		}
+
+		for _, notification := range notifications {
+
+			if retry := j.send(notification); retry {
+
+				// the row stays and comes due again once the delay set when
+				// it was claimed has passed
+				if notification.Attempts < maxNotificationAttempts {
+					continue
+				}
+
+				log.Printf("[notification] giving up on %s after %d attempts", notification.Kind, notification.Attempts)
+			}
+
+			if err := j.nr.RemoveFromOutbox(notification.Id); err != nil {
+				log.Println(err.Message())
+			}
+		}
+
+		if len(notifications) < notificationBatchSize {
+			return
+		}
+	}
+}
+
+// send reports whether the notification is worth trying again, it isn't
+// once it was delivered or can never be.
+func (j *notificationJob) send(notification *entity.Notification) bool {
+
+	notification.Data = j.decode(notification)
+
+	recipient, err := j.recipient(notification)
+
+	// the customer is gone, there is nobody left to tell
+	if err != nil && err.Status() == http.StatusNotFound {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	message, renderErr := j.t.Render(notification.Kind, &notification_template.Data{
+		Recipient:     recipient,
+		StoreName:     j.storeName,
+		URL:           j.url,
+		TransactionId: notification.TransactionId,
+		Data:          notification.Data,
+	})
+
+	// a template that fails once fails every time, so it isn't retried
+	if renderErr != nil {
+		log.Printf("[notification] %s to user %d can't be rendered: %s", notification.Kind, recipient.Id, renderErr.Error())
+		return false
+	}
+
+	if err := j.n.Send(message); err != nil {
+		log.Println(err.Message())
+		return true
+	}
+
+	return false
+}
+
+// decode turns the stored JSON back into the type the template of the kind
+// was written against.
+func (j *notificationJob) decode(notification *entity.Notification) any {
+
+	raw, ok := notification.Data.(json.RawMessage)
+
+	if !ok {
+		return notification.Data
+	}
+
+	var data any
+
+	switch notification.Kind {
+	case entity.NotificationOrderPlaced:
+		data = &entity.Transaction{}
+	case entity.NotificationPaymentReceived:
+		data = &entity.Payment{}
+	case entity.NotificationShipped:
+		data = &entity.Shipment{}
+	case entity.NotificationRefund:
+		data = &entity.PaymentRefund{}
+	case entity.NotificationPasswordReset:
+		data = &entity.PasswordReset{}
+	case entity.NotificationBackInStock:
+		data = &entity.BackInStock{}
+	case entity.NotificationLowStock:
+		data = &entity.LowStockAlert{}
+	default:
+		return nil
+	}
+
+	if err := json.Unmarshal(raw, data); err != nil {
+		log.Printf("[notification] %s data can't be read: %s", notification.Kind, err.Error())
+		return nil
+	}
+
+	return data
+}
+
+func (j *notificationJob) recipient(notification *entity.Notification) (*entity.Recipient, exception.Exception) {
+
+	if notification.UserId != 0 {
+		return j.nr.FetchRecipient(notification.UserId)
+	}
+
+	return j.nr.FetchTransactionRecipient(notification.TransactionId)
+}
@@ -0,0 +1,409 @@
+package notification_job
+
+import (
+	"encoding/json"
+	"fashion-api/entity"
+	"fashion-api/notification/notification_template"
+	"fashion-api/notification/notifier"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// outboxRepo keeps the outbox in memory and stores Data as JSON, the way
+// the notification_outbox table does.
+type outboxRepo struct {
+	recipients map[int]*entity.Recipient
+	outbox     []*entity.Notification
+	nextId     int
+}
+
+func (r *outboxRepo) FetchRecipient(userId int) (*entity.Recipient, exception.Exception) {
+
+	recipient, ok := r.recipients[userId]
+
+	if !ok {
+		return nil, exception.NewNotFoundError("user not found")
+	}
+
+	return recipient, nil
+}
+
+func (r *outboxRepo) FetchTransactionRecipient(transactionId int) (*entity.Recipient, exception.Exception) {
+	return r.FetchRecipient(transactionId)
+}
+
+func (r *outboxRepo) AddToOutbox(notification *entity.Notification) exception.Exception {
+
+	data, err := json.Marshal(notification.Data)
+
+	if err != nil {
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	r.nextId++
+	r.outbox = append(r.outbox, &entity.Notification{
+		Id:            r.nextId,
+		Kind:          notification.Kind,
+		UserId:        notification.UserId,
+		TransactionId: notification.TransactionId,
+		Data:          json.RawMessage(data),
+	})
+
+	return nil
+}
+
+// ClaimDue treats every row as due, the retry delay is the database's
+// concern.
+func (r *outboxRepo) ClaimDue(limit int, retryDelay time.Duration) ([]*entity.Notification, exception.Exception) {
+
+	var claimed []*entity.Notification
+
+	for _, row := range r.outbox {
+
+		if len(claimed) == limit {
+			break
+		}
+
+		row.Attempts++
+		claimed = append(claimed, &entity.Notification{
+			Id:            row.Id,
+			Kind:          row.Kind,
+			UserId:        row.UserId,
+			TransactionId: row.TransactionId,
+			Data:          row.Data,
+			Attempts:      row.Attempts,
+		})
+	}
+
+	return claimed, nil
+}
+
+func (r *outboxRepo) RemoveFromOutbox(id int) exception.Exception {
+
+	for i, row := range r.outbox {
+		if row.Id == id {
+			r.outbox = append(r.outbox[:i], r.outbox[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+type failingNotifier struct {
+	sent int
+}
+
+func (fn *failingNotifier) Name() string {
+	return "failing"
+}
+
+func (fn *failingNotifier) Send(message *notifier.Message) exception.Exception {
+	fn.sent++
+	return exception.NewInternalServerError("something went wrong")
+}
+
+// email is a sent .eml file with its bodies decoded.
+type email struct {
+	to      string
+	subject string
+	text    string
+	html    string
+}
+
+func readOutbox(t *testing.T, dir string) []*email {
+
+	names, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(names)
+
+	var emails []*email
+
+	for _, name := range names {
+
+		f, err := os.Open(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer f.Close()
+
+		msg, err := mail.ReadMessage(f)
+
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		to, err := mail.ParseAddress(msg.Header.Get("To"))
+
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+
+		if err != nil || mediaType != "multipart/alternative" {
+			t.Fatalf("%s: content type %q", name, msg.Header.Get("Content-Type"))
+		}
+
+		e := &email{to: to.Address, subject: subject}
+		parts := multipart.NewReader(msg.Body, params["boundary"])
+
+		for {
+
+			part, err := parts.NextRawPart()
+
+			if err == io.EOF {
+				break
+			}
+
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+
+			if part.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+				t.Fatalf("%s: part isn't quoted-printable", name)
+			}
+
+			body, err := io.ReadAll(quotedprintable.NewReader(part))
+
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+
+			switch part.Header.Get("Content-Type") {
+			case "text/plain; charset=utf-8":
+				e.text = string(body)
+			case "text/html; charset=utf-8":
+				e.html = string(body)
+			default:
+				t.Fatalf("%s: unexpected part %q", name, part.Header.Get("Content-Type"))
+			}
+		}
+
+		emails = append(emails, e)
+	}
+
+	return emails
+}
+
+func newTestJob(t *testing.T, nr *outboxRepo, n notifier.Notifier) *notificationJob {
+
+	tmpl, err := notification_template.NewTemplates(entity.LocaleEnglish)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewNotificationJob(nr, tmpl, n, "Fashion", "https://shop.example.com", time.Minute).(*notificationJob)
+}
+
+// queued is a notification as a service queues it, with a value its email
+// has to show once the data has been through the outbox.
+type queued struct {
+	notification *entity.Notification
+	want         string
+}
+
+func queuedNotifications(userId int) []queued {
+
+	expiresAt := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	daysUntilStockout := 2.5
+
+	return []queued{
+		{&entity.Notification{Kind: entity.NotificationSignUp, UserId: userId}, "Fashion"},
+		{&entity.Notification{Kind: entity.NotificationOrderPlaced, UserId: userId, Data: &entity.Transaction{
+			Id:            7,
+			OrderId:       42,
+			Currency:      "USD",
+			Subtotal:      money.New(12000, "USD"),
+			DiscountTotal: money.New(1000, "USD"),
+			TaxTotal:      money.New(990, "USD"),
+			ShippingTotal: money.New(500, "USD"),
+			GrandTotal:    money.New(12490, "USD"),
+			Items: []*entity.TransactionItem{
+				{ProductName: "Linen Shirt", Qty: 2, Total: money.New(11990, "USD")},
+			},
+			ShippingAddress: &entity.Address{
+				Recipient:  "Ayu Lestari",
+				Line1:      "Jl. Sudirman 1",
+				City:       "Jakarta",
+				PostalCode: "10210",
+				Country:    "ID",
+			},
+		}}, "124.90 USD"},
+		{&entity.Notification{Kind: entity.NotificationPaymentReceived, TransactionId: userId, Data: &entity.Payment{
+			TransactionId: 7,
+			Amount:        money.New(12490, "USD"),
+		}}, "124.90 USD"},
+		{&entity.Notification{Kind: entity.NotificationShipped, UserId: userId, Data: &entity.Shipment{
+			OrderId:        42,
+			Carrier:        "JNE",
+			TrackingNumber: "JNE123456",
+			ShippedAt:      expiresAt,
+		}}, "JNE123456"},
+		{&entity.Notification{Kind: entity.NotificationRefund, TransactionId: userId, Data: &entity.PaymentRefund{
+			Amount: money.New(150000, "IDR"),
+			Reason: "order #42 cancelled",
+		}}, "150000 IDR"},
+		{&entity.Notification{Kind: entity.NotificationPasswordReset, UserId: userId, Data: &entity.PasswordReset{
+			Token:     "reset-token",
+			ExpiresAt: expiresAt,
+		}}, "reset-token"},
+		{&entity.Notification{Kind: entity.NotificationBackInStock, UserId: userId, Data: &entity.BackInStock{
+			ProductId: 3,
+			Name:      "Linen Shirt",
+			Slug:      "linen-shirt",
+		}}, "https://shop.example.com/products/slug/linen-shirt"},
+		{&entity.Notification{Kind: entity.NotificationLowStock, UserId: userId, Data: &entity.LowStockAlert{
+			Items: []*entity.LowStockItem{
+				{ProductId: 3, Name: "Linen Shirt", Available: 2, ReorderThreshold: 5, DaysUntilStockout: &daysUntilStockout},
+			},
+		}}, "2.5"},
+	}
+}
+
+func TestDrainWritesEveryKindInEveryLocale(t *testing.T) {
+
+	dir := t.TempDir()
+	nr := &outboxRepo{recipients: map[int]*entity.Recipient{
+		1: {Id: 1, FullName: "Ayu Lestari", Email: "ayu@example.com", Locale: entity.LocaleIndonesian},
+		2: {Id: 2, FullName: "Jane Doe", Email: "jane@example.com", Locale: entity.LocaleEnglish},
+	}}
+	j := newTestJob(t, nr, notifier.NewFileNotifier(&notifier.Settings{
+		From:      "Fashion <shop@example.com>",
+		OutboxDir: dir,
+	}))
+
+	var sent []queued
+
+	for _, userId := range []int{1, 2} {
+		for _, q := range queuedNotifications(userId) {
+			sent = append(sent, q)
+			j.Queue(q.notification)
+		}
+	}
+
+	j.drain()
+
+	if len(nr.outbox) != 0 {
+		t.Fatalf("%d notifications are left in the outbox", len(nr.outbox))
+	}
+
+	emails := readOutbox(t, dir)
+
+	if len(emails) != len(sent) {
+		t.Fatalf("%d emails were written, want %d", len(emails), len(sent))
+	}
+
+	subjects := map[string]map[string]bool{}
+
+	// the outbox is drained in the order it was filled and the file names
+	// sort in the order the emails were sent
+	for i, e := range emails {
+
+		kind := sent[i].notification.Kind
+		recipient := nr.recipients[sent[i].notification.UserId+sent[i].notification.TransactionId]
+
+		if e.to != recipient.Email {
+			t.Errorf("%s for %s was sent to %s", kind, recipient.Email, e.to)
+		}
+
+		if subjects[e.to] == nil {
+			subjects[e.to] = map[string]bool{}
+		}
+
+		if e.subject == "" || subjects[e.to][e.subject] {
+			t.Errorf("%s to %s has an empty or repeated subject %q", kind, e.to, e.subject)
+		}
+
+		subjects[e.to][e.subject] = true
+
+		for part, body := range map[string]string{"text": e.text, "html": e.html} {
+
+			if !strings.Contains(body, sent[i].want) {
+				t.Errorf("%s to %s: %s part doesn't show %q:\n%s", kind, e.to, part, sent[i].want, body)
+			}
+
+			if strings.Contains(body, "<no value>") {
+				t.Errorf("%s to %s: %s part renders a missing field:\n%s", kind, e.to, part, body)
+			}
+		}
+	}
+
+	// the id and en emails of a kind shouldn't come from the same template
+	for subject := range subjects["ayu@example.com"] {
+		if subjects["jane@example.com"][subject] {
+			t.Errorf("%q was sent in both locales", subject)
+		}
+	}
+}
+
+func TestDrainKeepsFailedNotificationsUntilTheLastAttempt(t *testing.T) {
+
+	nr := &outboxRepo{recipients: map[int]*entity.Recipient{
+		1: {Id: 1, FullName: "Ayu Lestari", Email: "ayu@example.com", Locale: entity.LocaleEnglish},
+	}}
+	n := &failingNotifier{}
+	j := newTestJob(t, nr, n)
+
+	j.Queue(&entity.Notification{Kind: entity.NotificationPasswordReset, UserId: 1, Data: &entity.PasswordReset{
+		Token:     "reset-token",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}})
+
+	for attempt := 1; attempt < maxNotificationAttempts; attempt++ {
+
+		j.drain()
+
+		if len(nr.outbox) != 1 {
+			t.Fatalf("notification left the outbox after attempt %d", attempt)
+		}
+	}
+
+	j.drain()
+
+	if len(nr.outbox) != 0 {
+		t.Fatal("notification is still in the outbox after the last attempt")
+	}
+
+	if n.sent != maxNotificationAttempts {
+		t.Fatalf("sent %d times, want %d", n.sent, maxNotificationAttempts)
+	}
+}
+
+func TestDrainDropsNotificationsForDeletedCustomers(t *testing.T) {
+
+	nr := &outboxRepo{recipients: map[int]*entity.Recipient{}}
+	n := &failingNotifier{}
+	j := newTestJob(t, nr, n)
+
+	j.Queue(&entity.Notification{Kind: entity.NotificationSignUp, UserId: 1})
+	j.drain()
+
+	if len(nr.outbox) != 0 || n.sent != 0 {
+		t.Fatalf("outbox has %d notifications after %d sends, want none", len(nr.outbox), n.sent)
+	}
+}
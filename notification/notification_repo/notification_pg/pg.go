@@ -0,0 +1,143 @@
+package notification_pg
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fashion-api/entity"
+	"fashion-api/notification/notification_repo"
+	"fashion-api/pkg/exception"
+	"log"
+	"time"
+)
+
+type notificationPg struct {
+	db *sql.DB
+}
+
+const (
+	fetchRecipientQuery = `select u.id, u.full_name, u.email, u.locale from "user" as u where u.id = $1 and u.deleted_at is null`
+
+	fetchTransactionRecipientQuery = `select u.id, u.full_name, u.email, u.locale from "transaction" as t
+		join "user" as u on u.id = t.user_id
+		where t.id = $1 and u.deleted_at is null`
+
+	addToOutboxQuery = `insert into notification_outbox (kind, user_id, transaction_id, data) values ($1, nullif($2, 0), nullif($3, 0), $4) returning id`
+
+	// skip locked lets several servers share the outbox without sending a
+	// notification twice
+	claimDueQuery = `update notification_outbox set attempts = attempts + 1, next_attempt_at = now() + make_interval(secs => $2 * power(2, attempts))
+		where id in (select id from notification_outbox where next_attempt_at <= now() order by next_attempt_at, id limit $1 for update skip locked)
+		returning id, kind, coalesce(user_id, 0), coalesce(transaction_id, 0), data, attempts`
+
+	removeFromOutboxQuery = `delete from notification_outbox where id = $1`
+)
+
+func NewNotificationPg(db *sql.DB) notification_repo.NotificationRepo {
+	return &notificationPg{
+		db: db,
+	}
+}
+
+// FetchRecipient implements notification_repo.NotificationRepo.
+func (pg *notificationPg) FetchRecipient(userId int) (*entity.Recipient, exception.Exception) {
+	return pg.fetchRecipient(fetchRecipientQuery, userId)
+}
+
+// FetchTransactionRecipient implements notification_repo.NotificationRepo.
+func (pg *notificationPg) FetchTransactionRecipient(transactionId int) (*entity.Recipient, exception.Exception) {
+	return pg.fetchRecipient(fetchTransactionRecipientQuery, transactionId)
+}
+
+func (pg *notificationPg) fetchRecipient(query string, id int) (*entity.Recipient, exception.Exception) {
+
+	recipient := &entity.Recipient{}
+
+	if err := pg.db.QueryRow(query, id).Scan(
+		&recipient.Id,
+		&recipient.FullName,
+		&recipient.Email,
+		&recipient.Locale,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, exception.NewNotFoundError("recipient not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return recipient, nil
+}
+
+// AddToOutbox implements notification_repo.NotificationRepo.
+func (pg *notificationPg) AddToOutbox(notification *entity.Notification) exception.Exception {
+
+	data, err := json.Marshal(notification.Data)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := pg.db.QueryRow(
+		addToOutboxQuery,
+		notification.Kind,
+		notification.UserId,
+		notification.TransactionId,
+		data,
+	).Scan(&notification.Id); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// ClaimDue implements notification_repo.NotificationRepo.
+func (pg *notificationPg) ClaimDue(limit int, retryDelay time.Duration) ([]*entity.Notification, exception.Exception) {
+
+	notifications := []*entity.Notification{}
+
+	rows, err := pg.db.Query(claimDueQuery, limit, retryDelay.Seconds())
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		notification, data := &entity.Notification{}, []byte{}
+
+		if err := rows.Scan(
+			&notification.Id,
+			&notification.Kind,
+			&notification.UserId,
+			&notification.TransactionId,
+			&data,
+			&notification.Attempts,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		notification.Data = json.RawMessage(data)
+
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, nil
+}
+
+// RemoveFromOutbox implements notification_repo.NotificationRepo.
+func (pg *notificationPg) RemoveFromOutbox(id int) exception.Exception {
+
+	if _, err := pg.db.Exec(removeFromOutboxQuery, id); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
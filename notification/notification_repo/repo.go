@@ -0,0 +1,18 @@
+package notification_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"time"
+)
+
+type NotificationRepo interface {
+	FetchRecipient(userId int) (*entity.Recipient, exception.Exception)
+	FetchTransactionRecipient(transactionId int) (*entity.Recipient, exception.Exception)
+	AddToOutbox(notification *entity.Notification) exception.Exception
+	// ClaimDue takes up to limit notifications that are due and counts the
+	// attempt, pushing the next one back by retryDelay doubled for every
+	// earlier attempt. Data comes back as the stored JSON.
+	ClaimDue(limit int, retryDelay time.Duration) ([]*entity.Notification, exception.Exception)
+	RemoveFromOutbox(id int) exception.Exception
+}
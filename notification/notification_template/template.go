@@ -0,0 +1,161 @@
+package notification_template
+
+import (
+	"bytes"
+	"embed"
+	"fashion-api/entity"
+	"fashion-api/notification/notifier"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// templates/<locale>/<kind>.txt holds the subject, in a "subject" block,
+// and the text body. templates/<locale>/<kind>.html holds the HTML body in
+// a "content" block that layout.html of the same locale wraps.
+//
+//go:embed templates
+var files embed.FS
+
+// Data is what every template renders. Data holds what the notification
+// is about, see the callers queueing each kind.
+type Data struct {
+	Recipient     *entity.Recipient
+	StoreName     string
+	URL           string
+	TransactionId int
+	Data          any
+}
+
+// Templates renders notifications in the language of their recipient,
+// falling back to the default locale for languages without templates.
+type Templates interface {
+	Render(kind string, data *Data) (*notifier.Message, error)
+}
+
+type localized struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+type templates struct {
+	defaultLocale string
+	locales       map[string]map[string]*localized
+}
+
+var funcs = map[string]any{
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04 MST")
+	},
+	"days": func(days *float64) string {
+		return fmt.Sprintf("%.1f", *days)
+	},
+}
+
+// NewTemplates parses the templates of every locale. The default locale
+// must have a template for every kind, other locales may leave some out.
+func NewTemplates(defaultLocale string) (Templates, error) {
+
+	dirs, err := files.ReadDir("templates")
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := &templates{
+		defaultLocale: defaultLocale,
+		locales:       map[string]map[string]*localized{},
+	}
+
+	for _, dir := range dirs {
+
+		if !dir.IsDir() {
+			continue
+		}
+
+		locale := dir.Name()
+		t.locales[locale] = map[string]*localized{}
+
+		for _, kind := range entity.NotificationKinds {
+
+			base := "templates/" + locale + "/" + kind
+
+			if _, err := fs.Stat(files, base+".txt"); err != nil {
+				continue
+			}
+
+			text, err := texttemplate.New(kind+".txt").Funcs(funcs).ParseFS(files, base+".txt")
+
+			if err != nil {
+				return nil, err
+			}
+
+			html, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(files, "templates/"+locale+"/layout.html", base+".html")
+
+			if err != nil {
+				return nil, err
+			}
+
+			t.locales[locale][kind] = &localized{text: text, html: html}
+		}
+	}
+
+	for _, kind := range entity.NotificationKinds {
+		if t.locales[defaultLocale][kind] == nil {
+			return nil, fmt.Errorf("default locale %q has no %s template", defaultLocale, kind)
+		}
+	}
+
+	return t, nil
+}
+
+// Render implements Templates.
+func (t *templates) Render(kind string, data *Data) (*notifier.Message, error) {
+
+	tmpl := t.lookup(kind, data.Recipient.Locale)
+
+	if tmpl == nil {
+		return nil, fmt.Errorf("no %s template", kind)
+	}
+
+	subject, text, html := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
+
+	if err := tmpl.text.ExecuteTemplate(subject, "subject", data); err != nil {
+		return nil, err
+	}
+
+	if err := tmpl.text.Execute(text, data); err != nil {
+		return nil, err
+	}
+
+	if err := tmpl.html.Execute(html, data); err != nil {
+		return nil, err
+	}
+
+	return &notifier.Message{
+		To:      data.Recipient.Email,
+		Name:    data.Recipient.FullName,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// lookup tries the locale as given, then its language without the region,
+// so id-ID uses the id templates, then the default locale.
+func (t *templates) lookup(kind string, locale string) *localized {
+
+	locale = strings.ToLower(locale)
+	language, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+
+	for _, candidate := range []string{locale, language, t.defaultLocale} {
+		if tmpl := t.locales[candidate][kind]; tmpl != nil {
+			return tmpl
+		}
+	}
+
+	return nil
+}
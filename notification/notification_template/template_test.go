@@ -0,0 +1,239 @@
+package notification_template
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/money"
+	"strings"
+	"testing"
+	"time"
+)
+
+// notificationCase is one kind with the payload its caller queues and a
+// value from the payload each rendered part has to show.
+type notificationCase struct {
+	kind          string
+	transactionId int
+	data          any
+	want          string
+}
+
+func notificationCases() []notificationCase {
+
+	shippedAt := time.Date(2026, 3, 4, 10, 30, 0, 0, time.UTC)
+	daysUntilStockout := 2.5
+
+	return []notificationCase{
+		{
+			kind: entity.NotificationSignUp,
+			want: "Ayu Lestari",
+		},
+		{
+			kind:          entity.NotificationOrderPlaced,
+			transactionId: 7,
+			data: &entity.Transaction{
+				Id:            7,
+				OrderId:       42,
+				Currency:      "USD",
+				Subtotal:      money.New(12000, "USD"),
+				DiscountTotal: money.New(1000, "USD"),
+				TaxTotal:      money.New(990, "USD"),
+				ShippingTotal: money.New(500, "USD"),
+				GrandTotal:    money.New(12490, "USD"),
+				Items: []*entity.TransactionItem{
+					{ProductName: "Linen Shirt", Qty: 2, Total: money.New(11990, "USD")},
+				},
+				ShippingAddress: &entity.Address{
+					Recipient:  "Ayu Lestari",
+					Line1:      "Jl. Sudirman 1",
+					City:       "Jakarta",
+					Region:     "DKI Jakarta",
+					PostalCode: "10210",
+					Country:    "ID",
+				},
+			},
+			want: "124.90 USD",
+		},
+		{
+			kind:          entity.NotificationPaymentReceived,
+			transactionId: 7,
+			data: &entity.Payment{
+				TransactionId: 7,
+				Amount:        money.New(12490, "USD"),
+			},
+			want: "124.90 USD",
+		},
+		{
+			kind: entity.NotificationShipped,
+			data: &entity.Shipment{
+				OrderId:        42,
+				Carrier:        "JNE",
+				TrackingNumber: "JNE123456",
+				ShippedAt:      shippedAt,
+			},
+			want: "#42",
+		},
+		{
+			kind:          entity.NotificationRefund,
+			transactionId: 7,
+			data: &entity.PaymentRefund{
+				Amount: money.New(150000, "IDR"),
+				Reason: "order #42 cancelled",
+			},
+			want: "150000 IDR",
+		},
+		{
+			kind: entity.NotificationPasswordReset,
+			data: &entity.PasswordReset{
+				Token:     "reset-token",
+				ExpiresAt: shippedAt,
+			},
+			want: "reset-token",
+		},
+		{
+			kind: entity.NotificationBackInStock,
+			data: &entity.BackInStock{
+				ProductId: 3,
+				Name:      "Linen Shirt",
+				Slug:      "linen-shirt",
+			},
+			want: "https://shop.example.com/products/slug/linen-shirt",
+		},
+		{
+			kind: entity.NotificationLowStock,
+			data: &entity.LowStockAlert{
+				Items: []*entity.LowStockItem{
+					{ProductId: 3, Name: "Linen Shirt", Available: 2, ReorderThreshold: 5, DaysUntilStockout: &daysUntilStockout},
+					{ProductId: 4, Name: "Silk Scarf", Available: 1, ReorderThreshold: 3},
+				},
+			},
+			want: "2.5",
+		},
+	}
+}
+
+func TestEveryKindHasACase(t *testing.T) {
+
+	covered := map[string]bool{}
+
+	for _, c := range notificationCases() {
+		covered[c.kind] = true
+	}
+
+	for _, kind := range entity.NotificationKinds {
+		if !covered[kind] {
+			t.Errorf("%s has no test case", kind)
+		}
+	}
+}
+
+func TestRenderEveryKindInEveryLocale(t *testing.T) {
+
+	tmpl, err := NewTemplates(entity.LocaleEnglish)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, locale := range []string{entity.LocaleEnglish, entity.LocaleIndonesian} {
+		for _, c := range notificationCases() {
+			t.Run(locale+"/"+c.kind, func(t *testing.T) {
+
+				message, err := tmpl.Render(c.kind, &Data{
+					Recipient:     &entity.Recipient{Id: 1, FullName: "Ayu Lestari", Email: "ayu@example.com", Locale: locale},
+					StoreName:     "Fashion",
+					URL:           "https://shop.example.com",
+					TransactionId: c.transactionId,
+					Data:          c.data,
+				})
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if message.To != "ayu@example.com" || message.Name != "Ayu Lestari" {
+					t.Errorf("message is addressed to %q <%s>", message.Name, message.To)
+				}
+
+				if message.Subject == "" {
+					t.Error("subject is empty")
+				}
+
+				for part, body := range map[string]string{"text": message.Body, "html": message.HTML} {
+
+					if !strings.Contains(body, c.want) {
+						t.Errorf("%s body doesn't show %q:\n%s", part, c.want, body)
+					}
+
+					if strings.Contains(body, "<no value>") {
+						t.Errorf("%s body renders a missing field:\n%s", part, body)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRenderIndonesianDiffersFromEnglish(t *testing.T) {
+
+	tmpl, err := NewTemplates(entity.LocaleEnglish)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range notificationCases() {
+
+		subjects := map[string]string{}
+
+		for _, locale := range []string{entity.LocaleEnglish, entity.LocaleIndonesian} {
+
+			message, err := tmpl.Render(c.kind, &Data{
+				Recipient:     &entity.Recipient{FullName: "Ayu Lestari", Email: "ayu@example.com", Locale: locale},
+				StoreName:     "Fashion",
+				TransactionId: c.transactionId,
+				Data:          c.data,
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			subjects[locale] = message.Subject
+		}
+
+		if subjects[entity.LocaleEnglish] == subjects[entity.LocaleIndonesian] {
+			t.Errorf("%s is rendered from the english template for id: %q", c.kind, subjects[entity.LocaleIndonesian])
+		}
+	}
+}
+
+func TestRenderFallsBackToTheLanguageAndDefaultLocale(t *testing.T) {
+
+	tmpl, err := NewTemplates(entity.LocaleEnglish)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	render := func(locale string) string {
+
+		message, err := tmpl.Render(entity.NotificationSignUp, &Data{
+			Recipient: &entity.Recipient{FullName: "Ayu Lestari", Email: "ayu@example.com", Locale: locale},
+			StoreName: "Fashion",
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return message.Subject
+	}
+
+	if render("id-ID") != render(entity.LocaleIndonesian) {
+		t.Error("id-ID doesn't use the id templates")
+	}
+
+	if render("fr") != render(entity.LocaleEnglish) {
+		t.Error("a locale without templates doesn't fall back to the default locale")
+	}
+}
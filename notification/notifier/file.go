@@ -0,0 +1,78 @@
+package notifier
+
+import (
+	"fashion-api/pkg/exception"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type fileNotifier struct {
+	settings *Settings
+	sequence atomic.Int64
+}
+
+// NewFileNotifier returns a notifier that writes every message to the
+// outbox directory as an .eml file, exactly as it would go out over SMTP.
+// It lets development and tests look at rendered emails without a mail
+// server.
+func NewFileNotifier(settings *Settings) Notifier {
+	return &fileNotifier{
+		settings: settings,
+	}
+}
+
+// Name implements Notifier.
+func (fn *fileNotifier) Name() string {
+	return "file"
+}
+
+// Send implements Notifier.
+func (fn *fileNotifier) Send(message *Message) exception.Exception {
+
+	sender, err := mail.ParseAddress(fn.settings.From)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	now := time.Now()
+	msg, err := buildMIME(sender, message, now)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := os.MkdirAll(fn.settings.OutboxDir, 0755); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	// names sort in the order the messages were sent
+	name := fmt.Sprintf("%s-%06d-%s.eml", now.UTC().Format("20060102T150405"), fn.sequence.Add(1), fileSafe(message.To))
+
+	if err := os.WriteFile(filepath.Join(fn.settings.OutboxDir, name), msg, 0644); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+func fileSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '@':
+			return r
+		default:
+			return '_'
+		}
+	}, s)
+}
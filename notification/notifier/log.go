@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"fashion-api/pkg/exception"
+	"log"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a notifier that only writes messages to the log,
+// meant for development.
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+// Name implements Notifier.
+func (ln *logNotifier) Name() string {
+	return "log"
+}
+
+// Send implements Notifier.
+func (ln *logNotifier) Send(message *Message) exception.Exception {
+	log.Printf("[notifier] to %s <%s>: %s\n%s", message.Name, message.To, message.Subject, message.Body)
+	return nil
+}
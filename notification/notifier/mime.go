@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// buildMIME encodes message as an email from sender, with the text body and
+// the HTML body, if there is one, as alternatives of each other.
+func buildMIME(sender *mail.Address, message *Message, sentAt time.Time) ([]byte, error) {
+
+	buf := &bytes.Buffer{}
+	body := multipart.NewWriter(buf)
+	recipient := &mail.Address{Name: message.Name, Address: message.To}
+
+	id := make([]byte, 16)
+
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	headers := [][2]string{
+		{"From", sender.String()},
+		{"To", recipient.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", message.Subject)},
+		{"Date", sentAt.Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain(sender.Address))},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + body.Boundary()},
+	}
+
+	head := &bytes.Buffer{}
+
+	for _, header := range headers {
+		fmt.Fprintf(head, "%s: %s\r\n", header[0], header[1])
+	}
+
+	head.WriteString("\r\n")
+
+	parts := [][2]string{{"text/plain", message.Body}}
+
+	if message.HTML != "" {
+		parts = append(parts, [2]string{"text/html", message.HTML})
+	}
+
+	for _, part := range parts {
+
+		w, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part[0] + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+
+		if _, err := qp.Write([]byte(part[1])); err != nil {
+			return nil, err
+		}
+
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+func domain(address string) string {
+
+	if i := strings.LastIndex(address, "@"); i >= 0 {
+		return address[i+1:]
+	}
+
+	return "localhost"
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import "fashion-api/pkg/exception"
+
+// Message is a notification addressed to one customer. Body is plain text,
+// HTML is an optional alternative for clients that can show it.
+type Message struct {
+	To      string `json:"to"`
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	HTML    string `json:"html,omitempty"`
+}
+
+// Settings configures the notifiers that deliver email, From is used as
+// the sender of every message.
+type Settings struct {
+	From         string
+	OutboxDir    string
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+}
+
+// Notifier delivers messages to customers. A delivery channel can be
+// plugged in by implementing it and registering it in NewNotifier.
+type Notifier interface {
+	Name() string
+	Send(message *Message) exception.Exception
+}
+
+func NewNotifier(name string, settings *Settings) Notifier {
+	switch name {
+	case "smtp":
+		return NewSMTPNotifier(settings)
+	case "file":
+		return NewFileNotifier(settings)
+	default:
+		return NewLogNotifier()
+	}
+}
@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"fashion-api/pkg/exception"
+	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+type smtpNotifier struct {
+	settings *Settings
+}
+
+// NewSMTPNotifier returns a notifier that sends email through the
+// configured SMTP server, authenticating when a username is set.
+func NewSMTPNotifier(settings *Settings) Notifier {
+	return &smtpNotifier{
+		settings: settings,
+	}
+}
+
+// Name implements Notifier.
+func (sn *smtpNotifier) Name() string {
+	return "smtp"
+}
+
+// Send implements Notifier.
+func (sn *smtpNotifier) Send(message *Message) exception.Exception {
+
+	sender, err := mail.ParseAddress(sn.settings.From)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	msg, err := buildMIME(sender, message, time.Now())
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	var auth smtp.Auth
+
+	if sn.settings.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", sn.settings.SMTPUsername, sn.settings.SMTPPassword, sn.settings.SMTPHost)
+	}
+
+	addr := net.JoinHostPort(sn.settings.SMTPHost, sn.settings.SMTPPort)
+
+	if err := smtp.SendMail(addr, auth, sender.Address, []string{message.To}, msg); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
@@ -7,9 +7,12 @@ import (
 	"fashion-api/order/order_service"
 	"fashion-api/pkg/exception"
 	"fashion-api/pkg/helper"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type orderHandler struct {
@@ -19,8 +22,10 @@ type orderHandler struct {
 type OrderHandler interface {
 	Add(w http.ResponseWriter, r *http.Request)
 	Fetch(w http.ResponseWriter, r *http.Request)
+	FetchDetail(w http.ResponseWriter, r *http.Request)
 	Modify(w http.ResponseWriter, r *http.Request)
 	Remove(w http.ResponseWriter, r *http.Request)
+	Cancel(w http.ResponseWriter, r *http.Request)
 }
 
 func NewOrderHandler(os order_service.OrderService) OrderHandler {
@@ -51,7 +56,15 @@ func (oh *orderHandler) Add(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := oh.os.Add(user.Id, payload)
+	currency, err := helper.RequestCurrency(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := oh.os.Add(user.Id, currency, payload)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -69,7 +82,33 @@ func (oh *orderHandler) Fetch(w http.ResponseWriter, r *http.Request) {
 
 	user, _ := r.Context().Value("userData").(*entity.User)
 
-	res, err := oh.os.Fetch(user.Id)
+	query, err := helper.HistoryQuery(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := oh.os.Fetch(user.Id, query)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchDetail implements OrderHandler.
+func (oh *orderHandler) FetchDetail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := oh.os.FetchDetail(id)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -137,3 +176,36 @@ func (oh *orderHandler) Remove(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(res.Status)
 	w.Write(helper.ResponseJSON(res))
 }
+
+// Cancel implements OrderHandler.
+func (oh *orderHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	user := r.Context().Value("userData").(*entity.User)
+
+	path := strings.Split(r.URL.Path, "/")
+
+	id, _ := strconv.Atoi(path[2])
+
+	payload := &dto.CancelOrderPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil && err != io.EOF {
+		invalidJsonBodyRequest := exception.NewUnprocessableEntityError("invalid json body request")
+
+		w.WriteHeader(invalidJsonBodyRequest.Status())
+		w.Write(helper.ResponseJSON(invalidJsonBodyRequest))
+
+		return
+	}
+
+	res, err := oh.os.Cancel(user, id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -0,0 +1,13 @@
+package order_repo
+
+import "time"
+
+// OrderFilter narrows the order history of one user, newest first.
+type OrderFilter struct {
+	UserId int
+	From   *time.Time
+	To     *time.Time
+	Status string
+	Limit  int
+	Offset int
+}
@@ -5,7 +5,12 @@ import (
 	"fashion-api/entity"
 	"fashion-api/order/order_repo"
 	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+	"fmt"
 	"log"
+	"strings"
+
+	"github.com/lib/pq"
 )
 
 type orderPg struct {
@@ -19,15 +24,81 @@ func NewOrderPg(db *sql.DB) order_repo.OrderRepo {
 }
 
 const (
-	addOrderQuery = `insert into "order" (user_id, product_id, qty, total_price) values ($1, $2, $3, ((select p.price from product as p where id = $2)) * $3);`
-
-	fetchOrderQuery = `select o.id, o.user_id, o.product_id, p.name, p.price, o.qty, o.total_price, o.created_at, o.updated_at from "order" as o left join product as p on o.product_id = p.id where o.user_id = $1 and o.deleted_at is null;`
-
-	fetchUserIdQuery = `select user_id, product_id from "order" where id = $1`
-
-	modifyOrderQuery = `update "order" set qty = $2, total_price = ((select p.price from product as p where id = (select o.product_id from "order" as o where id = $1)) * $2), updated_at = now() where id = $1;`
-
-	deleteOrderQuery = `update "order" set deleted_at = now(), updated_at = now() where id = $1;`
+	addOrderQuery = `insert into "order" (user_id, product_id, qty, total_price, currency) values ($1, $2, $3, $4, $5);`
+
+	// the unit price is derived from the order itself so it is shown in the
+	// currency and at the price the customer ordered at. Paid and cancelled
+	// orders are soft deleted too but stay in the history, only the pending
+	// ones the customer removed are left out.
+	fetchOrderQuery = `select o.id, o.user_id, o.product_id, p.name, o.total_price / greatest(o.qty, 1), o.qty, o.total_price, o.currency, coalesce(o.shipping_method_name, ''), o.shipping_cost, o.status, o.created_at, o.updated_at from "order" as o left join product as p on o.product_id = p.id where o.user_id = $1 and (o.deleted_at is null or o.status <> 'pending')`
+
+	countOrderQuery = `select count(*) from "order" as o where o.user_id = $1 and (o.deleted_at is null or o.status <> 'pending')`
+
+	fetchOrderDetailQuery = `select o.id, o.user_id, o.product_id, coalesce(p.name, ''), o.total_price / greatest(o.qty, 1), o.qty, o.total_price, o.currency, coalesce(o.shipping_method_name, ''), o.shipping_cost, o.status,
+			coalesce(o.shipping_recipient, ''), coalesce(o.shipping_line1, ''), coalesce(o.shipping_city, ''), coalesce(o.shipping_region, ''), coalesce(o.shipping_postal_code, ''), coalesce(o.shipping_country, ''),
+			coalesce(o.cancel_reason, ''), o.cancelled_at, o.created_at, o.updated_at
+		from "order" as o left join product as p on o.product_id = p.id where o.id = $1 and (o.deleted_at is null or o.status <> 'pending')`
+
+	// the first webhook received for a payment is the one settling it
+	fetchOrderPaymentsQuery = `select p.id, p.transaction_id, p.provider, p.intent_id, p.amount, p.refunded_amount, p.currency, p.status, (select min(e.received_at) from payment_webhook_event as e where e.intent_id = p.intent_id), p.created_at, p.updated_at
+		from payment as p join transaction as t on p.transaction_id = t.id where t.order_id = $1 order by p.id`
+
+	fetchOrderRefundsQuery = `select r.id, r.payment_id, r.provider_refund_id, r.amount, p.currency, coalesce(r.reason, ''), r.created_at
+		from payment_refund as r join payment as p on r.payment_id = p.id join transaction as t on p.transaction_id = t.id where t.order_id = $1 order by r.id`
+
+	// paid orders are soft deleted by the payment trigger, so only a pending
+	// order the customer removed is treated as gone
+	fetchUserIdQuery = `select id, user_id, product_id, qty, total_price, currency, coalesce(shipping_method_id, 0), coalesce(shipping_method_name, ''), shipping_cost, status from "order" where id = $1 and (deleted_at is null or status <> 'pending')`
+
+	modifyOrderQuery = `update "order" set qty = $2, total_price = $3, updated_at = now() where id = $1;`
+
+	// only pending orders can be removed, the history tells removed orders
+	// apart by that
+	deleteOrderQuery = `update "order" set deleted_at = now(), updated_at = now() where id = $1 and status = 'pending';`
+
+	cancelOrderQuery = `update "order" set status = 'cancelled', cancelled_by = $3, cancel_reason = $4, cancelled_at = now(), deleted_at = coalesce(deleted_at, now()), updated_at = now() where id = $1 and status = $2`
+
+	cancelPendingPaymentQuery = `update payment set status = 'cancelled', updated_at = now() where status = 'pending' and transaction_id in (select id from transaction where order_id = $1)`
+
+	cancelPendingTransactionQuery = `update transaction set status = 'cancelled', updated_at = now() where order_id = $1 and status = 'pending'`
+
+	// the allocations of a cancelled order are dropped, the reservation is
+	// released at the locations they were made at
+	releaseReservedStockQuery = `with released as (
+			delete from order_allocation where order_id = $1 returning location_id, qty
+		), moved as (
+			update stock_level set reserved = reserved - r.qty from released as r where stock_level.product_id = $2 and stock_level.location_id = r.location_id
+			returning stock_level.product_id, stock_level.location_id, r.qty, stock_level.stock, stock_level.reserved
+		), aggregated as (
+			update product set reserved = reserved - $3, updated_at = now() where id = $2
+		)
+		insert into stock_movement (product_id, location_id, type, reserved_qty, stock_after, reserved_after, reason, reference, actor_id)
+		select product_id, location_id, 'reservation', -qty, stock, reserved, 'order cancelled', 'order #' || $1::int, $4 from moved`
+
+	// tax lines of failed payments are left out, the order is priced again
+	// at the next checkout
+	fetchTaxLinesByOrderIdsQuery = `select tl.id, tl.order_id, tl.transaction_id, tl.product_id, coalesce(tl.tax_rate_id, 0), tl.name, tl.rate, tl.inclusive, tl.taxable_amount, tl.amount, t.currency, tl.created_at from tax_line as tl join transaction as t on tl.transaction_id = t.id where tl.order_id = any($1) and t.status <> 'failed' order by tl.id`
+
+	// sold units still in the warehouse go back to the locations the order
+	// was allocated to, largest allocation first. Shipped units have left
+	// and returned ones were restocked when the return was received, orders
+	// from before shipments are covered by the restocked returns
+	restoreSoldStockQuery = `with remaining as (
+			select greatest($3::int - greatest(
+				(select coalesce(sum(si.qty), 0) from shipment_item as si join shipment as s on s.id = si.shipment_id where s.order_id = $1),
+				(select coalesce(sum(r.qty), 0) from return_request as r where r.order_id = $1 and r.restocked)
+			), 0) as qty
+		), allocated as (
+			select a.location_id, least(a.qty, greatest(r.qty - coalesce(sum(a.qty) over (order by a.qty desc, a.location_id asc rows between unbounded preceding and 1 preceding), 0), 0)) as qty
+			from order_allocation as a cross join remaining as r where a.order_id = $1
+		), moved as (
+			update stock_level set stock = stock + al.qty from allocated as al where al.qty > 0 and stock_level.product_id = $2 and stock_level.location_id = al.location_id
+			returning stock_level.product_id, stock_level.location_id, al.qty, stock_level.stock, stock_level.reserved
+		), aggregated as (
+			update product set stock = stock + r.qty, sold = greatest(sold - r.qty, 0), updated_at = now() from remaining as r where product.id = $2
+		)
+		insert into stock_movement (product_id, location_id, type, qty, stock_after, reserved_after, reason, reference, actor_id)
+		select product_id, location_id, 'return', qty, stock, reserved, 'order cancelled', 'order #' || $1::int, $4 from moved`
 )
 
 // Add implements order_repo.OrderRepo.
@@ -49,7 +120,7 @@ func (pg *orderPg) Add(order *entity.Order) exception.Exception {
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(order.UserId, order.ProductId, order.Qty); err != nil {
+	if _, err := stmt.Exec(order.UserId, order.ProductId, order.Qty, order.TotalPrice.Amount, order.TotalPrice.Currency); err != nil {
 		tx.Rollback()
 		log.Println(err.Error())
 		return exception.NewInternalServerError("something went wrong")
@@ -67,23 +138,30 @@ func (pg *orderPg) Add(order *entity.Order) exception.Exception {
 }
 
 // Fetch implements order_repo.OrderRepo.
-func (pg *orderPg) Fetch(userId int) ([]*entity.OrderWithProduct, exception.Exception) {
-	orders := []*entity.OrderWithProduct{}
+func (pg *orderPg) Fetch(filter *order_repo.OrderFilter) ([]*entity.OrderWithProduct, int, exception.Exception) {
 
-	stmt, err := pg.db.Prepare(fetchOrderQuery)
+	orders, total := []*entity.OrderWithProduct{}, 0
 
-	if err != nil {
+	where, args := orderFilterConditions(filter)
+
+	if err := pg.db.QueryRow(countOrderQuery+where, args...).Scan(&total); err != nil {
 		log.Println(err.Error())
-		return nil, exception.NewInternalServerError("something went wrong")
+		return nil, 0, exception.NewInternalServerError("something went wrong")
 	}
 
-	rows, err := stmt.Query(userId)
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := fmt.Sprintf("%s%s order by o.created_at desc, o.id desc limit $%d offset $%d", fetchOrderQuery, where, len(args)-1, len(args))
+
+	rows, err := pg.db.Query(query, args...)
 
 	if err != nil {
 		log.Println(err.Error())
-		return nil, exception.NewInternalServerError("something went wrong")
+		return nil, 0, exception.NewInternalServerError("something went wrong")
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
 		order := entity.OrderWithProduct{}
 
@@ -92,22 +170,178 @@ func (pg *orderPg) Fetch(userId int) ([]*entity.OrderWithProduct, exception.Exce
 			&order.UserId,
 			&order.ProductId,
 			&order.ProductName,
-			&order.ProductPrice,
+			&order.ProductPrice.Amount,
 			&order.Qty,
-			&order.TotalPrice,
+			&order.TotalPrice.Amount,
+			&order.TotalPrice.Currency,
+			&order.ShippingMethodName,
+			&order.ShippingCost.Amount,
+			&order.Status,
 			&order.CreatedAt,
 			&order.UpdatedAt,
 		); err != nil {
 			log.Println(err.Error())
-			return nil, exception.NewInternalServerError("something went wrong")
+			return nil, 0, exception.NewInternalServerError("something went wrong")
 		}
 
+		order.ProductPrice.Currency = order.TotalPrice.Currency
+		order.ShippingCost.Currency = order.TotalPrice.Currency
+
 		orders = append(orders, &order)
 	}
 
-	stmt.Close()
+	return orders, total, nil
+}
 
-	return orders, nil
+// FetchDetail implements order_repo.OrderRepo.
+func (pg *orderPg) FetchDetail(id int) (*entity.OrderDetail, exception.Exception) {
+
+	detail, item, address := entity.OrderDetail{}, entity.OrderItem{}, entity.Address{}
+	shippingMethod, shippingCost, cancelledAt := "", int64(0), sql.NullTime{}
+
+	if err := pg.db.QueryRow(fetchOrderDetailQuery, id).Scan(
+		&detail.Id,
+		&detail.UserId,
+		&item.ProductId,
+		&item.ProductName,
+		&item.UnitPrice.Amount,
+		&item.Qty,
+		&item.Total.Amount,
+		&detail.Currency,
+		&shippingMethod,
+		&shippingCost,
+		&detail.Status,
+		&address.Recipient,
+		&address.Line1,
+		&address.City,
+		&address.Region,
+		&address.PostalCode,
+		&address.Country,
+		&detail.CancelReason,
+		&cancelledAt,
+		&detail.CreatedAt,
+		&detail.UpdatedAt,
+	); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("order not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	// until checkout the order itself is the only line, priced as ordered
+	zero := money.New(0, detail.Currency)
+
+	item.UnitPrice.Currency, item.Total.Currency = detail.Currency, detail.Currency
+	item.Subtotal, item.Discount, item.Tax = item.Total, zero, zero
+
+	detail.Items = []*entity.OrderItem{&item}
+	detail.Subtotal, detail.DiscountTotal, detail.TaxTotal = item.Total, zero, zero
+	detail.ShippingTotal, detail.GrandTotal = zero, item.Total
+
+	if shippingMethod != "" {
+		detail.Shipping = &entity.OrderShipping{
+			Method: shippingMethod,
+			Cost:   money.New(shippingCost, detail.Currency),
+		}
+	}
+
+	if address.Line1 != "" {
+		detail.ShippingAddress = &address
+	}
+
+	if cancelledAt.Valid {
+		detail.CancelledAt = &cancelledAt.Time
+	}
+
+	return &detail, nil
+}
+
+// FetchPayments implements order_repo.OrderRepo.
+func (pg *orderPg) FetchPayments(orderId int) ([]*entity.Payment, exception.Exception) {
+
+	payments := []*entity.Payment{}
+
+	rows, err := pg.db.Query(fetchOrderPaymentsQuery, orderId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		payment, settledAt := entity.Payment{}, sql.NullTime{}
+
+		if err := rows.Scan(
+			&payment.Id,
+			&payment.TransactionId,
+			&payment.Provider,
+			&payment.IntentId,
+			&payment.Amount.Amount,
+			&payment.RefundedAmount.Amount,
+			&payment.Amount.Currency,
+			&payment.Status,
+			&settledAt,
+			&payment.CreatedAt,
+			&payment.UpdatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		payment.RefundedAmount.Currency = payment.Amount.Currency
+
+		if settledAt.Valid && payment.Status != entity.PaymentStatusPending {
+			payment.SettledAt = &settledAt.Time
+		}
+
+		payments = append(payments, &payment)
+	}
+
+	return payments, nil
+}
+
+// FetchRefunds implements order_repo.OrderRepo.
+func (pg *orderPg) FetchRefunds(orderId int) ([]*entity.PaymentRefund, exception.Exception) {
+
+	refunds := []*entity.PaymentRefund{}
+
+	rows, err := pg.db.Query(fetchOrderRefundsQuery, orderId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		refund := entity.PaymentRefund{}
+
+		if err := rows.Scan(
+			&refund.Id,
+			&refund.PaymentId,
+			&refund.ProviderRefundId,
+			&refund.Amount.Amount,
+			&refund.Amount.Currency,
+			&refund.Reason,
+			&refund.CreatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		refunds = append(refunds, &refund)
+	}
+
+	return refunds, nil
 }
 
 // FetchOrderById implements order_repo.OrderRepo.
@@ -123,8 +357,16 @@ func (pg *orderPg) FetchOrderById(id int) (*entity.Order, exception.Exception) {
 	}
 
 	if err := stmt.QueryRow(id).Scan(
+		&order.Id,
 		&order.UserId,
 		&order.ProductId,
+		&order.Qty,
+		&order.TotalPrice.Amount,
+		&order.TotalPrice.Currency,
+		&order.ShippingMethodId,
+		&order.ShippingMethodName,
+		&order.ShippingCost.Amount,
+		&order.Status,
 	); err != nil {
 
 		if err == sql.ErrNoRows {
@@ -136,6 +378,8 @@ func (pg *orderPg) FetchOrderById(id int) (*entity.Order, exception.Exception) {
 		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
+	order.ShippingCost.Currency = order.TotalPrice.Currency
+
 	return &order, nil
 }
 
@@ -158,7 +402,7 @@ func (pg *orderPg) Modify(order *entity.Order) exception.Exception {
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(order.Id, order.Qty); err != nil {
+	if _, err := stmt.Exec(order.Id, order.Qty, order.TotalPrice.Amount); err != nil {
 		tx.Rollback()
 		log.Println(err.Error())
 		return exception.NewInternalServerError("something went wrong")
@@ -194,12 +438,20 @@ func (pg *orderPg) Remove(id int) exception.Exception {
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(id); err != nil {
+	res, err := stmt.Exec(id)
+
+	if err != nil {
 		tx.Rollback()
 		log.Println(err.Error())
 		return exception.NewInternalServerError("something went wrong")
 	}
 
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		stmt.Close()
+		tx.Rollback()
+		return exception.NewConflictError("order status has changed, please try again")
+	}
+
 	if err := tx.Commit(); err != nil {
 		tx.Rollback()
 		log.Println(err.Error())
@@ -210,3 +462,145 @@ func (pg *orderPg) Remove(id int) exception.Exception {
 
 	return nil
 }
+
+// Cancel implements order_repo.OrderRepo. The order row stays locked while
+// refund runs, so a concurrent cancel waits and then finds it cancelled.
+func (pg *orderPg) Cancel(order *entity.Order, cancelledBy int, reason string, refund func() exception.Exception) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	res, err := tx.Exec(cancelOrderQuery, order.Id, order.Status, cancelledBy, reason)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewConflictError("order status has changed, please try again")
+	}
+
+	stockQueries := []string{}
+
+	switch order.Status {
+	case entity.OrderStatusAwaitingPayment:
+		if _, err := tx.Exec(cancelPendingPaymentQuery, order.Id); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+
+		if _, err := tx.Exec(cancelPendingTransactionQuery, order.Id); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+
+		stockQueries = append(stockQueries, releaseReservedStockQuery)
+	case entity.OrderStatusPaid, entity.OrderStatusShipped, entity.OrderStatusDelivered:
+		// only the units that never shipped or came back are restocked
+		stockQueries = append(stockQueries, restoreSoldStockQuery)
+	}
+
+	for _, query := range stockQueries {
+		if _, err := tx.Exec(query, order.Id, order.ProductId, order.Qty, cancelledBy); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+	}
+
+	if err := refund(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("order #%d was refunded but its cancellation couldn't be saved: %s", order.Id, err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchTaxLines implements order_repo.OrderRepo.
+func (pg *orderPg) FetchTaxLines(orderIds []int) ([]*entity.TaxLine, exception.Exception) {
+
+	lines := []*entity.TaxLine{}
+
+	rows, err := pg.db.Query(fetchTaxLinesByOrderIdsQuery, pq.Array(orderIds))
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		line := entity.TaxLine{}
+
+		if err := rows.Scan(
+			&line.Id,
+			&line.OrderId,
+			&line.TransactionId,
+			&line.ProductId,
+			&line.TaxRateId,
+			&line.Name,
+			&line.Rate,
+			&line.Inclusive,
+			&line.TaxableAmount.Amount,
+			&line.Amount.Amount,
+			&line.Amount.Currency,
+			&line.CreatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		line.TaxableAmount.Currency = line.Amount.Currency
+
+		lines = append(lines, &line)
+	}
+
+	return lines, nil
+}
+
+// orderFilterConditions turns the filter into "and ..." conditions on the
+// order alias o, $1 is always the user id.
+func orderFilterConditions(filter *order_repo.OrderFilter) (string, []any) {
+
+	conditions, args := []string{}, []any{filter.UserId}
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.From != nil {
+		add("o.created_at >= $%d", *filter.From)
+	}
+
+	if filter.To != nil {
+		add("o.created_at < $%d", *filter.To)
+	}
+
+	if filter.Status != "" {
+		add("o.status = $%d", filter.Status)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " and " + strings.Join(conditions, " and "), args
+}
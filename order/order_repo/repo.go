@@ -7,8 +7,15 @@ import (
 
 type OrderRepo interface {
 	Add(order *entity.Order) exception.Exception
-	Fetch(userId int) ([]*entity.OrderWithProduct, exception.Exception)
+	Fetch(filter *OrderFilter) ([]*entity.OrderWithProduct, int, exception.Exception)
+	FetchDetail(id int) (*entity.OrderDetail, exception.Exception)
+	FetchPayments(orderId int) ([]*entity.Payment, exception.Exception)
+	FetchRefunds(orderId int) ([]*entity.PaymentRefund, exception.Exception)
 	Modify(order *entity.Order) exception.Exception
 	Remove(id int) exception.Exception
 	FetchOrderById(id int) (*entity.Order, exception.Exception)
+	// Cancel cancels the order and calls refund before committing, the
+	// cancellation is rolled back when refund fails
+	Cancel(order *entity.Order, cancelledBy int, reason string, refund func() exception.Exception) exception.Exception
+	FetchTaxLines(orderIds []int) ([]*entity.TaxLine, exception.Exception)
 }
@@ -1,14 +1,21 @@
 package order_service
 
 import (
+	"fashion-api/currency/currency_service"
 	"fashion-api/dto"
 	"fashion-api/entity"
 	"fashion-api/order/order_repo"
+	"fashion-api/payment/payment_service"
 	"fashion-api/pkg/exception"
 	"fashion-api/pkg/helper"
 	"fashion-api/product/product_repo"
+	"fashion-api/shipment/shipment_repo"
+	"fashion-api/transaction/transaction_repo"
+	"fashion-api/wishlist/wishlist_job"
+	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"net/http"
 )
@@ -16,25 +23,37 @@ import (
 type orderService struct {
 	or order_repo.OrderRepo
 	pr product_repo.ProductRepo
+	tr transaction_repo.TransactionRepo
+	ps payment_service.PaymentService
+	cs currency_service.CurrencyService
+	bj wishlist_job.BackInStockJob
+	sr shipment_repo.ShipmentRepo
 }
 
 type OrderService interface {
-	Add(userId int, payload *dto.AddOrderPayload) (*helper.ResponseBody, exception.Exception)
-	Fetch(userId int) (*helper.ResponseBody, exception.Exception)
+	Add(userId int, currency string, payload *dto.AddOrderPayload) (*helper.ResponseBody, exception.Exception)
+	Fetch(userId int, query *dto.HistoryQuery) (*helper.ResponseBody, exception.Exception)
+	FetchDetail(id int) (*helper.ResponseBody, exception.Exception)
 	Modify(id int, payload *dto.ModifyOrderPayload) (*helper.ResponseBody, exception.Exception)
 	Remove(id int) (*helper.ResponseBody, exception.Exception)
+	Cancel(user *entity.User, id int, payload *dto.CancelOrderPayload) (*helper.ResponseBody, exception.Exception)
 	Authorization(next http.Handler) http.Handler
 }
 
-func NewOrderService(or order_repo.OrderRepo, pr product_repo.ProductRepo) OrderService {
+func NewOrderService(or order_repo.OrderRepo, pr product_repo.ProductRepo, tr transaction_repo.TransactionRepo, ps payment_service.PaymentService, cs currency_service.CurrencyService, bj wishlist_job.BackInStockJob, sr shipment_repo.ShipmentRepo) OrderService {
 	return &orderService{
 		or: or,
 		pr: pr,
+		tr: tr,
+		ps: ps,
+		cs: cs,
+		bj: bj,
+		sr: sr,
 	}
 }
 
 // Add implements OrderService.
-func (os *orderService) Add(userId int, payload *dto.AddOrderPayload) (*helper.ResponseBody, exception.Exception) {
+func (os *orderService) Add(userId int, currency string, payload *dto.AddOrderPayload) (*helper.ResponseBody, exception.Exception) {
 
 	product, err := os.pr.FetchById(payload.ProductId)
 
@@ -42,14 +61,21 @@ func (os *orderService) Add(userId int, payload *dto.AddOrderPayload) (*helper.R
 		return nil, err
 	}
 
-	if payload.Qty > product.Stock {
+	if payload.Qty > product.Available() {
 		return nil, exception.NewBadRequestError("qty is greater than stock")
 	}
 
+	// the order is priced in the currency the customer shops in and keeps
+	// it through checkout, so later rate changes don't move the total
+	if err := os.cs.Localize(product, currency); err != nil {
+		return nil, err
+	}
+
 	if err := os.or.Add(&entity.Order{
-		UserId:    userId,
-		ProductId: payload.ProductId,
-		Qty:       payload.Qty,
+		UserId:     userId,
+		ProductId:  payload.ProductId,
+		Qty:        payload.Qty,
+		TotalPrice: product.EffectivePrice.Mul(payload.Qty),
 	}); err != nil {
 		return nil, err
 	}
@@ -93,14 +119,45 @@ func (os *orderService) Authorization(next http.Handler) http.Handler {
 }
 
 // Fetch implements OrderService.
-func (os *orderService) Fetch(userId int) (*helper.ResponseBody, exception.Exception) {
+func (os *orderService) Fetch(userId int, query *dto.HistoryQuery) (*helper.ResponseBody, exception.Exception) {
+
+	switch query.Status {
+	case "", entity.OrderStatusPending, entity.OrderStatusAwaitingPayment, entity.OrderStatusPaid, entity.OrderStatusShipped, entity.OrderStatusDelivered, entity.OrderStatusCancelled:
+	default:
+		return nil, exception.NewBadRequestError("status must be pending, awaiting_payment, paid, shipped, delivered or cancelled")
+	}
 
-	orders, err := os.or.Fetch(userId)
+	orders, total, err := os.or.Fetch(&order_repo.OrderFilter{
+		UserId: userId,
+		From:   query.From,
+		To:     query.To,
+		Status: query.Status,
+		Limit:  query.Limit,
+		Offset: (query.Page - 1) * query.Limit,
+	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	orderIds := []int{}
+
+	for _, order := range orders {
+		orderIds = append(orderIds, order.Id)
+	}
+
+	taxLines, err := os.or.FetchTaxLines(orderIds)
+
+	if err != nil {
+		return nil, err
+	}
+
+	taxLinesByOrder := map[int][]*entity.TaxLine{}
+
+	for _, line := range taxLines {
+		taxLinesByOrder[line.OrderId] = append(taxLinesByOrder[line.OrderId], line)
+	}
+
 	data := []*entity.OrderWithProductMapped{}
 
 	for _, eachOrder := range orders {
@@ -114,17 +171,99 @@ func (os *orderService) Fetch(userId int) (*helper.ResponseBody, exception.Excep
 			},
 			Qty:        eachOrder.Qty,
 			TotalPrice: eachOrder.TotalPrice,
+			TaxLines:   taxLinesByOrder[eachOrder.Id],
+			Status:     eachOrder.Status,
 			CreatedAt:  eachOrder.CreatedAt,
 			UpdatedAt:  eachOrder.UpdatedAt,
 		}
 
+		if eachOrder.ShippingMethodName != "" {
+			orderWithProduct.Shipping = &entity.OrderShipping{
+				Method: eachOrder.ShippingMethodName,
+				Cost:   eachOrder.ShippingCost,
+			}
+		}
+
 		data = append(data, orderWithProduct)
 	}
 
 	return &helper.ResponseBody{
 		Status:  http.StatusOK,
 		Message: "order successfully fetched",
-		Data:    data,
+		Data: &dto.OrderPage{
+			Orders:     data,
+			Pagination: dto.NewPageInfo(query.Page, query.Limit, total),
+		},
+	}, nil
+}
+
+// FetchDetail implements OrderService.
+func (os *orderService) FetchDetail(id int) (*helper.ResponseBody, exception.Exception) {
+
+	detail, err := os.or.FetchDetail(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var checkedOutAt *time.Time
+
+	// failed checkouts leave no transaction behind, the order keeps its own
+	// line until it is checked out again
+	transaction, err := os.tr.FetchByOrderId(id)
+
+	if err != nil && err.Status() != http.StatusNotFound {
+		return nil, err
+	}
+
+	if transaction != nil {
+
+		detail.Items = []*entity.OrderItem{}
+
+		for _, item := range transaction.Items {
+			detail.Items = append(detail.Items, &entity.OrderItem{
+				ProductId:   item.ProductId,
+				ProductName: item.ProductName,
+				UnitPrice:   item.UnitPrice,
+				Qty:         item.Qty,
+				Subtotal:    item.Subtotal,
+				Discount:    item.Discount,
+				Tax:         item.Tax,
+				Total:       item.Total,
+			})
+		}
+
+		detail.TransactionId = transaction.Id
+		detail.Subtotal = transaction.Subtotal
+		detail.DiscountTotal = transaction.DiscountTotal
+		detail.TaxTotal = transaction.TaxTotal
+		detail.ShippingTotal = transaction.ShippingTotal
+		detail.GrandTotal = transaction.GrandTotal
+		checkedOutAt = &transaction.CreatedAt
+	}
+
+	if detail.TaxLines, err = os.or.FetchTaxLines([]int{id}); err != nil {
+		return nil, err
+	}
+
+	if detail.Payments, err = os.or.FetchPayments(id); err != nil {
+		return nil, err
+	}
+
+	if detail.Refunds, err = os.or.FetchRefunds(id); err != nil {
+		return nil, err
+	}
+
+	if detail.Shipments, err = os.sr.FetchByOrderId(id); err != nil {
+		return nil, err
+	}
+
+	detail.BuildTimeline(checkedOutAt)
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "order successfully fetched",
+		Data:    detail,
 	}, nil
 }
 
@@ -137,19 +276,28 @@ func (os *orderService) Modify(id int, payload *dto.ModifyOrderPayload) (*helper
 		return nil, err
 	}
 
+	if order.Status != entity.OrderStatusPending {
+		return nil, exception.NewBadRequestError("order can no longer be modified")
+	}
+
 	product, err := os.pr.FetchById(order.ProductId)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if payload.Qty > product.Stock {
+	if payload.Qty > product.Available() {
 		return nil, exception.NewBadRequestError("qty is greater than stock")
 	}
 
+	if err := os.cs.Localize(product, order.TotalPrice.Currency); err != nil {
+		return nil, err
+	}
+
 	if err := os.or.Modify(&entity.Order{
-		Id:  id,
-		Qty: payload.Qty,
+		Id:         id,
+		Qty:        payload.Qty,
+		TotalPrice: product.EffectivePrice.Mul(payload.Qty),
 	}); err != nil {
 		return nil, err
 	}
@@ -164,6 +312,16 @@ func (os *orderService) Modify(id int, payload *dto.ModifyOrderPayload) (*helper
 // Remove implements OrderService.
 func (os *orderService) Remove(id int) (*helper.ResponseBody, exception.Exception) {
 
+	order, err := os.or.FetchOrderById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != entity.OrderStatusPending {
+		return nil, exception.NewBadRequestError("order can no longer be removed")
+	}
+
 	if err := os.or.Remove(id); err != nil {
 		return nil, err
 	}
@@ -174,3 +332,101 @@ func (os *orderService) Remove(id int) (*helper.ResponseBody, exception.Exceptio
 		Data:    nil,
 	}, nil
 }
+
+// Cancel implements OrderService.
+func (os *orderService) Cancel(user *entity.User, id int, payload *dto.CancelOrderPayload) (*helper.ResponseBody, exception.Exception) {
+
+	order, err := os.or.FetchOrderById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	isAdmin := user.Role == "admin"
+
+	if !isAdmin && order.UserId != user.Id {
+		return nil, exception.NewUnauthorizedError("you're not authorized this order")
+	}
+
+	if isAdmin && payload.Reason == "" {
+		return nil, exception.NewBadRequestError("Reason can't be empty")
+	}
+
+	switch order.Status {
+	case entity.OrderStatusPending, entity.OrderStatusAwaitingPayment, entity.OrderStatusPaid:
+	case entity.OrderStatusShipped, entity.OrderStatusDelivered:
+		return nil, exception.NewBadRequestError("order has already been shipped, create a return instead")
+	default:
+		return nil, exception.NewBadRequestError("order can no longer be cancelled")
+	}
+
+	shippedQty := 0
+
+	if order.Status != entity.OrderStatusPending && order.Status != entity.OrderStatusAwaitingPayment {
+
+		if shippedQty, err = os.sr.FetchShippedQty(order.Id); err != nil {
+			return nil, err
+		}
+
+		// a paid order stays paid until its last unit ships, what already
+		// left the warehouse has to come back through a return instead
+		if shippedQty > 0 && !isAdmin {
+			return nil, exception.NewBadRequestError("order has already been partially shipped")
+		}
+	}
+
+	unshippedQty := order.Qty - shippedQty
+
+	// cancelling would refund nothing and leave the customer without a way
+	// to send the units back, returns only take delivered orders
+	if unshippedQty <= 0 {
+		return nil, exception.NewBadRequestError("order has already been shipped, create a return instead")
+	}
+
+	// the cancellation is claimed before refunding so concurrent cancels
+	// can't both refund
+	refund := func() exception.Exception {
+
+		if order.Status == entity.OrderStatusPending || order.Status == entity.OrderStatusAwaitingPayment {
+			return nil
+		}
+
+		transaction, err := os.tr.FetchByOrderId(order.Id)
+
+		if err != nil {
+			return err
+		}
+
+		if transaction.Status != entity.TransactionStatusPaid {
+			return nil
+		}
+
+		reason := fmt.Sprintf("order #%d cancelled", order.Id)
+
+		if shippedQty == 0 {
+			_, err = os.ps.RefundBalance(transaction.Id, reason)
+			return err
+		}
+
+		// only the units that never shipped are refunded, the rest is
+		// settled through returns
+		_, err = os.ps.RefundUpTo(transaction.Id, transaction.GrandTotal.MulDiv(unshippedQty, order.Qty), reason)
+
+		return err
+	}
+
+	if err := os.or.Cancel(order, user.Id, payload.Reason, refund); err != nil {
+		return nil, err
+	}
+
+	// reserved or unshipped sold units are given back to the product
+	if order.Status != entity.OrderStatusPending && unshippedQty > 0 {
+		os.bj.Queue(order.ProductId)
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "order successfully cancelled",
+		Data:    nil,
+	}, nil
+}
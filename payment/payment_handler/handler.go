@@ -0,0 +1,55 @@
+package payment_handler
+
+import (
+	"fashion-api/payment/payment_service"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"io"
+	"net/http"
+)
+
+// maxWebhookBody caps how much of a webhook request is read before the
+// signature is checked.
+const maxWebhookBody = 64 << 10
+
+type paymentHandler struct {
+	ps payment_service.PaymentService
+}
+
+type PaymentHandler interface {
+	Webhook(w http.ResponseWriter, r *http.Request)
+}
+
+func NewPaymentHandler(ps payment_service.PaymentService) PaymentHandler {
+	return &paymentHandler{
+		ps: ps,
+	}
+}
+
+// Webhook implements PaymentHandler.
+func (ph *paymentHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+
+	if err != nil {
+		invalidBody := exception.NewUnprocessableEntityError("invalid webhook body")
+
+		w.WriteHeader(invalidBody.Status())
+		w.Write(helper.ResponseJSON(invalidBody))
+
+		return
+	}
+
+	res, errs := ph.ps.Webhook(body, r.Header.Get("X-Payment-Signature"), r.Header.Get("X-Payment-Timestamp"))
+
+	if errs != nil {
+		w.WriteHeader(errs.Status())
+		w.Write(helper.ResponseJSON(errs))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -0,0 +1,132 @@
+package payment_provider
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+	"log"
+	"sync"
+)
+
+type fakeProvider struct {
+	secret  string
+	mu      sync.Mutex
+	intents map[string]*Intent
+}
+
+// NewFakeProvider returns an in-memory provider for development and tests.
+// It never talks to a real gateway; payments are confirmed by posting a
+// webhook signed with Sign and the configured secret.
+func NewFakeProvider(webhookSecret string) PaymentProvider {
+	return &fakeProvider{
+		secret:  webhookSecret,
+		intents: map[string]*Intent{},
+	}
+}
+
+// Name implements PaymentProvider.
+func (fp *fakeProvider) Name() string {
+	return "fake"
+}
+
+// CreateIntent implements PaymentProvider.
+func (fp *fakeProvider) CreateIntent(amount money.Money, reference string) (*Intent, exception.Exception) {
+
+	if !amount.IsPositive() {
+		return nil, exception.NewBadRequestError("amount must be greater than zero")
+	}
+
+	id, err := fakeId("pi_fake_")
+
+	if err != nil {
+		return nil, err
+	}
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	intent := &Intent{
+		Id:           id,
+		ClientSecret: id + "_secret_" + reference,
+		Amount:       amount,
+		Status:       "requires_payment",
+	}
+
+	fp.intents[intent.Id] = intent
+
+	return intent, nil
+}
+
+// Capture implements PaymentProvider.
+func (fp *fakeProvider) Capture(intentId string) (*Intent, exception.Exception) {
+
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	intent, ok := fp.intents[intentId]
+
+	if !ok {
+		intent = &Intent{Id: intentId}
+		fp.intents[intentId] = intent
+	}
+
+	intent.Status = "captured"
+
+	return intent, nil
+}
+
+// Refund implements PaymentProvider.
+func (fp *fakeProvider) Refund(intentId string, amount money.Money) (*Refund, exception.Exception) {
+
+	if !amount.IsPositive() {
+		return nil, exception.NewBadRequestError("refund amount must be greater than zero")
+	}
+
+	id, err := fakeId("re_fake_")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Refund{
+		Id:       id,
+		IntentId: intentId,
+		Amount:   amount,
+	}, nil
+}
+
+// VerifyWebhook implements PaymentProvider.
+func (fp *fakeProvider) VerifyWebhook(body []byte, signature string, timestamp string) (*WebhookEvent, exception.Exception) {
+
+	if err := verifySignature(fp.secret, body, signature, timestamp); err != nil {
+		return nil, err
+	}
+
+	event := &WebhookEvent{}
+
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, exception.NewUnprocessableEntityError("invalid webhook payload")
+	}
+
+	if event.Id == "" || event.Type == "" || event.IntentId == "" {
+		return nil, exception.NewBadRequestError("webhook event is missing id, type or intent_id")
+	}
+
+	return event, nil
+}
+
+// fakeId returns a random id, ids must stay unique across restarts since
+// they are stored alongside payments.
+func fakeId(prefix string) (string, exception.Exception) {
+
+	b := make([]byte, 12)
+
+	if _, err := rand.Read(b); err != nil {
+		log.Println(err.Error())
+		return "", exception.NewInternalServerError("something went wrong")
+	}
+
+	return prefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,299 @@
+package payment_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/payment/payment_repo"
+	"fashion-api/pkg/exception"
+	"log"
+	"strings"
+)
+
+type paymentPg struct {
+	db *sql.DB
+}
+
+const (
+	fetchPaymentByIntentIdQuery = `select id, transaction_id, provider, intent_id, amount, refunded_amount, currency, status, created_at, updated_at from payment where intent_id = $1`
+
+	fetchPaymentByTransactionIdQuery = `select id, transaction_id, provider, intent_id, amount, refunded_amount, currency, status, created_at, updated_at from payment where transaction_id = $1`
+
+	addWebhookEventQuery = `insert into payment_webhook_event (event_id, type, intent_id) values ($1, $2, $3)`
+
+	updatePaymentStatusQuery = `update payment set status = $2, captured_at = case when $2 = 'captured' then now() end, updated_at = now() where id = $1 and status = 'pending'`
+
+	updateTransactionStatusQuery = `update transaction set status = $2, updated_at = now() where id = $1`
+
+	// a sale takes the units out of stock and out of the reservation made
+	// at checkout, at each location the order was allocated to
+	sellLocationStockQuery = `with moved as (
+			update stock_level set stock = stock - a.qty, reserved = reserved - a.qty from transaction as t join "order" as o on t.order_id = o.id join order_allocation as a on a.order_id = o.id where t.id = $1 and stock_level.product_id = o.product_id and stock_level.location_id = a.location_id
+			returning stock_level.product_id, stock_level.location_id, a.qty, stock_level.stock, stock_level.reserved
+		)
+		insert into stock_movement (product_id, location_id, type, qty, reserved_qty, stock_after, reserved_after, reason, reference)
+		select product_id, location_id, 'sale', -qty, -qty, stock, reserved, 'payment captured', 'transaction #' || $1::int from moved`
+
+	updateStockAndSoldQuery = `update product set stock = stock - o.qty, reserved = reserved - o.qty, sold = sold + o.qty, updated_at = now() from transaction as t join "order" as o on t.order_id = o.id where t.id = $1 and product.id = o.product_id`
+
+	// the order goes back to pending, so its allocations are dropped and
+	// made again at the next checkout
+	releaseLocationReservedQuery = `with released as (
+			delete from order_allocation as a using transaction as t where t.id = $1 and a.order_id = t.order_id returning a.order_id, a.location_id, a.qty
+		), moved as (
+			update stock_level set reserved = reserved - r.qty from released as r join "order" as o on o.id = r.order_id where stock_level.product_id = o.product_id and stock_level.location_id = r.location_id
+			returning stock_level.product_id, stock_level.location_id, r.qty, stock_level.stock, stock_level.reserved
+		)
+		insert into stock_movement (product_id, location_id, type, reserved_qty, stock_after, reserved_after, reason, reference)
+		select product_id, location_id, 'reservation', -qty, stock, reserved, 'payment failed', 'transaction #' || $1::int from moved`
+
+	releaseReservedStockQuery = `update product set reserved = reserved - o.qty, updated_at = now() from transaction as t join "order" as o on t.order_id = o.id where t.id = $1 and product.id = o.product_id`
+
+	addRefundQuery = `insert into payment_refund (payment_id, provider_refund_id, amount, reason) values ($1, $2, $3, $4)`
+
+	addRefundedAmountQuery = `update payment set refunded_amount = refunded_amount + $2, status = case when refunded_amount + $2 >= amount then 'refunded' else 'partially_refunded' end, updated_at = now() where id = $1 and status in ('captured', 'partially_refunded') and refunded_amount + $2 <= amount returning status`
+
+	// money that arrived for a cancelled payment is sent back in full, the
+	// payment itself stays cancelled
+	refundCancelledQuery = `update payment set refunded_amount = amount, updated_at = now() where id = $1 and status = 'cancelled' and refunded_amount = 0`
+
+	reopenOrderQuery = `update "order" set status = 'pending', updated_at = now() where id = (select order_id from transaction where id = $1)`
+)
+
+func NewPaymentPg(db *sql.DB) payment_repo.PaymentRepo {
+	return &paymentPg{
+		db: db,
+	}
+}
+
+// FetchByIntentId implements payment_repo.PaymentRepo.
+func (pg *paymentPg) FetchByIntentId(intentId string) (*entity.Payment, exception.Exception) {
+	return pg.fetchOne(fetchPaymentByIntentIdQuery, intentId)
+}
+
+// FetchByTransactionId implements payment_repo.PaymentRepo.
+func (pg *paymentPg) FetchByTransactionId(transactionId int) (*entity.Payment, exception.Exception) {
+	return pg.fetchOne(fetchPaymentByTransactionIdQuery, transactionId)
+}
+
+func (pg *paymentPg) fetchOne(query string, arg any) (*entity.Payment, exception.Exception) {
+
+	payment := entity.Payment{}
+
+	stmt, err := pg.db.Prepare(query)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer stmt.Close()
+
+	if err := stmt.QueryRow(arg).Scan(
+		&payment.Id,
+		&payment.TransactionId,
+		&payment.Provider,
+		&payment.IntentId,
+		&payment.Amount.Amount,
+		&payment.RefundedAmount.Amount,
+		&payment.Amount.Currency,
+		&payment.Status,
+		&payment.CreatedAt,
+		&payment.UpdatedAt,
+	); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("payment not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	payment.RefundedAmount.Currency = payment.Amount.Currency
+
+	return &payment, nil
+}
+
+// RecordWebhookEvent implements payment_repo.PaymentRepo.
+func (pg *paymentPg) RecordWebhookEvent(event *entity.PaymentWebhookEvent) exception.Exception {
+
+	if _, err := pg.db.Exec(addWebhookEventQuery, event.EventId, event.Type, event.IntentId); err != nil {
+		return handleWebhookEventError(err)
+	}
+
+	return nil
+}
+
+// Capture implements payment_repo.PaymentRepo.
+func (pg *paymentPg) Capture(payment *entity.Payment, event *entity.PaymentWebhookEvent) exception.Exception {
+	return pg.settle(payment, event, entity.PaymentStatusCaptured, entity.TransactionStatusPaid, sellLocationStockQuery, updateStockAndSoldQuery)
+}
+
+// Fail implements payment_repo.PaymentRepo.
+func (pg *paymentPg) Fail(payment *entity.Payment, event *entity.PaymentWebhookEvent) exception.Exception {
+	return pg.settle(payment, event, entity.PaymentStatusFailed, entity.TransactionStatusFailed, releaseLocationReservedQuery, releaseReservedStockQuery, reopenOrderQuery)
+}
+
+// settle records the webhook event and moves a pending payment and its
+// transaction to their final status in one database transaction, so a
+// replayed event can never settle the same payment twice.
+func (pg *paymentPg) settle(payment *entity.Payment, event *entity.PaymentWebhookEvent, paymentStatus string, transactionStatus string, stockQueries ...string) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(addWebhookEventQuery, event.EventId, event.Type, event.IntentId); err != nil {
+		tx.Rollback()
+		return handleWebhookEventError(err)
+	}
+
+	res, err := tx.Exec(updatePaymentStatusQuery, payment.Id, paymentStatus)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewConflictError("payment has already been settled")
+	}
+
+	if _, err := tx.Exec(updateTransactionStatusQuery, payment.TransactionId, transactionStatus); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	for _, query := range stockQueries {
+		if _, err := tx.Exec(query, payment.TransactionId); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// AddRefund implements payment_repo.PaymentRepo.
+func (pg *paymentPg) AddRefund(payment *entity.Payment, refund *entity.PaymentRefund) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.QueryRow(addRefundedAmountQuery, payment.Id, refund.Amount.Amount).Scan(&payment.Status); err != nil {
+		tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return exception.NewBadRequestError("refund amount exceeds the refundable amount")
+		}
+
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(addRefundQuery, payment.Id, refund.ProviderRefundId, refund.Amount.Amount, refund.Reason); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if payment.Status == entity.PaymentStatusRefunded {
+		if _, err := tx.Exec(updateTransactionStatusQuery, payment.TransactionId, entity.TransactionStatusRefunded); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	payment.RefundedAmount = payment.RefundedAmount.Add(refund.Amount)
+
+	return nil
+}
+
+// RefundCancelled implements payment_repo.PaymentRepo.
+func (pg *paymentPg) RefundCancelled(payment *entity.Payment, event *entity.PaymentWebhookEvent, refund *entity.PaymentRefund, providerRefund func() exception.Exception) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	// a replay of the same event waits on the unique event id until this
+	// transaction ends, and is only seen as a duplicate if the refund went
+	// through
+	if _, err := tx.Exec(addWebhookEventQuery, event.EventId, event.Type, event.IntentId); err != nil {
+		tx.Rollback()
+		return handleWebhookEventError(err)
+	}
+
+	res, err := tx.Exec(refundCancelledQuery, payment.Id)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewConflictError("payment has already been refunded")
+	}
+
+	if err := providerRefund(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(addRefundQuery, payment.Id, refund.ProviderRefundId, refund.Amount.Amount, refund.Reason); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("payment #%d was refunded but the refund couldn't be saved: %s", payment.Id, err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	payment.RefundedAmount = payment.Amount
+
+	return nil
+}
+
+func handleWebhookEventError(err error) exception.Exception {
+
+	if strings.Contains(err.Error(), `unique constraint "payment_webhook_event_event_id_key"`) {
+		return exception.NewConflictError("webhook event has already been processed")
+	}
+
+	log.Println(err.Error())
+	return exception.NewInternalServerError("something went wrong")
+}
@@ -0,0 +1,20 @@
+package payment_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type PaymentRepo interface {
+	FetchByIntentId(intentId string) (*entity.Payment, exception.Exception)
+	FetchByTransactionId(transactionId int) (*entity.Payment, exception.Exception)
+	RecordWebhookEvent(event *entity.PaymentWebhookEvent) exception.Exception
+	Capture(payment *entity.Payment, event *entity.PaymentWebhookEvent) exception.Exception
+	Fail(payment *entity.Payment, event *entity.PaymentWebhookEvent) exception.Exception
+	AddRefund(payment *entity.Payment, refund *entity.PaymentRefund) exception.Exception
+	// RefundCancelled records the event and the refund of a payment that
+	// arrived after its order was cancelled. providerRefund sends the money
+	// back and fills in the provider refund id, nothing is recorded when
+	// it fails so the provider's retry of the event tries again.
+	RefundCancelled(payment *entity.Payment, event *entity.PaymentWebhookEvent, refund *entity.PaymentRefund, providerRefund func() exception.Exception) exception.Exception
+}
@@ -0,0 +1,244 @@
+package payment_service
+
+import (
+	"fashion-api/entity"
+	"fashion-api/invoice/invoice_service"
+	"fashion-api/notification/notification_job"
+	"fashion-api/payment/payment_provider"
+	"fashion-api/payment/payment_repo"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"log"
+	"net/http"
+	"strings"
+)
+
+type paymentService struct {
+	pr payment_repo.PaymentRepo
+	pp payment_provider.PaymentProvider
+	nj notification_job.NotificationJob
+	is invoice_service.InvoiceService
+}
+
+type PaymentService interface {
+	Webhook(body []byte, signature string, timestamp string) (*helper.ResponseBody, exception.Exception)
+	Refund(transactionId int, amount money.Money, reason string) (*entity.PaymentRefund, exception.Exception)
+	RefundBalance(transactionId int, reason string) (*entity.PaymentRefund, exception.Exception)
+	RefundUpTo(transactionId int, amount money.Money, reason string) (*entity.PaymentRefund, exception.Exception)
+}
+
+func NewPaymentService(pr payment_repo.PaymentRepo, pp payment_provider.PaymentProvider, nj notification_job.NotificationJob, is invoice_service.InvoiceService) PaymentService {
+	return &paymentService{
+		pr: pr,
+		pp: pp,
+		nj: nj,
+		is: is,
+	}
+}
+
+// Webhook implements PaymentService.
+func (ps *paymentService) Webhook(body []byte, signature string, timestamp string) (*helper.ResponseBody, exception.Exception) {
+
+	event, err := ps.pp.VerifyWebhook(body, signature, timestamp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := ps.pr.FetchByIntentId(event.IntentId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the currency is optional in the payload, an amount without one is in
+	// the currency the intent was created in
+	if event.Amount != 0 && event.Amount != payment.Amount.Amount {
+		return nil, exception.NewBadRequestError("webhook amount doesn't match payment amount")
+	}
+
+	if event.Currency != "" && strings.ToUpper(event.Currency) != payment.Amount.Currency {
+		return nil, exception.NewBadRequestError("webhook currency doesn't match payment currency")
+	}
+
+	webhookEvent := &entity.PaymentWebhookEvent{
+		EventId:  event.Id,
+		Type:     event.Type,
+		IntentId: event.IntentId,
+	}
+
+	captured := false
+
+	switch {
+	case payment.Status == entity.PaymentStatusCancelled && event.Type == payment_provider.EventPaymentSucceeded:
+		// the order was cancelled while the customer was still paying, so
+		// the money that arrived anyway goes straight back
+		err = ps.refundCancelled(payment, webhookEvent)
+	case event.Type == payment_provider.EventPaymentAuthorized:
+		if payment.Status != entity.PaymentStatusPending {
+			err = exception.NewConflictError("payment has already been settled")
+		} else if _, err = ps.pp.Capture(payment.IntentId); err == nil {
+			err = ps.pr.Capture(payment, webhookEvent)
+			captured = err == nil
+		}
+	case event.Type == payment_provider.EventPaymentSucceeded:
+		err = ps.pr.Capture(payment, webhookEvent)
+		captured = err == nil
+	case event.Type == payment_provider.EventPaymentFailed:
+		err = ps.pr.Fail(payment, webhookEvent)
+	default:
+		err = ps.pr.RecordWebhookEvent(webhookEvent)
+	}
+
+	// providers retry deliveries until they see a 2xx, so a replayed or
+	// already settled event is acknowledged rather than reported as an error
+	if err != nil && err.Status() == http.StatusConflict {
+		return &helper.ResponseBody{
+			Status:  http.StatusOK,
+			Message: err.Message(),
+			Data:    nil,
+		}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if captured {
+
+		// the capture is saved and the provider must get its 2xx, so an
+		// invoice that couldn't be issued is only logged
+		if _, err := ps.is.Issue(payment.TransactionId); err != nil {
+			log.Printf("invoice for transaction #%d couldn't be issued: %s", payment.TransactionId, err.Message())
+		}
+
+		ps.nj.Queue(&entity.Notification{
+			Kind:          entity.NotificationPaymentReceived,
+			TransactionId: payment.TransactionId,
+			Data:          payment,
+		})
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "webhook successfully processed",
+		Data:    nil,
+	}, nil
+}
+
+// Refund implements PaymentService.
+func (ps *paymentService) Refund(transactionId int, amount money.Money, reason string) (*entity.PaymentRefund, exception.Exception) {
+
+	payment, err := ps.pr.FetchByTransactionId(transactionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != entity.PaymentStatusCaptured && payment.Status != entity.PaymentStatusPartiallyRefunded {
+		return nil, exception.NewBadRequestError("payment can't be refunded")
+	}
+
+	if amount.Currency != payment.Amount.Currency {
+		return nil, exception.NewBadRequestError("refund currency must match the payment currency")
+	}
+
+	if !amount.IsPositive() || payment.Amount.Sub(payment.RefundedAmount).LessThan(amount) {
+		return nil, exception.NewBadRequestError("refund amount exceeds the refundable amount")
+	}
+
+	providerRefund, err := ps.pp.Refund(payment.IntentId, amount)
+
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &entity.PaymentRefund{
+		PaymentId:        payment.Id,
+		ProviderRefundId: providerRefund.Id,
+		Amount:           amount,
+		Reason:           reason,
+	}
+
+	if err := ps.pr.AddRefund(payment, refund); err != nil {
+		return nil, err
+	}
+
+	ps.nj.Queue(&entity.Notification{
+		Kind:          entity.NotificationRefund,
+		TransactionId: transactionId,
+		Data:          refund,
+	})
+
+	return refund, nil
+}
+
+// RefundBalance implements PaymentService.
+func (ps *paymentService) RefundBalance(transactionId int, reason string) (*entity.PaymentRefund, exception.Exception) {
+
+	payment, err := ps.pr.FetchByTransactionId(transactionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ps.Refund(transactionId, payment.Amount.Sub(payment.RefundedAmount), reason)
+}
+
+// RefundUpTo implements PaymentService. It refunds amount, or whatever is
+// left of the payment when earlier refunds took more of it.
+func (ps *paymentService) RefundUpTo(transactionId int, amount money.Money, reason string) (*entity.PaymentRefund, exception.Exception) {
+
+	payment, err := ps.pr.FetchByTransactionId(transactionId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if amount.Currency != payment.Amount.Currency {
+		return nil, exception.NewBadRequestError("refund currency must match the payment currency")
+	}
+
+	amount = amount.Min(payment.Amount.Sub(payment.RefundedAmount))
+
+	if !amount.IsPositive() {
+		return nil, nil
+	}
+
+	return ps.Refund(transactionId, amount, reason)
+}
+
+func (ps *paymentService) refundCancelled(payment *entity.Payment, event *entity.PaymentWebhookEvent) exception.Exception {
+
+	refund := &entity.PaymentRefund{
+		PaymentId: payment.Id,
+		Amount:    payment.Amount,
+		Reason:    "the order was cancelled before the payment arrived",
+	}
+
+	err := ps.pr.RefundCancelled(payment, event, refund, func() exception.Exception {
+
+		providerRefund, err := ps.pp.Refund(payment.IntentId, payment.Amount)
+
+		if err != nil {
+			return err
+		}
+
+		refund.ProviderRefundId = providerRefund.Id
+
+		return nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	ps.nj.Queue(&entity.Notification{
+		Kind:          entity.NotificationRefund,
+		TransactionId: payment.TransactionId,
+		Data:          refund,
+	})
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package payment_service
+
+import (
+	"encoding/json"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/payment/payment_provider"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const webhookSecret = "whsec_test"
+
+// paymentRepo holds a single payment and records what the webhook did to
+// it.
+type paymentRepo struct {
+	payment  *entity.Payment
+	captured bool
+}
+
+func (r *paymentRepo) FetchByIntentId(intentId string) (*entity.Payment, exception.Exception) {
+
+	if r.payment.IntentId != intentId {
+		return nil, exception.NewNotFoundError("payment not found")
+	}
+
+	return r.payment, nil
+}
+
+func (r *paymentRepo) FetchByTransactionId(transactionId int) (*entity.Payment, exception.Exception) {
+	return r.payment, nil
+}
+
+func (r *paymentRepo) RecordWebhookEvent(event *entity.PaymentWebhookEvent) exception.Exception {
+	return nil
+}
+
+func (r *paymentRepo) Capture(payment *entity.Payment, event *entity.PaymentWebhookEvent) exception.Exception {
+	r.captured = true
+	payment.Status = entity.PaymentStatusCaptured
+	return nil
+}
+
+func (r *paymentRepo) Fail(payment *entity.Payment, event *entity.PaymentWebhookEvent) exception.Exception {
+	return nil
+}
+
+func (r *paymentRepo) AddRefund(payment *entity.Payment, refund *entity.PaymentRefund) exception.Exception {
+	return nil
+}
+
+func (r *paymentRepo) RefundCancelled(payment *entity.Payment, event *entity.PaymentWebhookEvent, refund *entity.PaymentRefund, providerRefund func() exception.Exception) exception.Exception {
+	return providerRefund()
+}
+
+type invoiceService struct{}
+
+func (is *invoiceService) Issue(transactionId int) (*entity.Invoice, exception.Exception) {
+	return &entity.Invoice{}, nil
+}
+
+func (is *invoiceService) FetchInvoice(transactionId int) (*entity.Invoice, exception.Exception) {
+	return nil, exception.NewNotFoundError("invoice not found")
+}
+
+func (is *invoiceService) FetchCreditNote(transactionId int, id int) (*entity.Invoice, exception.Exception) {
+	return nil, exception.NewNotFoundError("credit note not found")
+}
+
+func (is *invoiceService) FetchDocuments(transactionId int) (*helper.ResponseBody, exception.Exception) {
+	return nil, nil
+}
+
+func (is *invoiceService) IssueCreditNote(transactionId int, payload *dto.CreditNotePayload) (*helper.ResponseBody, exception.Exception) {
+	return nil, nil
+}
+
+type notificationJob struct{}
+
+func (nj *notificationJob) Queue(notification *entity.Notification) {}
+
+func (nj *notificationJob) Run() {}
+
+func TestWebhookChecksTheAmountAndAnOptionalCurrency(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		amount   int64
+		currency string
+		status   int
+	}{
+		{name: "amount without currency", amount: 12490, status: http.StatusOK},
+		{name: "amount and currency", amount: 12490, currency: "USD", status: http.StatusOK},
+		{name: "lowercase currency", amount: 12490, currency: "usd", status: http.StatusOK},
+		{name: "no amount", status: http.StatusOK},
+		{name: "different amount", amount: 100, status: http.StatusBadRequest},
+		{name: "different currency", amount: 12490, currency: "IDR", status: http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			pr := &paymentRepo{payment: &entity.Payment{
+				TransactionId: 7,
+				IntentId:      "pi_fake_1",
+				Amount:        money.New(12490, "USD"),
+				Status:        entity.PaymentStatusPending,
+			}}
+			ps := NewPaymentService(pr, payment_provider.NewFakeProvider(webhookSecret), &notificationJob{}, &invoiceService{})
+
+			body, err := json.Marshal(&payment_provider.WebhookEvent{
+				Id:       "evt_1",
+				Type:     payment_provider.EventPaymentSucceeded,
+				IntentId: "pi_fake_1",
+				Amount:   c.amount,
+				Currency: c.currency,
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+			res, webhookErr := ps.Webhook(body, payment_provider.Sign(webhookSecret, timestamp, body), timestamp)
+
+			status := http.StatusOK
+
+			if webhookErr != nil {
+				status = webhookErr.Status()
+			} else if res.Status != http.StatusOK {
+				status = res.Status
+			}
+
+			if status != c.status {
+				t.Fatalf("status %d, want %d", status, c.status)
+			}
+
+			if pr.captured != (c.status == http.StatusOK) {
+				t.Fatalf("captured is %t", pr.captured)
+			}
+		})
+	}
+}
+
+func TestRefundUpToChecksTheCurrencyBeforeCapping(t *testing.T) {
+
+	cases := []struct {
+		name   string
+		amount money.Money
+		status int
+		refund money.Money
+	}{
+		{name: "less than the payment", amount: money.New(1000, "USD"), status: http.StatusOK, refund: money.New(1000, "USD")},
+		{name: "more than the payment", amount: money.New(20000, "USD"), status: http.StatusOK, refund: money.New(12490, "USD")},
+		{name: "another currency", amount: money.New(20000, "IDR"), status: http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			pr := &paymentRepo{payment: &entity.Payment{
+				TransactionId: 7,
+				IntentId:      "pi_fake_1",
+				Amount:        money.New(12490, "USD"),
+				Status:        entity.PaymentStatusCaptured,
+			}}
+			ps := NewPaymentService(pr, payment_provider.NewFakeProvider(webhookSecret), &notificationJob{}, &invoiceService{})
+
+			refund, err := ps.RefundUpTo(7, c.amount, "order #42 cancelled")
+
+			status := http.StatusOK
+
+			if err != nil {
+				status = err.Status()
+			}
+
+			if status != c.status {
+				t.Fatalf("status %d, want %d", status, c.status)
+			}
+
+			if err == nil && refund.Amount != c.refund {
+				t.Fatalf("refunded %s, want %s", refund.Amount, c.refund)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package helper
+
+import (
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+	"net/http"
+	"strings"
+)
+
+// RequestCurrency returns the display currency a client asked for through
+// the currency query parameter or the X-Currency header. An empty string
+// means the client didn't ask, so amounts stay in their stored currency.
+func RequestCurrency(r *http.Request) (string, exception.Exception) {
+
+	currency := r.URL.Query().Get("currency")
+
+	if currency == "" {
+		currency = r.Header.Get("X-Currency")
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+
+	if currency == "" {
+		return "", nil
+	}
+
+	if !money.IsSupported(currency) {
+		return "", exception.NewBadRequestError("currency " + currency + " is not supported")
+	}
+
+	return currency, nil
+}
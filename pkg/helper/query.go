@@ -0,0 +1,295 @@
+package helper
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+
+	// attributeQueryPrefix marks attribute filters, attr.brand=acme,zara
+	attributeQueryPrefix = "attr."
+
+	queryDateLayout = "2006-01-02"
+)
+
+// Pagination reads the page and limit query parameters, page starts at 1.
+func Pagination(r *http.Request) (int, int, exception.Exception) {
+
+	page, limit := 1, DefaultPageLimit
+
+	if value := r.URL.Query().Get("page"); value != "" {
+
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed < 1 {
+			return 0, 0, exception.NewBadRequestError("page must be a positive number")
+		}
+
+		page = parsed
+	}
+
+	if value := r.URL.Query().Get("limit"); value != "" {
+
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed < 1 || parsed > MaxPageLimit {
+			return 0, 0, exception.NewBadRequestError("limit must be between 1 and " + strconv.Itoa(MaxPageLimit))
+		}
+
+		limit = parsed
+	}
+
+	return page, limit, nil
+}
+
+// ProductQuery reads the product listing filters from the query string.
+func ProductQuery(r *http.Request) (*dto.ProductQuery, exception.Exception) {
+
+	values := r.URL.Query()
+
+	page, limit, err := Pagination(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := &dto.ProductQuery{
+		Search:     strings.TrimSpace(values.Get("q")),
+		Tags:       splitQueryValues(values["tags"]),
+		Attributes: map[string][]string{},
+		Sort:       values.Get("sort"),
+		Page:       page,
+		Limit:      limit,
+	}
+
+	for name, value := range values {
+
+		code := strings.TrimPrefix(name, attributeQueryPrefix)
+
+		if code == name {
+			continue
+		}
+
+		if options := splitQueryValues(value); len(options) > 0 {
+			query.Attributes[code] = options
+		}
+	}
+
+	switch query.Sort {
+	case "":
+		query.Sort = entity.ProductSortNewest
+	case entity.ProductSortNewest, entity.ProductSortPriceAsc, entity.ProductSortPriceDesc, entity.ProductSortBestSelling, entity.ProductSortName:
+	default:
+		return nil, exception.NewBadRequestError("sort must be newest, price_asc, price_desc, best_selling or name")
+	}
+
+	for name, target := range map[string]*int64{"min_price": &query.MinPrice, "max_price": &query.MaxPrice} {
+
+		value := values.Get(name)
+
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil || parsed < 0 {
+			return nil, exception.NewBadRequestError(name + " must be a non-negative number")
+		}
+
+		*target = parsed
+	}
+
+	if query.MaxPrice > 0 && query.MinPrice > query.MaxPrice {
+		return nil, exception.NewBadRequestError("min_price can't be greater than max_price")
+	}
+
+	if value := values.Get("category_id"); value != "" {
+
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed < 1 {
+			return nil, exception.NewBadRequestError("category_id must be a positive number")
+		}
+
+		query.CategoryId = parsed
+	}
+
+	if value := values.Get("in_stock"); value != "" {
+
+		parsed, err := strconv.ParseBool(value)
+
+		if err != nil {
+			return nil, exception.NewBadRequestError("in_stock must be true or false")
+		}
+
+		query.InStock = parsed
+	}
+
+	return query, nil
+}
+
+// TransactionQuery reads the admin transaction list filters from the query
+// string.
+func TransactionQuery(r *http.Request) (*dto.TransactionQuery, exception.Exception) {
+
+	values := r.URL.Query()
+
+	from, to, err := DateRange(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	query := &dto.TransactionQuery{
+		From:   from,
+		To:     to,
+		Status: values.Get("status"),
+		Cursor: values.Get("cursor"),
+		Limit:  DefaultPageLimit,
+	}
+
+	switch query.Status {
+	case "", entity.TransactionStatusPending, entity.TransactionStatusPaid, entity.TransactionStatusFailed, entity.TransactionStatusRefunded, entity.TransactionStatusCancelled:
+	default:
+		return nil, exception.NewBadRequestError("status must be pending, paid, failed, refunded or cancelled")
+	}
+
+	for name, target := range map[string]*int{"user_id": &query.UserId, "product_id": &query.ProductId, "category_id": &query.CategoryId} {
+
+		value := values.Get(name)
+
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed < 1 {
+			return nil, exception.NewBadRequestError(name + " must be a positive number")
+		}
+
+		*target = parsed
+	}
+
+	for name, target := range map[string]*int64{"min_amount": &query.MinAmount, "max_amount": &query.MaxAmount} {
+
+		value := values.Get(name)
+
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseInt(value, 10, 64)
+
+		if err != nil || parsed < 0 {
+			return nil, exception.NewBadRequestError(name + " must be a non-negative number")
+		}
+
+		*target = parsed
+	}
+
+	if query.MaxAmount > 0 && query.MinAmount > query.MaxAmount {
+		return nil, exception.NewBadRequestError("min_amount can't be greater than max_amount")
+	}
+
+	if value := values.Get("limit"); value != "" {
+
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed < 1 || parsed > MaxPageLimit {
+			return nil, exception.NewBadRequestError("limit must be between 1 and " + strconv.Itoa(MaxPageLimit))
+		}
+
+		query.Limit = parsed
+	}
+
+	return query, nil
+}
+
+// HistoryQuery reads the customer history filters from the query string,
+// statuses are checked by the service they belong to.
+func HistoryQuery(r *http.Request) (*dto.HistoryQuery, exception.Exception) {
+
+	page, limit, err := Pagination(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	from, to, err := DateRange(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.HistoryQuery{
+		From:   from,
+		To:     to,
+		Status: r.URL.Query().Get("status"),
+		Page:   page,
+		Limit:  limit,
+	}, nil
+}
+
+// DateRange reads the from and to dates, YYYY-MM-DD in server time. Both
+// days are included, so to is returned as the start of the day after. A
+// date that isn't given is nil.
+func DateRange(r *http.Request) (*time.Time, *time.Time, exception.Exception) {
+
+	var from, to *time.Time
+
+	if value := r.URL.Query().Get("from"); value != "" {
+
+		parsed, err := time.ParseInLocation(queryDateLayout, value, time.Local)
+
+		if err != nil {
+			return nil, nil, exception.NewBadRequestError("from must be a date like 2024-01-01")
+		}
+
+		from = &parsed
+	}
+
+	if value := r.URL.Query().Get("to"); value != "" {
+
+		parsed, err := time.ParseInLocation(queryDateLayout, value, time.Local)
+
+		if err != nil {
+			return nil, nil, exception.NewBadRequestError("to must be a date like 2024-01-31")
+		}
+
+		parsed = parsed.AddDate(0, 0, 1)
+		to = &parsed
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, exception.NewBadRequestError("from can't be after to")
+	}
+
+	return from, to, nil
+}
+
+// splitQueryValues reads comma separated values, repeating the parameter
+// works as well.
+func splitQueryValues(params []string) []string {
+
+	values := []string{}
+
+	for _, param := range params {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,25 @@
+package helper
+
+import (
+	"fashion-api/dto"
+	"net/http"
+)
+
+// SetRedirectLocation points the Location header at the current slug when
+// the response is a slug redirect, keeping the request's query string.
+func SetRedirectLocation(w http.ResponseWriter, r *http.Request, res *ResponseBody) {
+
+	redirect, ok := res.Data.(*dto.SlugRedirectData)
+
+	if !ok {
+		return
+	}
+
+	location := redirect.Location
+
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Location", location)
+}
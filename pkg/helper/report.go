@@ -0,0 +1,121 @@
+package helper
+
+import (
+	"encoding/csv"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultReportDays  = 30
+	MaxReportDays      = 3 * 366
+	DefaultReportLimit = 10
+)
+
+// ReportQuery reads the report filters from the query string. Dates are
+// YYYY-MM-DD and both ends are included, by default the last 30 days.
+func ReportQuery(r *http.Request) (*dto.ReportQuery, exception.Exception) {
+
+	values := r.URL.Query()
+
+	currency, err := RequestCurrency(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	query := &dto.ReportQuery{
+		To:       time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1),
+		Interval: values.Get("interval"),
+		Currency: currency,
+		Rank:     values.Get("by"),
+		Limit:    DefaultReportLimit,
+		Format:   values.Get("format"),
+	}
+
+	from, to, err := DateRange(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if to != nil {
+		query.To = *to
+	}
+
+	query.From = query.To.AddDate(0, 0, -DefaultReportDays)
+
+	if from != nil {
+		query.From = *from
+	}
+
+	if !query.From.Before(query.To) {
+		return nil, exception.NewBadRequestError("from can't be after to")
+	}
+
+	if query.To.Sub(query.From) > MaxReportDays*24*time.Hour {
+		return nil, exception.NewBadRequestError("reports can't cover more than " + strconv.Itoa(MaxReportDays) + " days")
+	}
+
+	switch query.Interval {
+	case "":
+		query.Interval = entity.ReportIntervalDay
+	case entity.ReportIntervalDay, entity.ReportIntervalWeek, entity.ReportIntervalMonth:
+	default:
+		return nil, exception.NewBadRequestError("interval must be day, week or month")
+	}
+
+	switch query.Rank {
+	case "":
+		query.Rank = entity.ReportRankRevenue
+	case entity.ReportRankRevenue, entity.ReportRankUnits:
+	default:
+		return nil, exception.NewBadRequestError("by must be revenue or units")
+	}
+
+	switch query.Format {
+	case "":
+		query.Format = entity.ReportFormatJSON
+	case entity.ReportFormatJSON, entity.ReportFormatCSV:
+	default:
+		return nil, exception.NewBadRequestError("format must be json or csv")
+	}
+
+	if value := values.Get("limit"); value != "" {
+
+		parsed, err := strconv.Atoi(value)
+
+		if err != nil || parsed < 1 || parsed > MaxPageLimit {
+			return nil, exception.NewBadRequestError("limit must be between 1 and " + strconv.Itoa(MaxPageLimit))
+		}
+
+		query.Limit = parsed
+	}
+
+	return query, nil
+}
+
+// WriteCSV writes report as a CSV attachment named filename.
+func WriteCSV(w http.ResponseWriter, filename string, report dto.CSVReport) {
+
+	header, records := report.CSV()
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+	writer.WriteAll(records)
+
+	if err := writer.Error(); err != nil {
+		log.Println(err.Error())
+	}
+}
@@ -0,0 +1,86 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var ErrUnknownRate = errors.New("exchange rate is not configured")
+
+// Converter converts amounts between currencies using rates expressed as
+// units of the currency per one unit of the base currency.
+type Converter struct {
+	base  string
+	rates map[string]*big.Rat
+}
+
+func NewConverter(base string, rates map[string]*big.Rat) *Converter {
+
+	normalized := map[string]*big.Rat{}
+
+	for currency, rate := range rates {
+		normalized[strings.ToUpper(currency)] = rate
+	}
+
+	normalized[strings.ToUpper(base)] = big.NewRat(1, 1)
+
+	return &Converter{
+		base:  strings.ToUpper(base),
+		rates: normalized,
+	}
+}
+
+func (c *Converter) Base() string {
+	return c.base
+}
+
+// Convert returns m expressed in the target currency, rounded half away
+// from zero to the target's minor unit.
+func (c *Converter) Convert(m Money, to string) (Money, error) {
+
+	to = strings.ToUpper(to)
+
+	if m.Currency == to || to == "" {
+		return m, nil
+	}
+
+	from, ok := c.rates[m.Currency]
+
+	if !ok || from.Sign() == 0 {
+		return Money{}, ErrUnknownRate
+	}
+
+	target, ok := c.rates[to]
+
+	if !ok {
+		return Money{}, ErrUnknownRate
+	}
+
+	// minor(from) -> major(from) -> major(base) -> major(to) -> minor(to)
+	value := new(big.Rat).SetInt64(m.Amount)
+	value.Quo(value, pow10(Exponent(m.Currency)))
+	value.Quo(value, from)
+	value.Mul(value, target)
+	value.Mul(value, pow10(Exponent(to)))
+
+	return Money{Amount: round(value), Currency: to}, nil
+}
+
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+func round(r *big.Rat) int64 {
+
+	num, denom := new(big.Int).Set(r.Num()), r.Denom()
+	half := new(big.Int).Rsh(denom, 1)
+
+	if num.Sign() >= 0 {
+		num.Add(num, half)
+	} else {
+		num.Sub(num, half)
+	}
+
+	return num.Quo(num, denom).Int64()
+}
@@ -0,0 +1,205 @@
+package money
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// exponents lists how many minor units make up one major unit for the
+// currencies we sell in. IDR is treated as zero-decimal, which is how local
+// payment gateways settle it.
+var exponents = map[string]int{
+	"IDR": 0,
+	"JPY": 0,
+	"KRW": 0,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"SGD": 2,
+	"MYR": 2,
+	"AUD": 2,
+}
+
+// Money is an amount in minor units (cents, sen, ...) of an ISO 4217 currency.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	Display  string `json:"display"`
+}
+
+func New(amount int64, currency string) Money {
+	return Money{
+		Amount:   amount,
+		Currency: strings.ToUpper(currency),
+	}
+}
+
+// IsSupported reports whether the currency code is one we can price in.
+func IsSupported(currency string) bool {
+	_, ok := exponents[strings.ToUpper(currency)]
+	return ok
+}
+
+// Exponent returns the number of decimal places of the currency.
+func Exponent(currency string) int {
+
+	if exp, ok := exponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+
+	return 2
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.pick(other)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.pick(other)}
+}
+
+func (m Money) Mul(n int) Money {
+	return Money{Amount: m.Amount * int64(n), Currency: m.Currency}
+}
+
+// MulDiv returns m * n / d rounded down, used to prorate amounts.
+func (m Money) MulDiv(n int, d int) Money {
+
+	if d == 0 {
+		return Money{Currency: m.Currency}
+	}
+
+	return Money{Amount: m.Amount * int64(n) / int64(d), Currency: m.Currency}
+}
+
+// MulDivRound returns m * n / d rounded half away from zero, used where a
+// fraction of a minor unit must not be lost, such as tax.
+func (m Money) MulDivRound(n int, d int) Money {
+
+	if d == 0 {
+		return Money{Currency: m.Currency}
+	}
+
+	product, divisor := m.Amount*int64(n), int64(d)
+
+	if (product < 0) != (divisor < 0) {
+		return Money{Amount: (product - divisor/2) / divisor, Currency: m.Currency}
+	}
+
+	return Money{Amount: (product + divisor/2) / divisor, Currency: m.Currency}
+}
+
+// Percent returns p percent of m rounded down.
+func (m Money) Percent(p int) Money {
+	return m.MulDiv(p, 100)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// SameCurrency reports whether m and other can be added or compared. A zero
+// value without a currency goes with any currency.
+func (m Money) SameCurrency(other Money) bool {
+	return m.Currency == "" || other.Currency == "" || m.Currency == other.Currency
+}
+
+func (m Money) LessThan(other Money) bool {
+	m.pick(other)
+	return m.Amount < other.Amount
+}
+
+func (m Money) Min(other Money) Money {
+
+	currency := m.pick(other)
+
+	if other.Amount < m.Amount {
+		return Money{Amount: other.Amount, Currency: currency}
+	}
+
+	return Money{Amount: m.Amount, Currency: currency}
+}
+
+// String formats the amount in major units, e.g. "12.50 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+// Decimal formats the amount in major units without the currency code.
+func (m Money) Decimal() string {
+
+	exp := Exponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	if exp == 0 {
+		return fmt.Sprintf("%s%d", sign, amount)
+	}
+
+	unit := int64(1)
+
+	for i := 0; i < exp; i++ {
+		unit *= 10
+	}
+
+	return fmt.Sprintf("%s%d.%0*d", sign, amount/unit, exp, amount%unit)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{
+		Amount:   m.Amount,
+		Currency: m.Currency,
+		Display:  m.Decimal(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Money) UnmarshalJSON(b []byte) error {
+
+	data := moneyJSON{}
+
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	*m = New(data.Amount, data.Currency)
+
+	return nil
+}
+
+// pick keeps the currency of whichever operand has one, so zero values can
+// take part in arithmetic without being initialised first. Mixing two real
+// currencies is a programming error, converting has to happen before, so it
+// panics rather than silently adding rupiah to dollars. Amounts that come
+// from a request or a different record are checked with SameCurrency first.
+func (m Money) pick(other Money) string {
+
+	if m.Currency == "" {
+		return other.Currency
+	}
+
+	if !m.SameCurrency(other) {
+		panic(fmt.Sprintf("money: currency mismatch %s and %s", m.Currency, other.Currency))
+	}
+
+	return m.Currency
+}
@@ -0,0 +1,178 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAddAndSubKeepTheCurrency(t *testing.T) {
+
+	cases := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{name: "add", got: New(1250, "USD").Add(New(250, "USD")), want: New(1500, "USD")},
+		{name: "sub", got: New(1250, "USD").Sub(New(250, "USD")), want: New(1000, "USD")},
+		{name: "sub below zero", got: New(250, "IDR").Sub(New(1250, "IDR")), want: New(-1000, "IDR")},
+		{name: "add to a zero value", got: Money{}.Add(New(250, "USD")), want: New(250, "USD")},
+		{name: "sub a zero value", got: New(250, "USD").Sub(Money{}), want: New(250, "USD")},
+		{name: "lowercase code", got: New(100, "usd").Add(New(100, "USD")), want: New(200, "USD")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.got != c.want {
+				t.Fatalf("got %s, want %s", c.got, c.want)
+			}
+		})
+	}
+}
+
+func TestSameCurrency(t *testing.T) {
+
+	cases := []struct {
+		name string
+		a, b Money
+		same bool
+	}{
+		{name: "same currency", a: New(1, "USD"), b: New(2, "USD"), same: true},
+		{name: "zero value on the left", a: Money{}, b: New(2, "IDR"), same: true},
+		{name: "zero value on the right", a: New(1, "IDR"), b: Money{}, same: true},
+		{name: "different currencies", a: New(1, "USD"), b: New(1, "IDR")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.a.SameCurrency(c.b) != c.same || c.b.SameCurrency(c.a) != c.same {
+				t.Fatalf("SameCurrency is %t, want %t", c.a.SameCurrency(c.b), c.same)
+			}
+		})
+	}
+}
+
+func TestMixingCurrenciesPanics(t *testing.T) {
+
+	ops := map[string]func(){
+		"add":       func() { New(1, "USD").Add(New(1, "IDR")) },
+		"sub":       func() { New(1, "USD").Sub(New(1, "IDR")) },
+		"less than": func() { New(1, "USD").LessThan(New(1, "IDR")) },
+		"min":       func() { New(1, "USD").Min(New(1, "IDR")) },
+	}
+
+	for name, op := range ops {
+		t.Run(name, func(t *testing.T) {
+
+			defer func() {
+				if recover() == nil {
+					t.Fatal("mixing USD and IDR didn't panic")
+				}
+			}()
+
+			op()
+		})
+	}
+}
+
+func TestMinAndLessThan(t *testing.T) {
+
+	cases := []struct {
+		name     string
+		a, b     Money
+		min      Money
+		lessThan bool
+	}{
+		{name: "smaller first", a: New(100, "USD"), b: New(200, "USD"), min: New(100, "USD"), lessThan: true},
+		{name: "smaller second", a: New(200, "USD"), b: New(100, "USD"), min: New(100, "USD")},
+		{name: "equal", a: New(100, "USD"), b: New(100, "USD"), min: New(100, "USD")},
+		{name: "zero value", a: Money{}, b: New(100, "USD"), min: New(0, "USD"), lessThan: true},
+		{name: "negative", a: New(-100, "IDR"), b: New(0, "IDR"), min: New(-100, "IDR"), lessThan: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			if min := c.a.Min(c.b); min != c.min {
+				t.Errorf("min %s, want %s", min, c.min)
+			}
+
+			if c.a.LessThan(c.b) != c.lessThan {
+				t.Errorf("less than is %t, want %t", c.a.LessThan(c.b), c.lessThan)
+			}
+		})
+	}
+}
+
+func TestRounding(t *testing.T) {
+
+	cases := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{name: "muldiv rounds down", got: New(1000, "USD").MulDiv(2, 3), want: New(666, "USD")},
+		{name: "muldiv by zero", got: New(1000, "USD").MulDiv(2, 0), want: New(0, "USD")},
+		{name: "muldivround rounds up from half", got: New(1000, "USD").MulDivRound(1, 8), want: New(125, "USD")},
+		{name: "muldivround rounds half away from zero", got: New(5, "USD").MulDivRound(1, 2), want: New(3, "USD")},
+		{name: "muldivround rounds down below half", got: New(1000, "USD").MulDivRound(1, 3), want: New(333, "USD")},
+		{name: "muldivround on a negative amount", got: New(-5, "USD").MulDivRound(1, 2), want: New(-3, "USD")},
+		{name: "muldivround with a negative divisor", got: New(5, "USD").MulDivRound(1, -2), want: New(-3, "USD")},
+		{name: "muldivround by zero", got: New(1000, "USD").MulDivRound(2, 0), want: New(0, "USD")},
+		{name: "percent rounds down", got: New(999, "USD").Percent(10), want: New(99, "USD")},
+		{name: "inclusive tax share", got: New(111000, "IDR").MulDivRound(1100, 11100), want: New(11000, "IDR")},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if c.got != c.want {
+				t.Fatalf("got %s, want %s", c.got, c.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+
+	cases := []struct {
+		money Money
+		want  string
+	}{
+		{money: New(1250, "USD"), want: "12.50 USD"},
+		{money: New(5, "USD"), want: "0.05 USD"},
+		{money: New(-1250, "EUR"), want: "-12.50 EUR"},
+		{money: New(150000, "IDR"), want: "150000 IDR"},
+		{money: New(-500, "JPY"), want: "-500 JPY"},
+		{money: New(1250, "XYZ"), want: "12.50 XYZ"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.want, func(t *testing.T) {
+			if got := c.money.String(); got != c.want {
+				t.Fatalf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+
+	b, err := json.Marshal(New(1250, "USD"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != `{"amount":1250,"currency":"USD","display":"12.50"}` {
+		t.Fatalf("marshalled to %s", b)
+	}
+
+	var m Money
+
+	if err := json.Unmarshal([]byte(`{"amount":1250,"currency":"usd","display":"ignored"}`), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m != New(1250, "USD") {
+		t.Fatalf("unmarshalled to %s", m)
+	}
+}
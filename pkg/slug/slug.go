@@ -0,0 +1,139 @@
+package slug
+
+import (
+	"fashion-api/pkg/exception"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const MaxLength = 150
+
+// accents folds the latin letters most product names use into ascii, other
+// letters and symbols are treated as separators.
+var accents = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a",
+	"ç", "c",
+	"è", "e", "é", "e", "ê", "e", "ë", "e",
+	"ì", "i", "í", "i", "î", "i", "ï", "i",
+	"ñ", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u",
+	"ý", "y", "ÿ", "y",
+	"ß", "ss", "æ", "ae", "œ", "oe",
+	"&", " and ",
+)
+
+// Make turns s into a lowercase, hyphen separated slug of ascii letters and
+// digits. It returns an empty string when s has nothing to keep.
+func Make(s string) string {
+
+	s = accents.Replace(strings.ToLower(s))
+
+	b := strings.Builder{}
+	separate := false
+
+	for _, r := range s {
+
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+
+			if separate && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+
+			b.WriteRune(r)
+			separate = false
+			continue
+		}
+
+		separate = true
+	}
+
+	slug := b.String()
+
+	if len(slug) > MaxLength {
+		slug = strings.TrimRight(slug[:MaxLength], "-")
+	}
+
+	return slug
+}
+
+// WithSuffix returns the n-th alternative for a slug that is already taken,
+// keeping the result within MaxLength.
+func WithSuffix(slug string, n int) string {
+
+	suffix := "-" + strconv.Itoa(n)
+
+	if len(slug)+len(suffix) > MaxLength {
+		slug = strings.TrimRight(slug[:MaxLength-len(suffix)], "-")
+	}
+
+	return slug + suffix
+}
+
+// IsValid reports whether s is already in slug form.
+func IsValid(s string) bool {
+	return s != "" && Make(s) == s
+}
+
+// Unique returns base, or the first suffixed alternative that taken reports
+// as free.
+func Unique(base string, taken func(slug string) (bool, exception.Exception)) (string, exception.Exception) {
+
+	candidate := base
+
+	for n := 2; ; n++ {
+
+		isTaken, err := taken(candidate)
+
+		if err != nil {
+			return "", err
+		}
+
+		if !isTaken {
+			return candidate, nil
+		}
+
+		candidate = WithSuffix(base, n)
+	}
+}
+
+// Resolve picks the slug to store. A requested slug is normalized and has to
+// be free, otherwise current is kept so links don't break on a rename, and
+// new records get one generated from name, or fallback when name has nothing
+// to keep.
+func Resolve(requested string, name string, current string, fallback string, taken func(slug string) (bool, exception.Exception)) (string, exception.Exception) {
+
+	if requested == "" && current != "" {
+		return current, nil
+	}
+
+	if requested != "" {
+
+		slug := Make(requested)
+
+		if slug == "" {
+			return "", exception.NewBadRequestError("slug must contain letters or digits")
+		}
+
+		isTaken, err := taken(slug)
+
+		if err != nil {
+			return "", err
+		}
+
+		if isTaken {
+			return "", exception.NewConflictError("slug is already in use")
+		}
+
+		return slug, nil
+	}
+
+	base := Make(name)
+
+	if base == "" {
+		base = fallback
+	}
+
+	return Unique(base, taken)
+}
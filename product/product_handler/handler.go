@@ -2,9 +2,11 @@ package product_handler
 
 import (
 	"fashion-api/dto"
+	"fashion-api/entity"
 	"fashion-api/pkg/exception"
 	"fashion-api/pkg/helper"
 	"fashion-api/product/product_service"
+	"io"
 	"strings"
 
 	"encoding/json"
@@ -14,6 +16,9 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
+// maxImportSize caps the CSV files accepted by Import
+const maxImportSize = 10 << 20
+
 type productHandler struct {
 	ps product_service.ProductService
 }
@@ -22,8 +27,15 @@ type ProductHandler interface {
 	Add(w http.ResponseWriter, r *http.Request)
 	Fetch(w http.ResponseWriter, r *http.Request)
 	FetchById(w http.ResponseWriter, r *http.Request)
+	FetchBySlug(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 	Modify(w http.ResponseWriter, r *http.Request)
+	PriceHistory(w http.ResponseWriter, r *http.Request)
+	AdjustStock(w http.ResponseWriter, r *http.Request)
+	StockMovements(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	FetchImport(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
 }
 
 func NewProductHandler(ps product_service.ProductService) ProductHandler {
@@ -52,7 +64,9 @@ func (ph *productHandler) Add(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := ph.ps.Add(payload)
+	user := r.Context().Value("userData").(*entity.User)
+
+	res, err := ph.ps.Add(user.Id, payload)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -89,7 +103,23 @@ func (ph *productHandler) Fetch(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 
-	res, err := ph.ps.Fetch()
+	currency, err := helper.RequestCurrency(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	query, err := helper.ProductQuery(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ph.ps.Fetch(currency, query)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -108,7 +138,15 @@ func (ph *productHandler) FetchById(w http.ResponseWriter, r *http.Request) {
 
 	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
 
-	res, err := ph.ps.FetchById(id)
+	currency, err := helper.RequestCurrency(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ph.ps.FetchById(id, currency)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -143,7 +181,91 @@ func (ph *productHandler) Modify(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := ph.ps.Modify(id, payload)
+	user := r.Context().Value("userData").(*entity.User)
+
+	res, err := ph.ps.Modify(user.Id, id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// PriceHistory implements ProductHandler.
+func (ph *productHandler) PriceHistory(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ph.ps.FetchPriceHistory(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchBySlug implements ProductHandler.
+func (ph *productHandler) FetchBySlug(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	currency, err := helper.RequestCurrency(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ph.ps.FetchBySlug(chi.URLParam(r, "slug"), currency)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	helper.SetRedirectLocation(w, r, res)
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// AdjustStock implements ProductHandler.
+func (ph *productHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	payload := &dto.StockAdjustmentPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	user := r.Context().Value("userData").(*entity.User)
+
+	res, err := ph.ps.AdjustStock(user.Id, id, payload)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -154,3 +276,102 @@ func (ph *productHandler) Modify(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(res.Status)
 	w.Write(helper.ResponseJSON(res))
 }
+
+// StockMovements implements ProductHandler.
+func (ph *productHandler) StockMovements(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	page, limit, err := helper.Pagination(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := ph.ps.FetchStockMovements(id, page, limit)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Import implements ProductHandler. The CSV is either the request body or
+// the file field of a multipart form.
+func (ph *productHandler) Import(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	user := r.Context().Value("userData").(*entity.User)
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var file io.Reader = r.Body
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+
+		formFile, _, err := r.FormFile("file")
+
+		if err != nil {
+			err := exception.NewBadRequestError("file can't be empty and must be at most 10MB")
+			w.WriteHeader(err.Status())
+			w.Write(helper.ResponseJSON(err))
+			return
+		}
+
+		defer formFile.Close()
+
+		file = formFile
+	}
+
+	res, err := ph.ps.Import(user.Id, file, dryRun)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchImport implements ProductHandler.
+func (ph *productHandler) FetchImport(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := ph.ps.FetchImport(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Export implements ProductHandler.
+func (ph *productHandler) Export(w http.ResponseWriter, r *http.Request) {
+
+	// the CSV is streamed by the service, only errors are JSON
+	if err := ph.ps.Export(w); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+	}
+}
@@ -0,0 +1,40 @@
+package product_job
+
+import (
+	"fashion-api/entity"
+	"fashion-api/product/product_repo"
+	"log"
+	"time"
+)
+
+type staleImportJob struct {
+	pr product_repo.ProductRepo
+}
+
+// StaleImportJob fails the imports whose server stopped reporting in. Any
+// server may run it, an import that is still alive somewhere keeps its
+// heartbeat fresh and is left alone.
+type StaleImportJob interface {
+	Run()
+}
+
+func NewStaleImportJob(pr product_repo.ProductRepo) StaleImportJob {
+	return &staleImportJob{
+		pr: pr,
+	}
+}
+
+// Run implements StaleImportJob.
+func (j *staleImportJob) Run() {
+
+	ticker := time.NewTicker(entity.ProductImportHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		if err := j.pr.FailStaleImports(entity.ProductImportStaleAfter); err != nil {
+			log.Println(err.Message())
+		}
+
+		<-ticker.C
+	}
+}
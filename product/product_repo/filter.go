@@ -0,0 +1,34 @@
+package product_repo
+
+// ProductFilter narrows a product listing. Prices are minor units of the
+// base currency, a zero value leaves the bound open. Attributes are keyed
+// by attribute code.
+type ProductFilter struct {
+	Search      string
+	CategoryIds []int
+	MinPrice    int64
+	MaxPrice    int64
+	InStock     bool
+	Tags        []string
+	Attributes  map[string][]string
+	Sort        string
+	Limit       int
+	Offset      int
+}
+
+// Without returns a copy of the filter that leaves out the values chosen
+// for one attribute, facet counts for it are taken that way so the other
+// values stay selectable.
+func (f *ProductFilter) Without(code string) *ProductFilter {
+
+	filter := *f
+	filter.Attributes = map[string][]string{}
+
+	for key, values := range f.Attributes {
+		if key != code {
+			filter.Attributes[key] = values
+		}
+	}
+
+	return &filter
+}
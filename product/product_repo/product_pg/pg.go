@@ -2,7 +2,10 @@ package product_pg
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log"
+	"time"
 
 	"fashion-api/entity"
 	"fashion-api/pkg/exception"
@@ -14,15 +17,139 @@ type productPg struct {
 }
 
 const (
-	addProductQuery = `insert into "product" (name, description, category_id, price, stock) values ($1, $2, $3, $4, $5)`
+	addProductQuery = `insert into "product" (name, description, category_id, price, compare_at_price, sale_price, sale_starts_at, sale_ends_at, stock, currency, tax_class, weight_grams, length_cm, width_cm, height_cm, slug, reorder_threshold, sku) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, nullif($18, '')) returning id`
 
-	fetchProductQuery = `select id, name, description, category_id, price, stock, sold, created_at, updated_at from "product" where deleted_at is null`
+	fetchProductQuery = `select id, name, slug, coalesce(sku, ''), description, category_id, tax_class, price, compare_at_price, sale_price, currency, sale_starts_at, sale_ends_at, weight_grams, length_cm, width_cm, height_cm, stock, reserved, sold, reorder_threshold, rating_average, review_count, created_at, updated_at from "product" where deleted_at is null`
 
-	fetchByIdProductQuery = `select id, name, description, category_id, price, stock, sold, created_at, updated_at from "product" where id = $1 and deleted_at is null`
+	fetchBySlugProductQuery = `select id, name, slug, coalesce(sku, ''), description, category_id, tax_class, price, compare_at_price, sale_price, currency, sale_starts_at, sale_ends_at, weight_grams, length_cm, width_cm, height_cm, stock, reserved, sold, reorder_threshold, rating_average, review_count, created_at, updated_at from "product" where slug = $1 and deleted_at is null`
+
+	// soft deleted products keep their slug, it stays taken
+	slugTakenQuery = `select exists (select 1 from "product" where slug = $1 and id <> $2)`
+
+	// the old slug is remembered before the update so storefront links to
+	// it can be redirected, one that is being reused stops redirecting
+	addSlugRedirectQuery = `insert into slug_redirect (entity, old_slug, entity_id) select 'product', slug, id from "product" where id = $1 and slug <> $2 on conflict (entity, old_slug) do update set entity_id = excluded.entity_id, created_at = now()`
+
+	releaseSlugRedirectQuery = `delete from slug_redirect where entity = 'product' and old_slug = $1`
+
+	fetchSlugRedirectQuery = `select p.slug from slug_redirect as r join "product" as p on r.entity_id = p.id where r.entity = 'product' and r.old_slug = $1 and p.deleted_at is null`
+
+	duplicateSlugError = `pq: duplicate key value violates unique constraint "product_slug_key"`
+
+	duplicateSkuError = `pq: duplicate key value violates unique constraint "product_sku_key"`
+
+	countProductQuery = `select count(*) from "product" where deleted_at is null`
+
+	// effectivePriceColumn mirrors entity.Product.PriceAt so listings can be
+	// filtered and sorted by what a customer pays right now
+	effectivePriceColumn = `(case when sale_price > 0 and (sale_starts_at is null or sale_starts_at <= now()) and (sale_ends_at is null or sale_ends_at > now()) then sale_price else price end)`
+
+	fetchByIdProductQuery = `select id, name, slug, coalesce(sku, ''), description, category_id, tax_class, price, compare_at_price, sale_price, currency, sale_starts_at, sale_ends_at, weight_grams, length_cm, width_cm, height_cm, stock, reserved, sold, reorder_threshold, rating_average, review_count, created_at, updated_at from "product" where id = $1 and deleted_at is null`
 
 	deleteProductQuery = `update "product" set updated_at = now(), deleted_at = now() where id = $1`
 
-	modifyProductQuery = `update "product" set name = $2, description = $3, category_id = $4, price = $5, stock = $6, updated_at = now() where id = $1`
+	// a null stock leaves the stock as it is
+	modifyProductQuery = `update "product" set name = $2, description = $3, category_id = $4, price = $5, compare_at_price = $6, sale_price = $7, sale_starts_at = $8, sale_ends_at = $9, stock = coalesce($10, stock), currency = $11, tax_class = $12, weight_grams = $13, length_cm = $14, width_cm = $15, height_cm = $16, slug = $17, reorder_threshold = $18, sku = nullif($19, ''), updated_at = now() where id = $1`
+
+	// a history row is only written when one of the price fields differs
+	// from the most recent entry for the product
+	addPriceHistoryQuery = `insert into product_price_history (product_id, price, compare_at_price, sale_price, sale_starts_at, sale_ends_at, changed_by, currency)
+		select $1, $2, $3, $4, $5, $6, $7, $8
+		where not exists (
+			select 1 from (
+				select price, compare_at_price, sale_price, sale_starts_at, sale_ends_at, currency from product_price_history where product_id = $1 order by id desc limit 1
+			) as latest
+			where (latest.price, latest.compare_at_price, latest.sale_price, latest.sale_starts_at, latest.sale_ends_at, latest.currency) is not distinct from ($2::int, $3::int, $4::int, $5::timestamptz, $6::timestamptz, $8::varchar)
+		)`
+
+	addProductTagQuery = `insert into product_tag (product_id, tag) values ($1, $2) on conflict do nothing`
+
+	deleteProductTagsQuery = `delete from product_tag where product_id = $1`
+
+	fetchProductTagsQuery = `select product_id, tag from product_tag where product_id = any($1) order by tag asc`
+
+	addProductAttributeQuery = `insert into product_attribute (product_id, attribute_id, value) select $1, id, $3 from attribute where code = $2`
+
+	deleteProductAttributesQuery = `delete from product_attribute where product_id = $1`
+
+	fetchProductAttributesQuery = `select pa.product_id, a.code, pa.value from product_attribute as pa join attribute as a on pa.attribute_id = a.id where pa.product_id = any($1)`
+
+	// facet queries count within the products matching the listing filter,
+	// the filter conditions are appended to productIdsQuery
+	productIdsQuery = `select id from "product" where deleted_at is null`
+
+	attributeFacetQuery = `select value, count(*) from product_attribute where attribute_id = $%d and product_id in (%s) group by value order by count(*) desc, value asc`
+
+	tagFacetQuery = `select tag, count(*) from product_tag where product_id in (%s) group by tag order by count(*) desc, tag asc limit %d`
+
+	tagFacetLimit = 30
+
+	// primaryLocationQuery is where stock entered without a location is held
+	primaryLocationQuery = `select id from location where active order by priority asc, id asc limit 1`
+
+	addInitialStockQuery = `with moved as (
+			insert into stock_level (product_id, location_id, stock) select p.id, l.id, p.stock from product as p, (` + primaryLocationQuery + `) as l where p.id = $1
+			returning product_id, location_id, stock, reserved
+		)
+		insert into stock_movement (product_id, location_id, type, qty, stock_after, reserved_after, reason, actor_id)
+		select product_id, location_id, 'restock', stock, stock, reserved, 'initial stock', $2 from moved`
+
+	// stock set through the product form is recorded as the difference to
+	// what was on hand, taken before the product is updated, and applied
+	// at the primary location
+	addStockDifferenceQuery = `with difference as (
+			select p.id as product_id, l.id as location_id, $2 - p.stock as qty from product as p, (` + primaryLocationQuery + `) as l where p.id = $1 and p.stock <> $2 for update of p
+		), moved as (
+			insert into stock_level (product_id, location_id, stock) select product_id, location_id, qty from difference
+			on conflict (product_id, location_id) do update set stock = stock_level.stock + excluded.stock
+			returning product_id, location_id, stock, reserved
+		)
+		insert into stock_movement (product_id, location_id, type, qty, stock_after, reserved_after, reason, actor_id)
+		select m.product_id, m.location_id, 'adjustment', d.qty, m.stock, m.reserved, 'product updated', $3 from moved as m join difference as d on d.product_id = m.product_id`
+
+	// adjustments without a location go to the primary one, stock_level_balance
+	// keeps them from going below the units reserved there
+	adjustStockQuery = `with target as (
+			select p.id as product_id, coalesce(nullif($6::int, 0), (` + primaryLocationQuery + `)) as location_id from product as p where p.id = $1 and p.deleted_at is null
+		), moved as (
+			insert into stock_level (product_id, location_id, stock) select product_id, location_id, $2 from target where location_id is not null
+			on conflict (product_id, location_id) do update set stock = stock_level.stock + excluded.stock
+			returning product_id, location_id, stock, reserved
+		), aggregated as (
+			update product set stock = stock + $2, updated_at = now() where id = (select product_id from moved)
+		)
+		insert into stock_movement (product_id, location_id, type, qty, stock_after, reserved_after, reason, actor_id)
+		select product_id, location_id, $3, $2, stock, reserved, $4, $5 from moved`
+
+	stockBalanceError = `pq: new row for relation "stock_level" violates check constraint "stock_level_balance"`
+
+	unknownLocationError = `pq: insert or update on table "stock_level" violates foreign key constraint "fk_location_id"`
+
+	fetchStockMovementsQuery = `select m.id, m.product_id, coalesce(m.location_id, 0), coalesce(l.code, ''), m.type, m.qty, m.reserved_qty, m.stock_after, m.reserved_after, m.reason, m.reference, coalesce(m.actor_id, 0), coalesce(u.full_name, ''), m.created_at from stock_movement as m left join location as l on m.location_id = l.id left join "user" as u on m.actor_id = u.id where m.product_id = $1 order by m.id desc limit $2 offset $3`
+
+	fetchStockLedgerQuery = `select p.stock, p.reserved, coalesce(sum(m.qty), 0), coalesce(sum(m.reserved_qty), 0),
+		(select coalesce(sum(stock), 0) from stock_level where product_id = p.id), (select coalesce(sum(reserved), 0) from stock_level where product_id = p.id), count(m.id)
+		from product as p left join stock_movement as m on m.product_id = p.id where p.id = $1 group by p.id`
+
+	fetchIdBySkuQuery = `select id from "product" where sku = $1 and deleted_at is null`
+
+	// products are exported in id order, a batch starts after the last id
+	// of the previous one
+	fetchBatchQuery = fetchProductQuery + ` and id > $1 order by id asc limit $2`
+
+	addImportQuery = `insert into product_import (status, total_rows, processed_rows, failed_count, errors, created_by, instance_id, heartbeat_at) values ($1, $2, $3, $4, $5, $6, $7, now()) returning id, created_at, heartbeat_at`
+
+	// an import another server already gave up on stays failed
+	modifyImportQuery = `update product_import set status = $2, processed_rows = $3, created_count = $4, updated_count = $5, failed_count = $6, errors = $7, started_at = $8, finished_at = $9, heartbeat_at = now() where id = $1 and status <> 'failed'`
+
+	heartbeatImportQuery = `update product_import set heartbeat_at = now() where id = $1 and instance_id = $2 and status in ('queued', 'running')`
+
+	failStaleImportsQuery = `update product_import set status = 'failed', finished_at = now(), errors = errors || '[{"line": 0, "sku": "", "message": "the server running the import stopped, rows after the processed ones weren't applied"}]'::jsonb
+		where status in ('queued', 'running') and coalesce(heartbeat_at, created_at) < now() - make_interval(secs => $1)`
+
+	fetchImportByIdQuery = `select id, status, total_rows, processed_rows, created_count, updated_count, failed_count, errors, coalesce(created_by, 0), instance_id, heartbeat_at, created_at, started_at, finished_at from product_import where id = $1`
+
+	fetchPriceHistoryQuery = `select h.id, h.product_id, h.price, h.compare_at_price, h.sale_price, h.currency, h.sale_starts_at, h.sale_ends_at, coalesce(h.changed_by, 0), coalesce(u.full_name, ''), h.created_at from product_price_history as h left join "user" as u on h.changed_by = u.id where h.product_id = $1 order by h.created_at desc, h.id desc`
 )
 
 func NewProductPg(db *sql.DB) product_repo.ProductRepo {
@@ -32,7 +159,7 @@ func NewProductPg(db *sql.DB) product_repo.ProductRepo {
 }
 
 // Add implements product_repo.ProductRepo.
-func (pg *productPg) Add(product *entity.Product) exception.Exception {
+func (pg *productPg) Add(product *entity.Product, changedBy int) exception.Exception {
 
 	tx, err := pg.db.Begin()
 
@@ -50,13 +177,63 @@ func (pg *productPg) Add(product *entity.Product) exception.Exception {
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(
+	if err := stmt.QueryRow(
 		product.Name,
 		product.Description,
 		product.CategoryId,
-		product.Price,
+		product.Price.Amount,
+		product.CompareAtPrice.Amount,
+		product.SalePrice.Amount,
+		product.SaleStartsAt,
+		product.SaleEndsAt,
 		product.Stock,
-	); err != nil {
+		product.Price.Currency,
+		product.TaxClass,
+		product.WeightGrams,
+		product.LengthCm,
+		product.WidthCm,
+		product.HeightCm,
+		product.Slug,
+		product.ReorderThreshold,
+		product.Sku,
+	).Scan(&product.Id); err != nil {
+		if err.Error() == duplicateSlugError {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewConflictError("slug is already in use")
+		}
+
+		if err.Error() == duplicateSkuError {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewConflictError("sku is already in use")
+		}
+
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := addPriceHistory(tx, product.Id, product, changedBy); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	initialStock, err := tx.Exec(addInitialStockQuery, product.Id, nullableActor(changedBy))
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := initialStock.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewBadRequestError("an active stock location is needed to hold the stock")
+	}
+
+	if err := saveProductLabels(tx, product.Id, product); err != nil {
 		tx.Rollback()
 		log.Println(err.Error())
 		return exception.NewInternalServerError("something went wrong")
@@ -106,49 +283,53 @@ func (pg *productPg) Delete(id int) exception.Exception {
 }
 
 // Fetch implements product_repo.ProductRepo.
-func (pg *productPg) Fetch() ([]*entity.Product, exception.Exception) {
+func (pg *productPg) Fetch(filter *product_repo.ProductFilter) ([]*entity.Product, int, exception.Exception) {
 
-	products := []*entity.Product{}
+	products, total := []*entity.Product{}, 0
+
+	where, args := productFilterConditions(filter)
+
+	if err := pg.db.QueryRow(countProductQuery+where, args...).Scan(&total); err != nil {
+		log.Println(err.Error())
+		return nil, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	args = append(args, filter.Limit, filter.Offset)
+
+	query := fmt.Sprintf("%s%s order by %s limit $%d offset $%d", fetchProductQuery, where, productSortColumns(filter.Sort), len(args)-1, len(args))
 
-	rows, err := pg.db.Query(fetchProductQuery)
+	rows, err := pg.db.Query(query, args...)
 
 	if err != nil {
 		log.Println(err.Error())
-		return nil, exception.NewInternalServerError("something went wrong")
+		return nil, 0, exception.NewInternalServerError("something went wrong")
 	}
 
 	defer rows.Close()
 
 	for rows.Next() {
 
-		product := entity.Product{}
+		product, err := scanProduct(rows)
 
-		if err := rows.Scan(
-			&product.Id,
-			&product.Name,
-			&product.Description,
-			&product.CategoryId,
-			&product.Price,
-			&product.Stock,
-			&product.Sold,
-			&product.CreatedAt,
-			&product.UpdatedAt,
-		); err != nil {
+		if err != nil {
 			log.Println(err.Error())
-			return nil, exception.NewInternalServerError("something went wrong")
+			return nil, 0, exception.NewInternalServerError("something went wrong")
 		}
 
-		products = append(products, &product)
+		products = append(products, product)
 	}
 
-	return products, nil
+	if err := loadProductLabels(pg.db, products...); err != nil {
+		log.Println(err.Error())
+		return nil, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return products, total, nil
 }
 
 // FetchById implements product_repo.ProductRepo.
 func (pg *productPg) FetchById(id int) (*entity.Product, exception.Exception) {
 
-	product := entity.Product{}
-
 	stmt, err := pg.db.Prepare(fetchByIdProductQuery)
 
 	if err != nil {
@@ -156,17 +337,9 @@ func (pg *productPg) FetchById(id int) (*entity.Product, exception.Exception) {
 		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	if err := stmt.QueryRow(id).Scan(
-		&product.Id,
-		&product.Name,
-		&product.Description,
-		&product.CategoryId,
-		&product.Price,
-		&product.Stock,
-		&product.Sold,
-		&product.CreatedAt,
-		&product.UpdatedAt,
-	); err != nil {
+	product, err := scanProduct(stmt.QueryRow(id))
+
+	if err != nil {
 
 		if err == sql.ErrNoRows {
 			log.Println(err.Error())
@@ -177,13 +350,16 @@ func (pg *productPg) FetchById(id int) (*entity.Product, exception.Exception) {
 		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	return &product, nil
+	if err := loadProductLabels(pg.db, product); err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return product, nil
 }
 
 // Modify implements product_repo.ProductRepo.
-func (pg *productPg) Modify(id int, product *entity.Product) exception.Exception {
-
-	log.Println(id)
+func (pg *productPg) Modify(id int, product *entity.Product, keepStock bool, changedBy int) exception.Exception {
 
 	tx, err := pg.db.Begin()
 
@@ -193,6 +369,27 @@ func (pg *productPg) Modify(id int, product *entity.Product) exception.Exception
 		return exception.NewInternalServerError("something went wrong")
 	}
 
+	if _, err := tx.Exec(addSlugRedirectQuery, id, product.Slug); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(releaseSlugRedirectQuery, product.Slug); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	stock := sql.NullInt64{Int64: int64(product.Stock), Valid: !keepStock}
+
+	if !keepStock {
+		if _, err := tx.Exec(addStockDifferenceQuery, id, product.Stock, nullableActor(changedBy)); err != nil {
+			tx.Rollback()
+			return handleStockLevelError(err)
+		}
+	}
+
 	stmt, err := tx.Prepare(modifyProductQuery)
 
 	if err != nil {
@@ -206,9 +403,46 @@ func (pg *productPg) Modify(id int, product *entity.Product) exception.Exception
 		product.Name,
 		product.Description,
 		product.CategoryId,
-		product.Price,
-		product.Stock,
+		product.Price.Amount,
+		product.CompareAtPrice.Amount,
+		product.SalePrice.Amount,
+		product.SaleStartsAt,
+		product.SaleEndsAt,
+		stock,
+		product.Price.Currency,
+		product.TaxClass,
+		product.WeightGrams,
+		product.LengthCm,
+		product.WidthCm,
+		product.HeightCm,
+		product.Slug,
+		product.ReorderThreshold,
+		product.Sku,
 	); err != nil {
+		if err.Error() == duplicateSlugError {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewConflictError("slug is already in use")
+		}
+
+		if err.Error() == duplicateSkuError {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewConflictError("sku is already in use")
+		}
+
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := addPriceHistory(tx, id, product, changedBy); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := saveProductLabels(tx, id, product); err != nil {
 		tx.Rollback()
 		log.Println(err.Error())
 		return exception.NewInternalServerError("something went wrong")
@@ -222,3 +456,479 @@ func (pg *productPg) Modify(id int, product *entity.Product) exception.Exception
 
 	return nil
 }
+
+// FetchPriceHistory implements product_repo.ProductRepo.
+func (pg *productPg) FetchPriceHistory(id int) ([]*entity.ProductPriceHistory, exception.Exception) {
+
+	histories := []*entity.ProductPriceHistory{}
+
+	rows, err := pg.db.Query(fetchPriceHistoryQuery, id)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		history := entity.ProductPriceHistory{}
+		saleStartsAt, saleEndsAt := sql.NullTime{}, sql.NullTime{}
+
+		if err := rows.Scan(
+			&history.Id,
+			&history.ProductId,
+			&history.Price.Amount,
+			&history.CompareAtPrice.Amount,
+			&history.SalePrice.Amount,
+			&history.Price.Currency,
+			&saleStartsAt,
+			&saleEndsAt,
+			&history.ChangedBy,
+			&history.ChangedByName,
+			&history.CreatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		history.CompareAtPrice.Currency = history.Price.Currency
+		history.SalePrice.Currency = history.Price.Currency
+
+		if saleStartsAt.Valid {
+			history.SaleStartsAt = &saleStartsAt.Time
+		}
+
+		if saleEndsAt.Valid {
+			history.SaleEndsAt = &saleEndsAt.Time
+		}
+
+		histories = append(histories, &history)
+	}
+
+	return histories, nil
+}
+
+func addPriceHistory(tx *sql.Tx, id int, product *entity.Product, changedBy int) error {
+
+	_, err := tx.Exec(
+		addPriceHistoryQuery,
+		id,
+		product.Price.Amount,
+		product.CompareAtPrice.Amount,
+		product.SalePrice.Amount,
+		product.SaleStartsAt,
+		product.SaleEndsAt,
+		nullableActor(changedBy),
+		product.Price.Currency,
+	)
+
+	return err
+}
+
+// FetchBySlug implements product_repo.ProductRepo.
+func (pg *productPg) FetchBySlug(slug string) (*entity.Product, exception.Exception) {
+
+	product, err := scanProduct(pg.db.QueryRow(fetchBySlugProductQuery, slug))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("product not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := loadProductLabels(pg.db, product); err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return product, nil
+}
+
+// SlugTaken implements product_repo.ProductRepo.
+func (pg *productPg) SlugTaken(slug string, excludeId int) (bool, exception.Exception) {
+
+	taken := false
+
+	if err := pg.db.QueryRow(slugTakenQuery, slug, excludeId).Scan(&taken); err != nil {
+		log.Println(err.Error())
+		return false, exception.NewInternalServerError("something went wrong")
+	}
+
+	return taken, nil
+}
+
+// FetchSlugRedirect implements product_repo.ProductRepo.
+func (pg *productPg) FetchSlugRedirect(slug string) (string, exception.Exception) {
+
+	current := ""
+
+	if err := pg.db.QueryRow(fetchSlugRedirectQuery, slug).Scan(&current); err != nil {
+
+		if err == sql.ErrNoRows {
+			return "", exception.NewNotFoundError("product not found")
+		}
+
+		log.Println(err.Error())
+		return "", exception.NewInternalServerError("something went wrong")
+	}
+
+	return current, nil
+}
+
+// FetchFacets implements product_repo.ProductRepo.
+func (pg *productPg) FetchFacets(filter *product_repo.ProductFilter, attributes []*entity.Attribute) ([]*entity.Facet, exception.Exception) {
+
+	facets := []*entity.Facet{}
+
+	for _, attribute := range attributes {
+
+		where, args := productFilterConditions(filter.Without(attribute.Code))
+		args = append(args, attribute.Id)
+
+		rows, err := pg.db.Query(fmt.Sprintf(attributeFacetQuery, len(args), productIdsQuery+where), args...)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		values, err := scanFacetValues(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		if len(values) == 0 {
+			continue
+		}
+
+		facets = append(facets, &entity.Facet{
+			Code:   attribute.Code,
+			Name:   attribute.Name,
+			Values: values,
+		})
+	}
+
+	// tags narrow each other down, their counts follow the whole filter
+	where, args := productFilterConditions(filter)
+
+	rows, err := pg.db.Query(fmt.Sprintf(tagFacetQuery, productIdsQuery+where, tagFacetLimit), args...)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	values, err := scanFacetValues(rows)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	if len(values) > 0 {
+		facets = append(facets, &entity.Facet{
+			Code:   entity.FacetCodeTags,
+			Name:   "Tags",
+			Values: values,
+		})
+	}
+
+	return facets, nil
+}
+
+// AdjustStock implements product_repo.ProductRepo.
+func (pg *productPg) AdjustStock(movement *entity.StockMovement) exception.Exception {
+
+	res, err := pg.db.Exec(
+		adjustStockQuery,
+		movement.ProductId,
+		movement.Qty,
+		movement.Type,
+		movement.Reason,
+		nullableActor(movement.ActorId),
+		movement.LocationId,
+	)
+
+	if err != nil {
+		return handleStockLevelError(err)
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return exception.NewBadRequestError("an active stock location is needed to hold the stock")
+	}
+
+	return nil
+}
+
+// FetchStockMovements implements product_repo.ProductRepo.
+func (pg *productPg) FetchStockMovements(id int, limit int, offset int) ([]*entity.StockMovement, *entity.StockLedger, int, exception.Exception) {
+
+	ledger, total := &entity.StockLedger{}, 0
+
+	if err := pg.db.QueryRow(fetchStockLedgerQuery, id).Scan(
+		&ledger.Stock,
+		&ledger.Reserved,
+		&ledger.LedgerStock,
+		&ledger.LedgerReserved,
+		&ledger.LocationStock,
+		&ledger.LocationReserved,
+		&total,
+	); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, nil, 0, exception.NewNotFoundError("product not found")
+		}
+
+		log.Println(err.Error())
+		return nil, nil, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	ledger.Reconciled = ledger.Stock == ledger.LedgerStock && ledger.Reserved == ledger.LedgerReserved &&
+		ledger.Stock == ledger.LocationStock && ledger.Reserved == ledger.LocationReserved
+
+	movements := []*entity.StockMovement{}
+
+	rows, err := pg.db.Query(fetchStockMovementsQuery, id, limit, offset)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, nil, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		movement := entity.StockMovement{}
+
+		if err := rows.Scan(
+			&movement.Id,
+			&movement.ProductId,
+			&movement.LocationId,
+			&movement.LocationCode,
+			&movement.Type,
+			&movement.Qty,
+			&movement.ReservedQty,
+			&movement.StockAfter,
+			&movement.ReservedAfter,
+			&movement.Reason,
+			&movement.Reference,
+			&movement.ActorId,
+			&movement.ActorName,
+			&movement.CreatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, nil, 0, exception.NewInternalServerError("something went wrong")
+		}
+
+		movements = append(movements, &movement)
+	}
+
+	return movements, ledger, total, nil
+}
+
+func handleStockLevelError(err error) exception.Exception {
+
+	log.Println(err.Error())
+
+	switch err.Error() {
+	case stockBalanceError:
+		return exception.NewBadRequestError("stock can't go below the reserved units at the location")
+	case unknownLocationError:
+		return exception.NewNotFoundError("location not found")
+	}
+
+	return exception.NewInternalServerError("something went wrong")
+}
+
+// FetchIdBySku implements product_repo.ProductRepo.
+func (pg *productPg) FetchIdBySku(sku string) (int, exception.Exception) {
+
+	id := 0
+
+	if err := pg.db.QueryRow(fetchIdBySkuQuery, sku).Scan(&id); err != nil {
+
+		if err == sql.ErrNoRows {
+			return 0, exception.NewNotFoundError("product not found")
+		}
+
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return id, nil
+}
+
+// FetchBatch implements product_repo.ProductRepo.
+func (pg *productPg) FetchBatch(afterId int, limit int) ([]*entity.Product, exception.Exception) {
+
+	products := []*entity.Product{}
+
+	rows, err := pg.db.Query(fetchBatchQuery, afterId, limit)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		product, err := scanProduct(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		products = append(products, product)
+	}
+
+	if err := loadProductLabels(pg.db, products...); err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return products, nil
+}
+
+// AddImport implements product_repo.ProductRepo.
+func (pg *productPg) AddImport(productImport *entity.ProductImport) exception.Exception {
+
+	errors, err := json.Marshal(productImport.Errors)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := pg.db.QueryRow(
+		addImportQuery,
+		productImport.Status,
+		productImport.TotalRows,
+		productImport.ProcessedRows,
+		productImport.Failed,
+		errors,
+		nullableActor(productImport.CreatedBy),
+		productImport.InstanceId,
+	).Scan(&productImport.Id, &productImport.CreatedAt, &productImport.HeartbeatAt); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// ModifyImport implements product_repo.ProductRepo.
+func (pg *productPg) ModifyImport(productImport *entity.ProductImport) exception.Exception {
+
+	errors, err := json.Marshal(productImport.Errors)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := pg.db.Exec(
+		modifyImportQuery,
+		productImport.Id,
+		productImport.Status,
+		productImport.ProcessedRows,
+		productImport.Created,
+		productImport.Updated,
+		productImport.Failed,
+		errors,
+		productImport.StartedAt,
+		productImport.FinishedAt,
+	); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// HeartbeatImport implements product_repo.ProductRepo.
+func (pg *productPg) HeartbeatImport(id int, instanceId string) exception.Exception {
+
+	if _, err := pg.db.Exec(heartbeatImportQuery, id, instanceId); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FailStaleImports implements product_repo.ProductRepo.
+func (pg *productPg) FailStaleImports(staleAfter time.Duration) exception.Exception {
+
+	if _, err := pg.db.Exec(failStaleImportsQuery, staleAfter.Seconds()); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchImportById implements product_repo.ProductRepo.
+func (pg *productPg) FetchImportById(id int) (*entity.ProductImport, exception.Exception) {
+
+	productImport, errors := entity.ProductImport{}, []byte{}
+	heartbeatAt, startedAt, finishedAt := sql.NullTime{}, sql.NullTime{}, sql.NullTime{}
+
+	if err := pg.db.QueryRow(fetchImportByIdQuery, id).Scan(
+		&productImport.Id,
+		&productImport.Status,
+		&productImport.TotalRows,
+		&productImport.ProcessedRows,
+		&productImport.Created,
+		&productImport.Updated,
+		&productImport.Failed,
+		&errors,
+		&productImport.CreatedBy,
+		&productImport.InstanceId,
+		&heartbeatAt,
+		&productImport.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("product import not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := json.Unmarshal(errors, &productImport.Errors); err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	if heartbeatAt.Valid {
+		productImport.HeartbeatAt = &heartbeatAt.Time
+	}
+
+	if startedAt.Valid {
+		productImport.StartedAt = &startedAt.Time
+	}
+
+	if finishedAt.Valid {
+		productImport.FinishedAt = &finishedAt.Time
+	}
+
+	return &productImport, nil
+}
@@ -0,0 +1,254 @@
+package product_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/product/product_repo"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanProduct(row scanner) (*entity.Product, error) {
+
+	product := entity.Product{}
+	saleStartsAt, saleEndsAt := sql.NullTime{}, sql.NullTime{}
+
+	if err := row.Scan(
+		&product.Id,
+		&product.Name,
+		&product.Slug,
+		&product.Sku,
+		&product.Description,
+		&product.CategoryId,
+		&product.TaxClass,
+		&product.Price.Amount,
+		&product.CompareAtPrice.Amount,
+		&product.SalePrice.Amount,
+		&product.Price.Currency,
+		&saleStartsAt,
+		&saleEndsAt,
+		&product.WeightGrams,
+		&product.LengthCm,
+		&product.WidthCm,
+		&product.HeightCm,
+		&product.Stock,
+		&product.Reserved,
+		&product.Sold,
+		&product.ReorderThreshold,
+		&product.RatingAverage,
+		&product.ReviewCount,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	product.CompareAtPrice.Currency = product.Price.Currency
+	product.SalePrice.Currency = product.Price.Currency
+
+	if saleStartsAt.Valid {
+		product.SaleStartsAt = &saleStartsAt.Time
+	}
+
+	if saleEndsAt.Valid {
+		product.SaleEndsAt = &saleEndsAt.Time
+	}
+
+	return &product, nil
+}
+
+// productFilterConditions turns the filter into "and ..." conditions to
+// append to a query that already has a where clause, with numbered
+// placeholders matching the returned arguments.
+func productFilterConditions(filter *product_repo.ProductFilter) (string, []any) {
+
+	conditions, args := []string{}, []any{}
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Search != "" {
+		add("(name ilike $%[1]d or description ilike $%[1]d)", "%"+filter.Search+"%")
+	}
+
+	if len(filter.CategoryIds) > 0 {
+		add("category_id = any($%d)", pq.Array(filter.CategoryIds))
+	}
+
+	if filter.MinPrice > 0 {
+		add(effectivePriceColumn+" >= $%d", filter.MinPrice)
+	}
+
+	if filter.MaxPrice > 0 {
+		add(effectivePriceColumn+" <= $%d", filter.MaxPrice)
+	}
+
+	if filter.InStock {
+		conditions = append(conditions, "stock - reserved > 0")
+	}
+
+	if len(filter.Tags) > 0 {
+		add("id in (select product_id from product_tag where tag = any($%[1]d) group by product_id having count(*) = cardinality($%[1]d::varchar[]))", pq.Array(filter.Tags))
+	}
+
+	codes := []string{}
+
+	for code := range filter.Attributes {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		args = append(args, code, pq.Array(filter.Attributes[code]))
+		conditions = append(conditions, fmt.Sprintf("id in (select pa.product_id from product_attribute as pa join attribute as a on pa.attribute_id = a.id where a.code = $%d and pa.value = any($%d))", len(args)-1, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " and " + strings.Join(conditions, " and "), args
+}
+
+// nullableActor stores a missing user as null rather than id 0.
+func nullableActor(userId int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(userId), Valid: userId != 0}
+}
+
+// saveProductLabels replaces the tags and attribute values of a product,
+// values of attribute codes that aren't defined are skipped.
+func saveProductLabels(tx *sql.Tx, id int, product *entity.Product) error {
+
+	if _, err := tx.Exec(deleteProductTagsQuery, id); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(deleteProductAttributesQuery, id); err != nil {
+		return err
+	}
+
+	for _, tag := range product.Tags {
+		if _, err := tx.Exec(addProductTagQuery, id, tag); err != nil {
+			return err
+		}
+	}
+
+	for code, value := range product.Attributes {
+		if _, err := tx.Exec(addProductAttributeQuery, id, code, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadProductLabels fills in the tags and attribute values of the given
+// products with one query each.
+func loadProductLabels(db *sql.DB, products ...*entity.Product) error {
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids, byId := []int{}, map[int]*entity.Product{}
+
+	for _, product := range products {
+		product.Tags = []string{}
+		product.Attributes = map[string]string{}
+		ids = append(ids, product.Id)
+		byId[product.Id] = product
+	}
+
+	rows, err := db.Query(fetchProductTagsQuery, pq.Array(ids))
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		id, tag := 0, ""
+
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+
+		byId[id].Tags = append(byId[id].Tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	attributeRows, err := db.Query(fetchProductAttributesQuery, pq.Array(ids))
+
+	if err != nil {
+		return err
+	}
+
+	defer attributeRows.Close()
+
+	for attributeRows.Next() {
+
+		id, code, value := 0, "", ""
+
+		if err := attributeRows.Scan(&id, &code, &value); err != nil {
+			return err
+		}
+
+		byId[id].Attributes[code] = value
+	}
+
+	return attributeRows.Err()
+}
+
+// scanFacetValues reads value and count rows of a facet query.
+func scanFacetValues(rows *sql.Rows) ([]*entity.FacetValue, error) {
+
+	defer rows.Close()
+
+	values := []*entity.FacetValue{}
+
+	for rows.Next() {
+
+		value := entity.FacetValue{}
+
+		if err := rows.Scan(&value.Value, &value.Count); err != nil {
+			return nil, err
+		}
+
+		values = append(values, &value)
+	}
+
+	return values, rows.Err()
+}
+
+// productSortColumns returns the order by columns for a sort, ties are
+// broken by id so pages are stable.
+func productSortColumns(sort string) string {
+
+	switch sort {
+	case entity.ProductSortPriceAsc:
+		return effectivePriceColumn + " asc, id asc"
+	case entity.ProductSortPriceDesc:
+		return effectivePriceColumn + " desc, id desc"
+	case entity.ProductSortBestSelling:
+		return "sold desc, id desc"
+	case entity.ProductSortName:
+		return "name asc, id asc"
+	}
+
+	return "created_at desc, id desc"
+}
@@ -3,12 +3,32 @@ package product_repo
 import (
 	"fashion-api/entity"
 	"fashion-api/pkg/exception"
+	"time"
 )
 
 type ProductRepo interface {
-	Fetch() ([]*entity.Product, exception.Exception)
+	Fetch(filter *ProductFilter) ([]*entity.Product, int, exception.Exception)
 	FetchById(id int) (*entity.Product, exception.Exception)
-	Add(product *entity.Product) exception.Exception
-	Modify(id int, product *entity.Product) exception.Exception
+	FetchBySlug(slug string) (*entity.Product, exception.Exception)
+	SlugTaken(slug string, excludeId int) (bool, exception.Exception)
+	FetchSlugRedirect(slug string) (string, exception.Exception)
+	Add(product *entity.Product, changedBy int) exception.Exception
+	// Modify saves the product, with keepStock its stock is left as it is
+	// rather than set to product.Stock.
+	Modify(id int, product *entity.Product, keepStock bool, changedBy int) exception.Exception
 	Delete(id int) exception.Exception
+	FetchPriceHistory(id int) ([]*entity.ProductPriceHistory, exception.Exception)
+	AdjustStock(movement *entity.StockMovement) exception.Exception
+	FetchStockMovements(id int, limit int, offset int) ([]*entity.StockMovement, *entity.StockLedger, int, exception.Exception)
+	FetchFacets(filter *ProductFilter, attributes []*entity.Attribute) ([]*entity.Facet, exception.Exception)
+	FetchIdBySku(sku string) (int, exception.Exception)
+	FetchBatch(afterId int, limit int) ([]*entity.Product, exception.Exception)
+	AddImport(productImport *entity.ProductImport) exception.Exception
+	ModifyImport(productImport *entity.ProductImport) exception.Exception
+	FetchImportById(id int) (*entity.ProductImport, exception.Exception)
+	// HeartbeatImport reports that instanceId is still running the import.
+	HeartbeatImport(id int, instanceId string) exception.Exception
+	// FailStaleImports marks the imports whose server hasn't reported in for
+	// staleAfter as failed, the rows they applied before stay applied.
+	FailStaleImports(staleAfter time.Duration) exception.Exception
 }
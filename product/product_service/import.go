@@ -0,0 +1,711 @@
+package product_service
+
+import (
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxImportRows = 10000
+
+	// rows failing beyond maxImportErrors are still counted, only their
+	// messages are dropped
+	maxImportErrors = 500
+
+	// progress of a running import is saved every importProgressRows rows
+	importProgressRows = 50
+
+	exportBatchSize = 500
+
+	tagSeparator = "|"
+
+	attributeColumnPrefix = "attr."
+)
+
+// productColumns are the CSV columns of the product catalogue, followed by
+// one attr.<code> column per attribute. Prices are minor units of the base
+// currency and sale dates RFC 3339 timestamps.
+var productColumns = []string{
+	"sku",
+	"name",
+	"slug",
+	"description",
+	"category_id",
+	"tax_class",
+	"price",
+	"compare_at_price",
+	"sale_price",
+	"sale_starts_at",
+	"sale_ends_at",
+	"weight_grams",
+	"length_cm",
+	"width_cm",
+	"height_cm",
+	"stock",
+	"reorder_threshold",
+	"tags",
+}
+
+type importRow struct {
+	line    int
+	payload *dto.ProductPayload
+	// columns are the columns the row sets, an existing product keeps
+	// everything else. A blank stock cell counts as not set.
+	columns map[string]bool
+}
+
+// Import implements ProductService.
+func (ps *productService) Import(userId int, file io.Reader, dryRun bool) (*helper.ResponseBody, exception.Exception) {
+
+	rows, rowErrors, err := parseProductCSV(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	productImport := &entity.ProductImport{
+		Status:        entity.ProductImportQueued,
+		DryRun:        dryRun,
+		TotalRows:     len(rows) + len(rowErrors),
+		ProcessedRows: len(rowErrors),
+		Errors:        []*entity.ProductImportError{},
+		CreatedBy:     userId,
+		InstanceId:    ps.instanceId,
+	}
+
+	for _, rowError := range rowErrors {
+		addImportError(productImport, rowError)
+	}
+
+	if dryRun {
+		for _, row := range rows {
+			ps.importRow(userId, productImport, row, true)
+		}
+
+		productImport.Status = entity.ProductImportCompleted
+
+		return &helper.ResponseBody{
+			Status:  http.StatusOK,
+			Message: "product import successfully validated, nothing was saved",
+			Data:    productImport,
+		}, nil
+	}
+
+	if err := ps.pr.AddImport(productImport); err != nil {
+		return nil, err
+	}
+
+	// the import keeps changing once it runs, the response gets a copy
+	queued := *productImport
+	queued.Errors = append([]*entity.ProductImportError{}, productImport.Errors...)
+
+	go ps.runImport(userId, productImport, rows)
+
+	return &helper.ResponseBody{
+		Status:  http.StatusAccepted,
+		Message: "product import successfully queued",
+		Data:    &queued,
+	}, nil
+}
+
+// FetchImport implements ProductService.
+func (ps *productService) FetchImport(id int) (*helper.ResponseBody, exception.Exception) {
+
+	productImport, err := ps.pr.FetchImportById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "product import successfully fetched",
+		Data:    productImport,
+	}, nil
+}
+
+// Export implements ProductService. Products are written in batches and
+// flushed as they go, so an error after the first batch can only cut the
+// file short.
+func (ps *productService) Export(w http.ResponseWriter) exception.Exception {
+
+	attributes, err := ps.ar.Fetch()
+
+	if err != nil {
+		return err
+	}
+
+	products, err := ps.pr.FetchBatch(0, exportBatchSize)
+
+	if err != nil {
+		return err
+	}
+
+	header := append([]string{}, productColumns...)
+
+	for _, attribute := range attributes {
+		header = append(header, attributeColumnPrefix+attribute.Code)
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="products.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(header)
+
+	for len(products) > 0 {
+
+		for _, product := range products {
+			writer.Write(productToRecord(product, attributes))
+		}
+
+		writer.Flush()
+
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if len(products) < exportBatchSize {
+			break
+		}
+
+		if products, err = ps.pr.FetchBatch(products[len(products)-1].Id, exportBatchSize); err != nil {
+			log.Println(err.Message())
+			break
+		}
+	}
+
+	if err := writer.Error(); err != nil {
+		log.Println(err.Error())
+	}
+
+	return nil
+}
+
+// runImport applies the rows of a queued import, one import at a time.
+func (ps *productService) runImport(userId int, productImport *entity.ProductImport, rows []*importRow) {
+
+	// the heartbeat covers the wait for the lock too, a queued import is as
+	// alive as a running one
+	done := make(chan struct{})
+	defer close(done)
+
+	go ps.heartbeatImport(productImport.Id, done)
+
+	ps.im.Lock()
+	defer ps.im.Unlock()
+
+	startedAt := time.Now()
+
+	productImport.Status = entity.ProductImportRunning
+	productImport.StartedAt = &startedAt
+
+	if err := ps.pr.ModifyImport(productImport); err != nil {
+		log.Println(err.Message())
+	}
+
+	for i, row := range rows {
+
+		ps.importRow(userId, productImport, row, false)
+
+		if (i+1)%importProgressRows == 0 {
+			if err := ps.pr.ModifyImport(productImport); err != nil {
+				log.Println(err.Message())
+			}
+		}
+	}
+
+	finishedAt := time.Now()
+
+	productImport.Status = entity.ProductImportCompleted
+	productImport.FinishedAt = &finishedAt
+
+	if err := ps.pr.ModifyImport(productImport); err != nil {
+		log.Println(err.Message())
+	}
+}
+
+// heartbeatImport keeps the import from looking abandoned to the other
+// servers until done is closed.
+func (ps *productService) heartbeatImport(id int, done <-chan struct{}) {
+
+	ticker := time.NewTicker(entity.ProductImportHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := ps.pr.HeartbeatImport(id, ps.instanceId); err != nil {
+				log.Println(err.Message())
+			}
+		}
+	}
+}
+
+// newInstanceId names this server process, the host and pid make it
+// readable and the random part keeps a restarted process with a reused pid
+// apart from its predecessor.
+func newInstanceId() string {
+
+	host, err := os.Hostname()
+
+	if err != nil {
+		host = "unknown"
+	}
+
+	b := make([]byte, 4)
+
+	if _, err := rand.Read(b); err != nil {
+		log.Println(err.Error())
+	}
+
+	id := fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+
+	if len(id) > 64 {
+		id = id[len(id)-64:]
+	}
+
+	return id
+}
+
+// importRow upserts one row by its SKU and counts the outcome on the
+// import. A dry run goes through the same checks without saving.
+func (ps *productService) importRow(userId int, productImport *entity.ProductImport, row *importRow, dryRun bool) {
+
+	productImport.ProcessedRows++
+
+	payload := row.payload
+
+	fail := func(err exception.Exception) {
+		addImportError(productImport, &entity.ProductImportError{
+			Line:    row.line,
+			Sku:     payload.Sku,
+			Message: err.Message(),
+		})
+	}
+
+	id, err := ps.pr.FetchIdBySku(payload.Sku)
+
+	if err != nil && err.Status() != http.StatusNotFound {
+		fail(err)
+		return
+	}
+
+	current := &entity.Product{}
+
+	if id != 0 {
+		if current, err = ps.pr.FetchById(id); err != nil {
+			fail(err)
+			return
+		}
+
+		payload = mergeImportRow(current, row)
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		fail(err)
+		return
+	}
+
+	if dryRun {
+		if err := ps.checkImportRow(id, current.Slug, payload); err != nil {
+			fail(err)
+			return
+		}
+	} else if id == 0 {
+		if _, err := ps.Add(userId, payload); err != nil {
+			fail(err)
+			return
+		}
+	} else {
+		if _, err := ps.modify(userId, id, payload, !row.columns["stock"]); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	if id == 0 {
+		productImport.Created++
+	} else {
+		productImport.Updated++
+	}
+}
+
+// checkImportRow runs the checks Add and Modify make before saving.
+func (ps *productService) checkImportRow(id int, currentSlug string, payload *dto.ProductPayload) exception.Exception {
+
+	if err := validateProductPayload(payload); err != nil {
+		return err
+	}
+
+	if _, err := ps.cr.FetchId(payload.CategoryId); err != nil {
+		return err
+	}
+
+	if _, err := ps.productAttributes(payload.Attributes); err != nil {
+		return err
+	}
+
+	if _, err := ps.resolveSlug(id, payload.Slug, payload.Name, currentSlug); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func addImportError(productImport *entity.ProductImport, rowError *entity.ProductImportError) {
+
+	productImport.Failed++
+
+	if len(productImport.Errors) < maxImportErrors {
+		productImport.Errors = append(productImport.Errors, rowError)
+	}
+}
+
+// parseProductCSV reads the rows of a product CSV. Rows whose cells can't
+// be read are returned as errors, a file that can't be read at all fails
+// the import.
+func parseProductCSV(file io.Reader) ([]*importRow, []*entity.ProductImportError, exception.Exception) {
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+
+	if err != nil {
+		return nil, nil, exception.NewBadRequestError("the file must be a CSV with a header row")
+	}
+
+	columns := map[string]int{}
+
+	for i, column := range header {
+
+		column = strings.ToLower(strings.TrimSpace(column))
+
+		// spreadsheets tend to save CSV with a byte order mark
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+
+		if !isProductColumn(column) {
+			return nil, nil, exception.NewBadRequestError("unknown column " + column)
+		}
+
+		columns[column] = i
+	}
+
+	for _, column := range []string{"sku", "name"} {
+		if _, ok := columns[column]; !ok {
+			return nil, nil, exception.NewBadRequestError("column " + column + " is missing")
+		}
+	}
+
+	rows, rowErrors, skus := []*importRow{}, []*entity.ProductImportError{}, map[string]int{}
+
+	for {
+
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		line, _ := reader.FieldPos(0)
+
+		if err != nil {
+
+			if !errors.Is(err, csv.ErrFieldCount) {
+				return nil, nil, exception.NewBadRequestError(fmt.Sprintf("line %d can't be read: %s", line, err.Error()))
+			}
+
+			rowError := &entity.ProductImportError{Line: line, Message: "row doesn't have as many cells as the header"}
+
+			if columns["sku"] < len(record) {
+				rowError.Sku = strings.TrimSpace(record[columns["sku"]])
+			}
+
+			rowErrors = append(rowErrors, rowError)
+			continue
+		}
+
+		if len(rows)+len(rowErrors) == maxImportRows {
+			return nil, nil, exception.NewBadRequestError(fmt.Sprintf("a file can't have more than %d rows", maxImportRows))
+		}
+
+		row, err := recordToImportRow(record, columns)
+
+		if err == nil && row.payload.Sku == "" {
+			err = errors.New("sku can't be empty")
+		}
+
+		if err == nil && skus[row.payload.Sku] != 0 {
+			err = fmt.Errorf("sku is already used on line %d", skus[row.payload.Sku])
+		}
+
+		if err != nil {
+			rowErrors = append(rowErrors, &entity.ProductImportError{Line: line, Sku: strings.TrimSpace(record[columns["sku"]]), Message: err.Error()})
+			continue
+		}
+
+		row.line = line
+		skus[row.payload.Sku] = line
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func isProductColumn(column string) bool {
+
+	if strings.HasPrefix(column, attributeColumnPrefix) {
+		return len(column) > len(attributeColumnPrefix)
+	}
+
+	for _, productColumn := range productColumns {
+		if column == productColumn {
+			return true
+		}
+	}
+
+	return false
+}
+
+func recordToImportRow(record []string, columns map[string]int) (*importRow, error) {
+
+	cell := func(column string) string {
+		if i, ok := columns[column]; ok {
+			return strings.TrimSpace(record[i])
+		}
+
+		return ""
+	}
+
+	number := func(column string, into *int) error {
+
+		if cell(column) == "" {
+			return nil
+		}
+
+		value, err := strconv.Atoi(cell(column))
+
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", column)
+		}
+
+		*into = value
+
+		return nil
+	}
+
+	timestamp := func(column string, into **time.Time) error {
+
+		if cell(column) == "" {
+			return nil
+		}
+
+		value, err := time.Parse(time.RFC3339, cell(column))
+
+		if err != nil {
+			return fmt.Errorf("%s must be an RFC 3339 timestamp", column)
+		}
+
+		*into = &value
+
+		return nil
+	}
+
+	payload := &dto.ProductPayload{
+		Sku:         cell("sku"),
+		Name:        cell("name"),
+		Slug:        cell("slug"),
+		Description: cell("description"),
+		TaxClass:    cell("tax_class"),
+		Tags:        []string{},
+		Attributes:  map[string]string{},
+	}
+
+	for _, err := range []error{
+		number("category_id", &payload.CategoryId),
+		number("price", &payload.Price),
+		number("compare_at_price", &payload.CompareAtPrice),
+		number("sale_price", &payload.SalePrice),
+		timestamp("sale_starts_at", &payload.SaleStartsAt),
+		timestamp("sale_ends_at", &payload.SaleEndsAt),
+		number("weight_grams", &payload.WeightGrams),
+		number("length_cm", &payload.LengthCm),
+		number("width_cm", &payload.WidthCm),
+		number("height_cm", &payload.HeightCm),
+		number("stock", &payload.Stock),
+		number("reorder_threshold", &payload.ReorderThreshold),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tags := cell("tags"); tags != "" {
+		payload.Tags = strings.Split(tags, tagSeparator)
+	}
+
+	for column := range columns {
+		if code, ok := strings.CutPrefix(column, attributeColumnPrefix); ok && cell(column) != "" {
+			payload.Attributes[code] = cell(column)
+		}
+	}
+
+	present := map[string]bool{}
+
+	for column := range columns {
+		present[column] = column != "stock" || cell(column) != ""
+	}
+
+	return &importRow{
+		payload: payload,
+		columns: present,
+	}, nil
+}
+
+// mergeImportRow lays the columns the row sets over the current product, so
+// a file with only some columns doesn't wipe the others.
+func mergeImportRow(current *entity.Product, row *importRow) *dto.ProductPayload {
+
+	// the current stock only gets the payload through validation, it isn't
+	// saved unless the row sets the stock
+	merged := &dto.ProductPayload{
+		Sku:              current.Sku,
+		Name:             current.Name,
+		Slug:             current.Slug,
+		Description:      current.Description,
+		CategoryId:       current.CategoryId,
+		TaxClass:         current.TaxClass,
+		Price:            int(current.Price.Amount),
+		CompareAtPrice:   int(current.CompareAtPrice.Amount),
+		SalePrice:        int(current.SalePrice.Amount),
+		SaleStartsAt:     current.SaleStartsAt,
+		SaleEndsAt:       current.SaleEndsAt,
+		WeightGrams:      current.WeightGrams,
+		LengthCm:         current.LengthCm,
+		WidthCm:          current.WidthCm,
+		HeightCm:         current.HeightCm,
+		Stock:            current.Stock,
+		ReorderThreshold: current.ReorderThreshold,
+		Tags:             append([]string{}, current.Tags...),
+		Attributes:       map[string]string{},
+	}
+
+	for code, value := range current.Attributes {
+		merged.Attributes[code] = value
+	}
+
+	payload := row.payload
+
+	for column := range row.columns {
+
+		if !row.columns[column] {
+			continue
+		}
+
+		switch column {
+		case "name":
+			merged.Name = payload.Name
+		case "slug":
+			merged.Slug = payload.Slug
+		case "description":
+			merged.Description = payload.Description
+		case "category_id":
+			merged.CategoryId = payload.CategoryId
+		case "tax_class":
+			merged.TaxClass = payload.TaxClass
+		case "price":
+			merged.Price = payload.Price
+		case "compare_at_price":
+			merged.CompareAtPrice = payload.CompareAtPrice
+		case "sale_price":
+			merged.SalePrice = payload.SalePrice
+		case "sale_starts_at":
+			merged.SaleStartsAt = payload.SaleStartsAt
+		case "sale_ends_at":
+			merged.SaleEndsAt = payload.SaleEndsAt
+		case "weight_grams":
+			merged.WeightGrams = payload.WeightGrams
+		case "length_cm":
+			merged.LengthCm = payload.LengthCm
+		case "width_cm":
+			merged.WidthCm = payload.WidthCm
+		case "height_cm":
+			merged.HeightCm = payload.HeightCm
+		case "stock":
+			merged.Stock = payload.Stock
+		case "reorder_threshold":
+			merged.ReorderThreshold = payload.ReorderThreshold
+		case "tags":
+			merged.Tags = payload.Tags
+		default:
+			// a blank attribute cell clears the attribute
+			if code, ok := strings.CutPrefix(column, attributeColumnPrefix); ok {
+				if value, ok := payload.Attributes[code]; ok {
+					merged.Attributes[code] = value
+				} else {
+					delete(merged.Attributes, code)
+				}
+			}
+		}
+	}
+
+	return merged
+}
+
+func productToRecord(product *entity.Product, attributes []*entity.Attribute) []string {
+
+	timestamp := func(value *time.Time) string {
+		if value == nil {
+			return ""
+		}
+
+		return value.Format(time.RFC3339)
+	}
+
+	record := []string{
+		product.Sku,
+		product.Name,
+		product.Slug,
+		product.Description,
+		strconv.Itoa(product.CategoryId),
+		product.TaxClass,
+		strconv.FormatInt(product.Price.Amount, 10),
+		strconv.FormatInt(product.CompareAtPrice.Amount, 10),
+		strconv.FormatInt(product.SalePrice.Amount, 10),
+		timestamp(product.SaleStartsAt),
+		timestamp(product.SaleEndsAt),
+		strconv.Itoa(product.WeightGrams),
+		strconv.Itoa(product.LengthCm),
+		strconv.Itoa(product.WidthCm),
+		strconv.Itoa(product.HeightCm),
+		strconv.Itoa(product.Stock),
+		strconv.Itoa(product.ReorderThreshold),
+		strings.Join(product.Tags, tagSeparator),
+	}
+
+	for _, attribute := range attributes {
+		record = append(record, product.Attributes[attribute.Code])
+	}
+
+	return record
+}
@@ -1,62 +1,106 @@
 package product_service
 
 import (
+	"fashion-api/attribute/attribute_repo"
 	"fashion-api/category/category_repo"
+	"fashion-api/currency/currency_service"
 	"fashion-api/dto"
 	"fashion-api/entity"
+	"fashion-api/infra/config"
 	"fashion-api/pkg/exception"
 	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"fashion-api/pkg/slug"
 	"fashion-api/product/product_repo"
+	"fashion-api/wishlist/wishlist_job"
+	"io"
+	"strings"
 	"sync"
 
 	"net/http"
 )
 
+const (
+	// maxTagLength matches the product_tag column
+	maxTagLength = 50
+
+	// maxSkuLength matches the product sku column
+	maxSkuLength = 64
+)
+
 type productService struct {
 	pr product_repo.ProductRepo
 	cr category_repo.CategoryRepo
+	cs currency_service.CurrencyService
+	ar attribute_repo.AttributeRepo
+	bj wishlist_job.BackInStockJob
 	wg *sync.WaitGroup
+	// im runs one product import at a time, the others wait queued
+	im sync.Mutex
+	// instanceId tells the imports of this server apart from those of the
+	// other servers sharing the database
+	instanceId string
 }
 
 type ProductService interface {
-	Fetch() (*helper.ResponseBody, exception.Exception)
-	FetchById(id int) (*helper.ResponseBody, exception.Exception)
-	Add(payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception)
-	Modify(id int, payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception)
+	Fetch(currency string, query *dto.ProductQuery) (*helper.ResponseBody, exception.Exception)
+	FetchPage(currency string, query *dto.ProductQuery) (*dto.ProductPage, exception.Exception)
+	FetchById(id int, currency string) (*helper.ResponseBody, exception.Exception)
+	FetchBySlug(slug string, currency string) (*helper.ResponseBody, exception.Exception)
+	Add(userId int, payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception)
+	Modify(userId int, id int, payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception)
 	Delete(id int) (*helper.ResponseBody, exception.Exception)
+	FetchPriceHistory(id int) (*helper.ResponseBody, exception.Exception)
+	AdjustStock(userId int, id int, payload *dto.StockAdjustmentPayload) (*helper.ResponseBody, exception.Exception)
+	FetchStockMovements(id int, page int, limit int) (*helper.ResponseBody, exception.Exception)
+	Import(userId int, file io.Reader, dryRun bool) (*helper.ResponseBody, exception.Exception)
+	FetchImport(id int) (*helper.ResponseBody, exception.Exception)
+	Export(w http.ResponseWriter) exception.Exception
 }
 
-func NewProductService(pr product_repo.ProductRepo, cr category_repo.CategoryRepo, wg *sync.WaitGroup) ProductService {
+func NewProductService(pr product_repo.ProductRepo, cr category_repo.CategoryRepo, cs currency_service.CurrencyService, ar attribute_repo.AttributeRepo, bj wishlist_job.BackInStockJob, wg *sync.WaitGroup) ProductService {
 	return &productService{
-		pr: pr,
-		cr: cr,
-		wg: wg,
+		pr:         pr,
+		cr:         cr,
+		cs:         cs,
+		ar:         ar,
+		bj:         bj,
+		wg:         wg,
+		instanceId: newInstanceId(),
 	}
 }
 
 // Add implements ProductService.
-func (ps *productService) Add(payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception) {
+func (ps *productService) Add(userId int, payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception) {
 
 	errCh := make(chan exception.Exception, 1)
 
+	if err := validateProductPayload(payload); err != nil {
+		return nil, err
+	}
+
 	_, err := ps.cr.FetchId(payload.CategoryId)
 
 	if err != nil {
 		return nil, err
 	}
-	
+
+	product := payloadToProduct(payload)
+
+	if product.Attributes, err = ps.productAttributes(payload.Attributes); err != nil {
+		return nil, err
+	}
+
+	if product.Slug, err = ps.resolveSlug(0, payload.Slug, payload.Name, ""); err != nil {
+		return nil, err
+	}
+
 	ps.wg.Add(1)
 
 	go func() {
 		defer ps.wg.Done()
 
-		if err := ps.pr.Add(&entity.Product{
-			Name:        payload.Name,
-			Description: payload.Description,
-			CategoryId:  payload.CategoryId,
-			Price:       payload.Price,
-			Stock:       payload.Stock,
-		}); err != nil {
+		if err := ps.pr.Add(product, userId); err != nil {
 			errCh <- err
 			return
 		}
@@ -79,7 +123,7 @@ func (ps *productService) Add(payload *dto.ProductPayload) (*helper.ResponseBody
 // Delete implements ProductService.
 func (ps *productService) Delete(id int) (*helper.ResponseBody, exception.Exception) {
 
-	if _, err := ps.FetchById(id); err != nil {
+	if _, err := ps.pr.FetchById(id); err != nil {
 		return nil, err
 	}
 
@@ -95,9 +139,9 @@ func (ps *productService) Delete(id int) (*helper.ResponseBody, exception.Except
 }
 
 // Fetch implements ProductService.
-func (ps *productService) Fetch() (*helper.ResponseBody, exception.Exception) {
+func (ps *productService) Fetch(currency string, query *dto.ProductQuery) (*helper.ResponseBody, exception.Exception) {
 
-	products, err := ps.pr.Fetch()
+	page, err := ps.FetchPage(currency, query)
 
 	if err != nil {
 		return nil, err
@@ -106,12 +150,110 @@ func (ps *productService) Fetch() (*helper.ResponseBody, exception.Exception) {
 	return &helper.ResponseBody{
 		Status:  http.StatusOK,
 		Message: "products successfully fetched",
-		Data:    products,
+		Data:    page,
+	}, nil
+}
+
+// FetchPage implements ProductService.
+func (ps *productService) FetchPage(currency string, query *dto.ProductQuery) (*dto.ProductPage, exception.Exception) {
+
+	filter := &product_repo.ProductFilter{
+		Search:     query.Search,
+		InStock:    query.InStock,
+		Tags:       entity.NormalizeTags(query.Tags),
+		Attributes: map[string][]string{},
+		Sort:       query.Sort,
+		Limit:      query.Limit,
+		Offset:     (query.Page - 1) * query.Limit,
+	}
+
+	attributes, err := ps.ar.Fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := filterAttributes(filter, attributes, query.Attributes); err != nil {
+		return nil, err
+	}
+
+	// a category lists the products of its subcategories as well
+	if query.CategoryId != 0 {
+
+		if _, err := ps.cr.FetchId(query.CategoryId); err != nil {
+			return nil, err
+		}
+
+		descendantIds, err := ps.cr.FetchDescendantIds(query.CategoryId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		filter.CategoryIds = append([]int{query.CategoryId}, descendantIds...)
+	}
+
+	// price bounds come in the display currency while the catalogue is
+	// priced in the base currency
+	base := config.NewAppConfig().BaseCurrency
+
+	bounds := []struct {
+		value  int64
+		target *int64
+	}{
+		{query.MinPrice, &filter.MinPrice},
+		{query.MaxPrice, &filter.MaxPrice},
+	}
+
+	for _, bound := range bounds {
+
+		if bound.value == 0 || currency == "" || currency == base {
+			*bound.target = bound.value
+			continue
+		}
+
+		converted, err := ps.cs.Convert(money.New(bound.value, currency), base)
+
+		if err != nil {
+			return nil, err
+		}
+
+		*bound.target = converted.Amount
+	}
+
+	products, total, err := ps.pr.Fetch(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.cs.LocalizeAll(products, currency); err != nil {
+		return nil, err
+	}
+
+	filterable := []*entity.Attribute{}
+
+	for _, attribute := range attributes {
+		if attribute.Filterable {
+			filterable = append(filterable, attribute)
+		}
+	}
+
+	facets, err := ps.pr.FetchFacets(filter, filterable)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ProductPage{
+		Products:   products,
+		Facets:     facets,
+		Pagination: dto.NewPageInfo(query.Page, query.Limit, total),
 	}, nil
 }
 
 // FetchById implements ProductService.
-func (ps *productService) FetchById(id int) (*helper.ResponseBody, exception.Exception) {
+func (ps *productService) FetchById(id int, currency string) (*helper.ResponseBody, exception.Exception) {
 
 	product, err := ps.pr.FetchById(id)
 
@@ -119,25 +261,99 @@ func (ps *productService) FetchById(id int) (*helper.ResponseBody, exception.Exc
 		return nil, err
 	}
 
+	if err := ps.cs.Localize(product, currency); err != nil {
+		return nil, err
+	}
+
 	return &helper.ResponseBody{
 		Status:  http.StatusOK,
 		Message: "product with id successfully fetched",
-		Data: &dto.ProductData{
-			Id:          product.Id,
-			Name:        product.Name,
-			Description: product.Description,
-			CategoryId:  product.CategoryId,
-			Price:       product.Price,
-			Stock:       product.Stock,
-			Sold:        product.Sold,
-			CreatedAt:   product.CreatedAt,
-			UpdatedAt:   product.UpdatedAt,
-		},
+		Data:    productToData(product),
+	}, nil
+}
+
+// FetchBySlug implements ProductService.
+func (ps *productService) FetchBySlug(productSlug string, currency string) (*helper.ResponseBody, exception.Exception) {
+
+	product, err := ps.pr.FetchBySlug(productSlug)
+
+	if err != nil {
+
+		if err.Status() != http.StatusNotFound {
+			return nil, err
+		}
+
+		// a slug that has since been changed points to the current one
+		current, redirectErr := ps.pr.FetchSlugRedirect(productSlug)
+
+		if redirectErr != nil {
+			return nil, redirectErr
+		}
+
+		return &helper.ResponseBody{
+			Status:  http.StatusMovedPermanently,
+			Message: "product has moved",
+			Data: &dto.SlugRedirectData{
+				Slug:     current,
+				Location: "/products/slug/" + current,
+			},
+		}, nil
+	}
+
+	if err := ps.cs.Localize(product, currency); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "product with slug successfully fetched",
+		Data:    productToData(product),
 	}, nil
 }
 
+func productToData(product *entity.Product) *dto.ProductData {
+	return &dto.ProductData{
+		Id:               product.Id,
+		Name:             product.Name,
+		Slug:             product.Slug,
+		Sku:              product.Sku,
+		Description:      product.Description,
+		CategoryId:       product.CategoryId,
+		TaxClass:         product.TaxClass,
+		Price:            product.Price,
+		CompareAtPrice:   product.CompareAtPrice,
+		SalePrice:        product.SalePrice,
+		SaleStartsAt:     product.SaleStartsAt,
+		SaleEndsAt:       product.SaleEndsAt,
+		EffectivePrice:   product.EffectivePrice,
+		WeightGrams:      product.WeightGrams,
+		LengthCm:         product.LengthCm,
+		WidthCm:          product.WidthCm,
+		HeightCm:         product.HeightCm,
+		Stock:            product.Stock,
+		Sold:             product.Sold,
+		ReorderThreshold: product.ReorderThreshold,
+		RatingAverage:    product.RatingAverage,
+		ReviewCount:      product.ReviewCount,
+		Tags:             product.Tags,
+		Attributes:       product.Attributes,
+		CreatedAt:        product.CreatedAt,
+		UpdatedAt:        product.UpdatedAt,
+	}
+}
+
 // Modify implements ProductService.
-func (ps *productService) Modify(id int, payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception) {
+func (ps *productService) Modify(userId int, id int, payload *dto.ProductPayload) (*helper.ResponseBody, exception.Exception) {
+	return ps.modify(userId, id, payload, false)
+}
+
+// modify saves the product, with keepStock the stock in the payload is
+// ignored so sales made since it was read aren't undone.
+func (ps *productService) modify(userId int, id int, payload *dto.ProductPayload, keepStock bool) (*helper.ResponseBody, exception.Exception) {
+
+	if err := validateProductPayload(payload); err != nil {
+		return nil, err
+	}
 
 	_, err := ps.cr.FetchById(payload.CategoryId)
 
@@ -145,23 +361,266 @@ func (ps *productService) Modify(id int, payload *dto.ProductPayload) (*helper.R
 		return nil, err
 	}
 
-	if _, err := ps.FetchById(id); err != nil {
+	current, err := ps.pr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	product := payloadToProduct(payload)
+
+	if product.Attributes, err = ps.productAttributes(payload.Attributes); err != nil {
+		return nil, err
+	}
+
+	if product.Slug, err = ps.resolveSlug(id, payload.Slug, payload.Name, current.Slug); err != nil {
 		return nil, err
 	}
 
-	if err := ps.pr.Modify(id, &entity.Product{
-		Name:        payload.Name,
-		Description: payload.Description,
-		CategoryId:  payload.CategoryId,
-		Price:       payload.Price,
-		Stock:       payload.Stock,
-	}); err != nil {
+	if err := ps.pr.Modify(id, product, keepStock, userId); err != nil {
 		return nil, err
 	}
 
+	if !keepStock && product.Stock > current.Stock {
+		ps.bj.Queue(id)
+	}
+
 	return &helper.ResponseBody{
 		Status:  http.StatusOK,
 		Message: "product successfully modified",
 		Data:    nil,
 	}, nil
 }
+
+// FetchPriceHistory implements ProductService.
+func (ps *productService) FetchPriceHistory(id int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ps.pr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	histories, err := ps.pr.FetchPriceHistory(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "price history successfully fetched",
+		Data:    histories,
+	}, nil
+}
+
+// AdjustStock implements ProductService.
+func (ps *productService) AdjustStock(userId int, id int, payload *dto.StockAdjustmentPayload) (*helper.ResponseBody, exception.Exception) {
+
+	movementType := payload.Type
+
+	if movementType == "" {
+		movementType = entity.StockMovementAdjustment
+	}
+
+	if movementType == entity.StockMovementRestock && payload.Qty < 0 {
+		return nil, exception.NewBadRequestError("a restock must add stock")
+	}
+
+	if _, err := ps.pr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	movement := &entity.StockMovement{
+		ProductId:  id,
+		LocationId: payload.LocationId,
+		Type:       movementType,
+		Qty:        payload.Qty,
+		Reason:     strings.TrimSpace(payload.Reason),
+		ActorId:    userId,
+	}
+
+	if err := ps.pr.AdjustStock(movement); err != nil {
+		return nil, err
+	}
+
+	if movement.Qty > 0 {
+		ps.bj.Queue(id)
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "stock successfully adjusted",
+		Data:    nil,
+	}, nil
+}
+
+// FetchStockMovements implements ProductService.
+func (ps *productService) FetchStockMovements(id int, page int, limit int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ps.pr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	movements, ledger, total, err := ps.pr.FetchStockMovements(id, limit, (page-1)*limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "stock movements successfully fetched",
+		Data: &dto.StockMovementPage{
+			StockLedger: ledger,
+			Movements:   movements,
+			Pagination:  dto.NewPageInfo(page, limit, total),
+		},
+	}, nil
+}
+
+func (ps *productService) resolveSlug(id int, requested string, name string, current string) (string, exception.Exception) {
+	return slug.Resolve(requested, name, current, "product", func(candidate string) (bool, exception.Exception) {
+		return ps.pr.SlugTaken(candidate, id)
+	})
+}
+
+// productAttributes checks the attribute values of a payload against their
+// definitions and returns them normalized.
+func (ps *productService) productAttributes(values map[string]string) (map[string]string, exception.Exception) {
+
+	attributes := map[string]string{}
+
+	if len(values) == 0 {
+		return attributes, nil
+	}
+
+	definitions, err := ps.ar.Fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := map[string]*entity.Attribute{}
+
+	for _, definition := range definitions {
+		byCode[definition.Code] = definition
+	}
+
+	for code, value := range values {
+
+		definition, ok := byCode[code]
+
+		if !ok {
+			return nil, exception.NewBadRequestError("attribute " + code + " doesn't exist")
+		}
+
+		normalized, ok := definition.Normalize(value)
+
+		if !ok {
+			return nil, exception.NewBadRequestError("invalid value for attribute " + code)
+		}
+
+		attributes[code] = normalized
+	}
+
+	return attributes, nil
+}
+
+// filterAttributes adds the attribute values a listing is filtered by to
+// the filter, in the form they are stored in.
+func filterAttributes(filter *product_repo.ProductFilter, definitions []*entity.Attribute, values map[string][]string) exception.Exception {
+
+	byCode := map[string]*entity.Attribute{}
+
+	for _, definition := range definitions {
+		byCode[definition.Code] = definition
+	}
+
+	for code, options := range values {
+
+		definition, ok := byCode[code]
+
+		if !ok {
+			return exception.NewBadRequestError("attribute " + code + " doesn't exist")
+		}
+
+		for _, option := range options {
+
+			normalized, ok := definition.Normalize(option)
+
+			if !ok {
+				return exception.NewBadRequestError("invalid value for attribute " + code)
+			}
+
+			filter.Attributes[code] = append(filter.Attributes[code], normalized)
+		}
+	}
+
+	return nil
+}
+
+func validateProductPayload(payload *dto.ProductPayload) exception.Exception {
+
+	if payload.Price < 0 || payload.CompareAtPrice < 0 || payload.SalePrice < 0 {
+		return exception.NewBadRequestError("prices can't be negative")
+	}
+
+	if payload.SalePrice >= payload.Price && payload.SalePrice != 0 {
+		return exception.NewBadRequestError("sale price must be lower than price")
+	}
+
+	if payload.SaleStartsAt != nil && payload.SaleEndsAt != nil && !payload.SaleEndsAt.After(*payload.SaleStartsAt) {
+		return exception.NewBadRequestError("sale end must be after sale start")
+	}
+
+	if payload.WeightGrams < 0 || payload.LengthCm < 0 || payload.WidthCm < 0 || payload.HeightCm < 0 {
+		return exception.NewBadRequestError("weight and dimensions can't be negative")
+	}
+
+	if len(strings.TrimSpace(payload.Sku)) > maxSkuLength {
+		return exception.NewBadRequestError("sku can't be longer than 64 characters")
+	}
+
+	if payload.ReorderThreshold < 0 {
+		return exception.NewBadRequestError("reorder threshold can't be negative")
+	}
+
+	for _, tag := range payload.Tags {
+		if len(strings.TrimSpace(tag)) > maxTagLength {
+			return exception.NewBadRequestError("tags can't be longer than 50 characters")
+		}
+	}
+
+	return nil
+}
+
+// payloadToProduct reads prices in minor units of the base currency, other
+// markets are priced through exchange rates or product price lists.
+func payloadToProduct(payload *dto.ProductPayload) *entity.Product {
+
+	currency := config.NewAppConfig().BaseCurrency
+	taxClass := strings.ToLower(strings.TrimSpace(payload.TaxClass))
+
+	if taxClass == "" {
+		taxClass = entity.TaxClassStandard
+	}
+
+	return &entity.Product{
+		Name:             payload.Name,
+		Sku:              strings.TrimSpace(payload.Sku),
+		Description:      payload.Description,
+		CategoryId:       payload.CategoryId,
+		TaxClass:         taxClass,
+		Price:            money.New(int64(payload.Price), currency),
+		CompareAtPrice:   money.New(int64(payload.CompareAtPrice), currency),
+		SalePrice:        money.New(int64(payload.SalePrice), currency),
+		SaleStartsAt:     payload.SaleStartsAt,
+		SaleEndsAt:       payload.SaleEndsAt,
+		WeightGrams:      payload.WeightGrams,
+		LengthCm:         payload.LengthCm,
+		WidthCm:          payload.WidthCm,
+		HeightCm:         payload.HeightCm,
+		Stock:            payload.Stock,
+		ReorderThreshold: payload.ReorderThreshold,
+		Tags:             entity.NormalizeTags(payload.Tags),
+	}
+}
@@ -0,0 +1,191 @@
+package promotion_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/promotion/promotion_repo"
+	"log"
+	"strings"
+)
+
+type promotionPg struct {
+	db *sql.DB
+}
+
+const (
+	promotionColumns = `id, name, code, type, value, buy_qty, get_qty, min_spend, usage_limit, per_user_limit, category_id, product_id, starts_at, ends_at, active, currency, created_at, updated_at`
+
+	addPromotionQuery = `insert into promotion (name, code, type, value, buy_qty, get_qty, min_spend, usage_limit, per_user_limit, category_id, product_id, starts_at, ends_at, active, currency) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
+
+	fetchPromotionsQuery = `select ` + promotionColumns + ` from promotion where deleted_at is null order by created_at desc`
+
+	fetchPromotionByIdQuery = `select ` + promotionColumns + ` from promotion where id = $1 and deleted_at is null`
+
+	fetchPromotionByCodeQuery = `select ` + promotionColumns + ` from promotion where code = $1 and deleted_at is null`
+
+	fetchAutomaticPromotionsQuery = `select ` + promotionColumns + ` from promotion where code is null and active and deleted_at is null`
+
+	modifyPromotionQuery = `update promotion set name = $2, code = $3, type = $4, value = $5, buy_qty = $6, get_qty = $7, min_spend = $8, usage_limit = $9, per_user_limit = $10, category_id = $11, product_id = $12, starts_at = $13, ends_at = $14, active = $15, currency = $16, updated_at = now() where id = $1`
+
+	deletePromotionQuery = `update promotion set active = false, deleted_at = now(), updated_at = now() where id = $1`
+
+	countRedemptionsQuery = `select count(*), count(*) filter (where r.user_id = $2) from promotion_redemption as r join transaction as t on r.transaction_id = t.id where r.promotion_id = $1 and t.status not in ('failed', 'cancelled')`
+)
+
+func NewPromotionPg(db *sql.DB) promotion_repo.PromotionRepo {
+	return &promotionPg{
+		db: db,
+	}
+}
+
+// Add implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) Add(promotion *entity.Promotion) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(addPromotionQuery, promotionArgs(promotion)...); err != nil {
+		tx.Rollback()
+		return handlePromotionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Fetch implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) Fetch() ([]*entity.Promotion, exception.Exception) {
+	return pg.fetchMany(fetchPromotionsQuery)
+}
+
+// FetchAutomatic implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) FetchAutomatic() ([]*entity.Promotion, exception.Exception) {
+	return pg.fetchMany(fetchAutomaticPromotionsQuery)
+}
+
+// FetchById implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) FetchById(id int) (*entity.Promotion, exception.Exception) {
+	return pg.fetchOne(fetchPromotionByIdQuery, id)
+}
+
+// FetchByCode implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) FetchByCode(code string) (*entity.Promotion, exception.Exception) {
+	return pg.fetchOne(fetchPromotionByCodeQuery, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// Modify implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) Modify(id int, promotion *entity.Promotion) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(modifyPromotionQuery, append([]any{id}, promotionArgs(promotion)...)...); err != nil {
+		tx.Rollback()
+		return handlePromotionError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Delete implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) Delete(id int) exception.Exception {
+
+	if _, err := pg.db.Exec(deletePromotionQuery, id); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// CountRedemptions implements promotion_repo.PromotionRepo.
+func (pg *promotionPg) CountRedemptions(promotionId int, userId int) (int, int, exception.Exception) {
+
+	total, byUser := 0, 0
+
+	if err := pg.db.QueryRow(countRedemptionsQuery, promotionId, userId).Scan(&total, &byUser); err != nil {
+		log.Println(err.Error())
+		return 0, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return total, byUser, nil
+}
+
+func (pg *promotionPg) fetchOne(query string, arg any) (*entity.Promotion, exception.Exception) {
+
+	promotion, err := scanPromotion(pg.db.QueryRow(query, arg))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("promotion not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return promotion, nil
+}
+
+func (pg *promotionPg) fetchMany(query string) ([]*entity.Promotion, exception.Exception) {
+
+	promotions := []*entity.Promotion{}
+
+	rows, err := pg.db.Query(query)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		promotion, err := scanPromotion(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		promotions = append(promotions, promotion)
+	}
+
+	return promotions, nil
+}
+
+func handlePromotionError(err error) exception.Exception {
+
+	if strings.Contains(err.Error(), `unique constraint "promotion_code_key"`) {
+		log.Println(err.Error())
+		return exception.NewConflictError("code has been used")
+	}
+
+	log.Println(err.Error())
+	return exception.NewInternalServerError("something went wrong")
+}
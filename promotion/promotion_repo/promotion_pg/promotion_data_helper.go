@@ -0,0 +1,111 @@
+package promotion_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"time"
+)
+
+type promotionData struct {
+	Id           int
+	Name         string
+	Code         sql.NullString
+	Type         string
+	Value        int
+	BuyQty       int
+	GetQty       int
+	MinSpend     int
+	UsageLimit   int
+	PerUserLimit int
+	CategoryId   sql.NullInt64
+	ProductId    sql.NullInt64
+	StartsAt     sql.NullTime
+	EndsAt       sql.NullTime
+	Active       bool
+	Currency     string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPromotion(row scanner) (*entity.Promotion, error) {
+
+	data := promotionData{}
+
+	if err := row.Scan(
+		&data.Id,
+		&data.Name,
+		&data.Code,
+		&data.Type,
+		&data.Value,
+		&data.BuyQty,
+		&data.GetQty,
+		&data.MinSpend,
+		&data.UsageLimit,
+		&data.PerUserLimit,
+		&data.CategoryId,
+		&data.ProductId,
+		&data.StartsAt,
+		&data.EndsAt,
+		&data.Active,
+		&data.Currency,
+		&data.CreatedAt,
+		&data.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	promotion := &entity.Promotion{
+		Id:           data.Id,
+		Name:         data.Name,
+		Code:         data.Code.String,
+		Type:         data.Type,
+		Currency:     data.Currency,
+		Value:        data.Value,
+		BuyQty:       data.BuyQty,
+		GetQty:       data.GetQty,
+		MinSpend:     data.MinSpend,
+		UsageLimit:   data.UsageLimit,
+		PerUserLimit: data.PerUserLimit,
+		CategoryId:   int(data.CategoryId.Int64),
+		ProductId:    int(data.ProductId.Int64),
+		Active:       data.Active,
+		CreatedAt:    data.CreatedAt,
+		UpdatedAt:    data.UpdatedAt,
+	}
+
+	if data.StartsAt.Valid {
+		promotion.StartsAt = &data.StartsAt.Time
+	}
+
+	if data.EndsAt.Valid {
+		promotion.EndsAt = &data.EndsAt.Time
+	}
+
+	return promotion, nil
+}
+
+// promotionArgs returns the insert and update arguments, turning the zero
+// values the entity uses for "not set" back into SQL nulls.
+func promotionArgs(promotion *entity.Promotion) []any {
+	return []any{
+		promotion.Name,
+		sql.NullString{String: promotion.Code, Valid: promotion.Code != ""},
+		promotion.Type,
+		promotion.Value,
+		promotion.BuyQty,
+		promotion.GetQty,
+		promotion.MinSpend,
+		promotion.UsageLimit,
+		promotion.PerUserLimit,
+		sql.NullInt64{Int64: int64(promotion.CategoryId), Valid: promotion.CategoryId != 0},
+		sql.NullInt64{Int64: int64(promotion.ProductId), Valid: promotion.ProductId != 0},
+		promotion.StartsAt,
+		promotion.EndsAt,
+		promotion.Active,
+		promotion.Currency,
+	}
+}
@@ -0,0 +1,17 @@
+package promotion_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type PromotionRepo interface {
+	Add(promotion *entity.Promotion) exception.Exception
+	Fetch() ([]*entity.Promotion, exception.Exception)
+	FetchById(id int) (*entity.Promotion, exception.Exception)
+	FetchByCode(code string) (*entity.Promotion, exception.Exception)
+	FetchAutomatic() ([]*entity.Promotion, exception.Exception)
+	Modify(id int, promotion *entity.Promotion) exception.Exception
+	Delete(id int) exception.Exception
+	CountRedemptions(promotionId int, userId int) (int, int, exception.Exception)
+}
@@ -0,0 +1,338 @@
+package promotion_service
+
+import (
+	"fashion-api/category/category_repo"
+	"fashion-api/currency/currency_service"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/infra/config"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"fashion-api/product/product_repo"
+	"fashion-api/promotion/promotion_repo"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type promotionService struct {
+	pmr promotion_repo.PromotionRepo
+	cr  category_repo.CategoryRepo
+	pr  product_repo.ProductRepo
+	cs  currency_service.CurrencyService
+}
+
+type PromotionService interface {
+	Add(payload *dto.PromotionPayload) (*helper.ResponseBody, exception.Exception)
+	Fetch() (*helper.ResponseBody, exception.Exception)
+	FetchById(id int) (*helper.ResponseBody, exception.Exception)
+	Modify(id int, payload *dto.PromotionPayload) (*helper.ResponseBody, exception.Exception)
+	Delete(id int) (*helper.ResponseBody, exception.Exception)
+	Apply(userId int, couponCode string, transaction *entity.Transaction) exception.Exception
+}
+
+func NewPromotionService(pmr promotion_repo.PromotionRepo, cr category_repo.CategoryRepo, pr product_repo.ProductRepo, cs currency_service.CurrencyService) PromotionService {
+	return &promotionService{
+		pmr: pmr,
+		cr:  cr,
+		pr:  pr,
+		cs:  cs,
+	}
+}
+
+// Add implements PromotionService.
+func (ps *promotionService) Add(payload *dto.PromotionPayload) (*helper.ResponseBody, exception.Exception) {
+
+	promotion, err := ps.payloadToPromotion(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.pmr.Add(promotion); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "promotion successfully added",
+		Data:    nil,
+	}, nil
+}
+
+// Fetch implements PromotionService.
+func (ps *promotionService) Fetch() (*helper.ResponseBody, exception.Exception) {
+
+	promotions, err := ps.pmr.Fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "promotions successfully fetched",
+		Data:    promotions,
+	}, nil
+}
+
+// FetchById implements PromotionService.
+func (ps *promotionService) FetchById(id int) (*helper.ResponseBody, exception.Exception) {
+
+	promotion, err := ps.pmr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "promotion successfully fetched",
+		Data:    promotion,
+	}, nil
+}
+
+// Modify implements PromotionService.
+func (ps *promotionService) Modify(id int, payload *dto.PromotionPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ps.pmr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	promotion, err := ps.payloadToPromotion(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.pmr.Modify(id, promotion); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "promotion successfully modified",
+		Data:    nil,
+	}, nil
+}
+
+// Delete implements PromotionService.
+func (ps *promotionService) Delete(id int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ps.pmr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	if err := ps.pmr.Delete(id); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "promotion successfully deleted",
+		Data:    nil,
+	}, nil
+}
+
+// Apply implements PromotionService.
+func (ps *promotionService) Apply(userId int, couponCode string, transaction *entity.Transaction) exception.Exception {
+
+	now := time.Now()
+
+	promotions, err := ps.pmr.FetchAutomatic()
+
+	if err != nil {
+		return err
+	}
+
+	if couponCode != "" {
+
+		coupon, err := ps.pmr.FetchByCode(couponCode)
+
+		if err != nil {
+			if err.Status() == http.StatusNotFound {
+				return exception.NewBadRequestError("invalid coupon code")
+			}
+
+			return err
+		}
+
+		if !coupon.IsRunning(now) {
+			return exception.NewBadRequestError("coupon is not valid at this time")
+		}
+
+		if err := ps.checkUsage(coupon, userId); err != nil {
+			return err
+		}
+
+		promotions = append(promotions, coupon)
+	}
+
+	for _, promotion := range promotions {
+
+		if !promotion.IsRunning(now) {
+			continue
+		}
+
+		// automatic promotions that ran out are skipped quietly, a coupon has
+		// already been checked above
+		if promotion.Code == "" && ps.checkUsage(promotion, userId) != nil {
+			continue
+		}
+
+		localized, err := ps.localize(promotion, transaction.Currency)
+
+		if err != nil {
+			return err
+		}
+
+		// like the category listing, a category takes in its subcategories
+		if localized.CategoryId != 0 {
+			if localized.SubcategoryIds, err = ps.cr.FetchDescendantIds(localized.CategoryId); err != nil {
+				return err
+			}
+		}
+
+		discount := localized.Apply(transaction.Items)
+
+		if discount.IsZero() {
+			if promotion.Code != "" {
+				return exception.NewBadRequestError("coupon doesn't apply to this order")
+			}
+
+			continue
+		}
+
+		transaction.Promotions = append(transaction.Promotions, &entity.TransactionPromotion{
+			PromotionId: promotion.Id,
+			Name:        promotion.Name,
+			Code:        promotion.Code,
+			Discount:    discount,
+		})
+	}
+
+	return nil
+}
+
+// localize returns a copy of the promotion with its fixed value and minimum
+// spend converted into the currency being checked out in.
+func (ps *promotionService) localize(promotion *entity.Promotion, currency string) (*entity.Promotion, exception.Exception) {
+
+	if promotion.Currency == currency {
+		return promotion, nil
+	}
+
+	localized := *promotion
+	localized.Currency = currency
+
+	if promotion.Type == entity.PromotionTypeFixed {
+
+		value, err := ps.cs.Convert(money.New(int64(promotion.Value), promotion.Currency), currency)
+
+		if err != nil {
+			return nil, err
+		}
+
+		localized.Value = int(value.Amount)
+	}
+
+	minSpend, err := ps.cs.Convert(money.New(int64(promotion.MinSpend), promotion.Currency), currency)
+
+	if err != nil {
+		return nil, err
+	}
+
+	localized.MinSpend = int(minSpend.Amount)
+
+	return &localized, nil
+}
+
+func (ps *promotionService) checkUsage(promotion *entity.Promotion, userId int) exception.Exception {
+
+	if promotion.UsageLimit == 0 && promotion.PerUserLimit == 0 {
+		return nil
+	}
+
+	total, byUser, err := ps.pmr.CountRedemptions(promotion.Id, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if promotion.UsageLimit > 0 && total >= promotion.UsageLimit {
+		return exception.NewBadRequestError("promotion usage limit has been reached")
+	}
+
+	if promotion.PerUserLimit > 0 && byUser >= promotion.PerUserLimit {
+		return exception.NewBadRequestError("you have already used this promotion")
+	}
+
+	return nil
+}
+
+func (ps *promotionService) payloadToPromotion(payload *dto.PromotionPayload) (*entity.Promotion, exception.Exception) {
+
+	switch payload.Type {
+	case entity.PromotionTypePercentage:
+		if payload.Value < 1 || payload.Value > 100 {
+			return nil, exception.NewBadRequestError("Value must be between 1 and 100")
+		}
+	case entity.PromotionTypeFixed:
+		if payload.Value < 1 {
+			return nil, exception.NewBadRequestError("Value must be greater than zero")
+		}
+	case entity.PromotionTypeBuyXGetY:
+		if payload.BuyQty < 1 || payload.GetQty < 1 {
+			return nil, exception.NewBadRequestError("Buy qty and get qty must be greater than zero")
+		}
+	}
+
+	if payload.MinSpend < 0 || payload.UsageLimit < 0 || payload.PerUserLimit < 0 {
+		return nil, exception.NewBadRequestError("Min spend and usage limits can't be negative")
+	}
+
+	if payload.StartsAt != nil && payload.EndsAt != nil && !payload.EndsAt.After(*payload.StartsAt) {
+		return nil, exception.NewBadRequestError("Ends at must be after starts at")
+	}
+
+	currency := strings.ToUpper(payload.Currency)
+
+	if currency == "" {
+		currency = config.NewAppConfig().BaseCurrency
+	}
+
+	if !money.IsSupported(currency) {
+		return nil, exception.NewBadRequestError("Currency " + currency + " is not supported")
+	}
+
+	if payload.CategoryId != 0 {
+		if _, err := ps.cr.FetchId(payload.CategoryId); err != nil {
+			return nil, err
+		}
+	}
+
+	if payload.ProductId != 0 {
+		if _, err := ps.pr.FetchById(payload.ProductId); err != nil {
+			return nil, err
+		}
+	}
+
+	return &entity.Promotion{
+		Name:         payload.Name,
+		Code:         strings.ToUpper(strings.TrimSpace(payload.Code)),
+		Type:         payload.Type,
+		Currency:     currency,
+		Value:        payload.Value,
+		BuyQty:       payload.BuyQty,
+		GetQty:       payload.GetQty,
+		MinSpend:     payload.MinSpend,
+		UsageLimit:   payload.UsageLimit,
+		PerUserLimit: payload.PerUserLimit,
+		CategoryId:   payload.CategoryId,
+		ProductId:    payload.ProductId,
+		StartsAt:     payload.StartsAt,
+		EndsAt:       payload.EndsAt,
+		Active:       payload.Active,
+	}, nil
+}
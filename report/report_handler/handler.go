@@ -0,0 +1,119 @@
+package report_handler
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/report/report_service"
+	"net/http"
+	"strconv"
+)
+
+type reportHandler struct {
+	rs report_service.ReportService
+}
+
+type ReportHandler interface {
+	FetchLowStock(w http.ResponseWriter, r *http.Request)
+	FetchSales(w http.ResponseWriter, r *http.Request)
+	FetchTopProducts(w http.ResponseWriter, r *http.Request)
+	FetchTopCategories(w http.ResponseWriter, r *http.Request)
+	FetchCustomers(w http.ResponseWriter, r *http.Request)
+	FetchConversion(w http.ResponseWriter, r *http.Request)
+}
+
+func NewReportHandler(rs report_service.ReportService) ReportHandler {
+	return &reportHandler{
+		rs: rs,
+	}
+}
+
+// FetchLowStock implements ReportHandler.
+func (rh *reportHandler) FetchLowStock(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	windowDays := 0
+
+	if days := r.URL.Query().Get("days"); days != "" {
+
+		parsed, err := strconv.Atoi(days)
+
+		if err != nil {
+			err := exception.NewBadRequestError("days must be a number")
+			w.WriteHeader(err.Status())
+			w.Write(helper.ResponseJSON(err))
+			return
+		}
+
+		windowDays = parsed
+	}
+
+	res, err := rh.rs.FetchLowStock(windowDays)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchSales implements ReportHandler.
+func (rh *reportHandler) FetchSales(w http.ResponseWriter, r *http.Request) {
+	rh.writeReport(w, r, "sales.csv", rh.rs.FetchSales)
+}
+
+// FetchTopProducts implements ReportHandler.
+func (rh *reportHandler) FetchTopProducts(w http.ResponseWriter, r *http.Request) {
+	rh.writeReport(w, r, "top-products.csv", rh.rs.FetchTopProducts)
+}
+
+// FetchTopCategories implements ReportHandler.
+func (rh *reportHandler) FetchTopCategories(w http.ResponseWriter, r *http.Request) {
+	rh.writeReport(w, r, "top-categories.csv", rh.rs.FetchTopCategories)
+}
+
+// FetchCustomers implements ReportHandler.
+func (rh *reportHandler) FetchCustomers(w http.ResponseWriter, r *http.Request) {
+	rh.writeReport(w, r, "customers.csv", rh.rs.FetchCustomers)
+}
+
+// FetchConversion implements ReportHandler.
+func (rh *reportHandler) FetchConversion(w http.ResponseWriter, r *http.Request) {
+	rh.writeReport(w, r, "conversion.csv", rh.rs.FetchConversion)
+}
+
+// writeReport runs fetch with the report filters of r and writes the report
+// as JSON, or as a CSV attachment named filename when format=csv.
+func (rh *reportHandler) writeReport(w http.ResponseWriter, r *http.Request, filename string, fetch func(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception)) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	query, err := helper.ReportQuery(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := fetch(query)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if query.Format == entity.ReportFormatCSV {
+		helper.WriteCSV(w, filename, res.Data.(dto.CSVReport))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
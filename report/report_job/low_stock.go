@@ -0,0 +1,94 @@
+package report_job
+
+import (
+	"fashion-api/entity"
+	"fashion-api/notification/notification_job"
+	"fashion-api/report/report_repo"
+	"log"
+	"time"
+)
+
+type lowStockJob struct {
+	rr       report_repo.ReportRepo
+	nj       notification_job.NotificationJob
+	interval time.Duration
+}
+
+// LowStockJob periodically alerts admins about products that fell to their
+// reorder threshold. A product is only alerted once until it is restocked
+// above the threshold again.
+type LowStockJob interface {
+	Run()
+}
+
+func NewLowStockJob(rr report_repo.ReportRepo, nj notification_job.NotificationJob, interval time.Duration) LowStockJob {
+	return &lowStockJob{
+		rr:       rr,
+		nj:       nj,
+		interval: interval,
+	}
+}
+
+// Run implements LowStockJob.
+func (j *lowStockJob) Run() {
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.check()
+		<-ticker.C
+	}
+}
+
+func (j *lowStockJob) check() {
+
+	if err := j.rr.ClearLowStockAlerts(); err != nil {
+		log.Println(err.Message())
+		return
+	}
+
+	items, err := j.rr.FetchLowStock(entity.LowStockWindowDays)
+
+	if err != nil {
+		log.Println(err.Message())
+		return
+	}
+
+	pending, productIds := []*entity.LowStockItem{}, []int{}
+
+	for _, item := range items {
+		if item.AlertedAt == nil {
+			pending = append(pending, item)
+			productIds = append(productIds, item.ProductId)
+		}
+	}
+
+	if len(pending) == 0 {
+		return
+	}
+
+	admins, err := j.rr.FetchAdmins()
+
+	if err != nil {
+		log.Println(err.Message())
+		return
+	}
+
+	// with nobody to tell, the products are alerted again on the next check
+	if len(admins) == 0 {
+		return
+	}
+
+	for _, admin := range admins {
+		j.nj.Queue(&entity.Notification{
+			Kind:   entity.NotificationLowStock,
+			UserId: admin.Id,
+			Data:   &entity.LowStockAlert{Items: pending},
+		})
+	}
+
+	if err := j.rr.MarkLowStockAlerted(productIds); err != nil {
+		log.Println(err.Message())
+	}
+}
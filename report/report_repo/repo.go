@@ -0,0 +1,20 @@
+package report_repo
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type ReportRepo interface {
+	FetchLowStock(windowDays int) ([]*entity.LowStockItem, exception.Exception)
+	FetchAdmins() ([]*entity.User, exception.Exception)
+	MarkLowStockAlerted(productIds []int) exception.Exception
+	ClearLowStockAlerts() exception.Exception
+	FetchSales(query *dto.ReportQuery) ([]*entity.SalesBucket, exception.Exception)
+	FetchTopProducts(query *dto.ReportQuery) ([]*entity.TopSeller, exception.Exception)
+	FetchTopCategories(query *dto.ReportQuery) ([]*entity.TopSeller, exception.Exception)
+	FetchCustomers(query *dto.ReportQuery) ([]*entity.CustomerBucket, exception.Exception)
+	FetchCustomerTotals(query *dto.ReportQuery) (*entity.Customers, exception.Exception)
+	FetchConversion(query *dto.ReportQuery) ([]*entity.ConversionBucket, exception.Exception)
+}
@@ -0,0 +1,365 @@
+package report_pg
+
+import (
+	"database/sql"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+	"fashion-api/report/report_repo"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+type reportPg struct {
+	db *sql.DB
+}
+
+const (
+	// recent sales only count transactions that were paid and not refunded
+	fetchLowStockQuery = `with recent as (
+			select ti.product_id, sum(ti.qty) as qty from transaction_item as ti join transaction as t on ti.transaction_id = t.id
+			where t.status = 'paid' and t.deleted_at is null and t.created_at >= now() - make_interval(days => $1) group by ti.product_id
+		)
+		select p.id, p.name, p.slug, p.stock, p.reserved, p.reorder_threshold, p.sold, coalesce(r.qty, 0), extract(epoch from now() - p.created_at) / 86400, p.low_stock_alerted_at
+		from product as p left join recent as r on r.product_id = p.id
+		where p.deleted_at is null and p.reorder_threshold > 0 and p.stock - p.reserved <= p.reorder_threshold
+		order by p.stock - p.reserved asc, p.id asc`
+
+	fetchAdminsQuery = `select id, full_name, email from "user" where role = 'admin' order by id`
+
+	markLowStockAlertedQuery = `update product set low_stock_alerted_at = now() where id = any($1)`
+
+	// restocked products are alerted again the next time they run low
+	clearLowStockAlertsQuery = `update product set low_stock_alerted_at = null where low_stock_alerted_at is not null and (reorder_threshold = 0 or stock - reserved > reorder_threshold)`
+
+	// reportBucketsQuery lists every period between $2 and $3 in steps of $1,
+	// so periods without sales are reported as zero
+	reportBucketsQuery = `bucket as (
+			select generate_series(date_trunc($1, $2::timestamptz), $3::timestamptz - interval '1 microsecond', ('1 ' || $1)::interval) as period
+		)`
+
+	// a sale counts in the period its payment was captured, whatever
+	// happened to the order later, and a refund comes off the period it was
+	// issued in. Refunds of payments that arrived after a cancel were never
+	// captured, so never a sale. Transactions paid before payments were
+	// recorded count at checkout.
+	fetchSalesQuery = `with ` + reportBucketsQuery + `, sold as (
+			select t.id, t.grand_total, t.discount_total, t.tax_total, t.shipping_total, coalesce(p.captured_at, t.created_at) as sold_at
+			from transaction as t left join payment as p on p.transaction_id = t.id
+			where t.deleted_at is null and t.currency = $4 and (p.captured_at is not null or (p.id is null and t.status in ('paid', 'refunded')))
+		), sale as (
+			select date_trunc($1, sold_at) as period, count(id) as orders, sum(grand_total) as revenue, sum(discount_total) as discount, sum(tax_total) as tax, sum(shipping_total) as shipping
+			from sold where sold_at >= $2 and sold_at < $3
+			group by 1
+		), refund as (
+			select date_trunc($1, r.created_at) as period, sum(r.amount) as amount
+			from payment_refund as r join payment as p on r.payment_id = p.id join transaction as t on p.transaction_id = t.id
+			where p.captured_at is not null and t.deleted_at is null and t.currency = $4 and r.created_at >= $2 and r.created_at < $3
+			group by 1
+		)
+		select b.period, coalesce(s.orders, 0), coalesce(s.revenue, 0), coalesce(s.discount, 0), coalesce(s.tax, 0), coalesce(s.shipping, 0), coalesce(r.amount, 0)
+		from bucket as b left join sale as s on s.period = b.period left join refund as r on r.period = b.period
+		order by b.period`
+
+	// %[1]s is the id column items are grouped by, %[2]s the name shown for
+	// it, %[3]s the whitelisted ranking and %[4]s the join bringing the name
+	fetchTopSellersQuery = `select coalesce(%[1]s, 0), coalesce(%[2]s, ''), sum(ti.qty), count(distinct t.id), coalesce(sum(ti.total) filter (where t.currency = $3), 0) as revenue
+		from transaction_item as ti join transaction as t on ti.transaction_id = t.id %[4]s
+		where t.status = 'paid' and t.deleted_at is null and t.created_at >= $1 and t.created_at < $2
+		group by %[1]s, %[2]s order by %[3]s desc, %[1]s asc limit $4`
+
+	// a customer is new in the period of their first paid transaction ever,
+	// not just the first one in the report
+	fetchCustomersQuery = `with ` + reportBucketsQuery + `, paid as (
+			select user_id, created_at from transaction where status = 'paid' and deleted_at is null
+		), first_paid as (
+			select user_id, min(created_at) as created_at from paid group by user_id
+		)
+		select b.period, count(distinct p.user_id), count(distinct p.user_id) filter (where f.created_at >= greatest(b.period, $2::timestamptz))
+		from bucket as b left join paid as p on date_trunc($1, p.created_at) = b.period and p.created_at >= $2 and p.created_at < $3
+		left join first_paid as f on f.user_id = p.user_id
+		group by b.period order by b.period`
+
+	fetchCustomerTotalsQuery = `with first_paid as (
+			select user_id, min(created_at) as created_at from transaction where status = 'paid' and deleted_at is null group by user_id
+		)
+		select count(distinct t.user_id), count(distinct t.user_id) filter (where f.created_at >= $1)
+		from transaction as t join first_paid as f on f.user_id = t.user_id
+		where t.status = 'paid' and t.deleted_at is null and t.created_at >= $1 and t.created_at < $2`
+
+	// cancelled orders are soft deleted but still count as placed
+	fetchConversionQuery = `with ` + reportBucketsQuery + `
+		select b.period, count(o.id),
+			count(o.id) filter (where exists (select 1 from transaction as t where t.order_id = o.id and t.deleted_at is null)),
+			count(o.id) filter (where exists (select 1 from transaction as t where t.order_id = o.id and t.deleted_at is null and t.status in ('paid', 'refunded')))
+		from bucket as b left join "order" as o on date_trunc($1, o.created_at) = b.period and o.created_at >= $2 and o.created_at < $3
+		group by b.period order by b.period`
+)
+
+var topSellerRanks = map[string]string{
+	entity.ReportRankRevenue: "revenue",
+	entity.ReportRankUnits:   "sum(ti.qty)",
+}
+
+func NewReportPg(db *sql.DB) report_repo.ReportRepo {
+	return &reportPg{
+		db: db,
+	}
+}
+
+// FetchLowStock implements report_repo.ReportRepo.
+func (pg *reportPg) FetchLowStock(windowDays int) ([]*entity.LowStockItem, exception.Exception) {
+
+	items := []*entity.LowStockItem{}
+
+	rows, err := pg.db.Query(fetchLowStockQuery, windowDays)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		item, lifetimeDays, alertedAt := entity.LowStockItem{}, 0.0, sql.NullTime{}
+
+		if err := rows.Scan(
+			&item.ProductId,
+			&item.Name,
+			&item.Slug,
+			&item.Stock,
+			&item.Reserved,
+			&item.ReorderThreshold,
+			&item.Sold,
+			&item.RecentSold,
+			&lifetimeDays,
+			&alertedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		if alertedAt.Valid {
+			item.AlertedAt = &alertedAt.Time
+		}
+
+		item.Available = item.Stock - item.Reserved
+		item.EstimateStockout(windowDays, lifetimeDays)
+
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+// FetchAdmins implements report_repo.ReportRepo.
+func (pg *reportPg) FetchAdmins() ([]*entity.User, exception.Exception) {
+
+	admins := []*entity.User{}
+
+	rows, err := pg.db.Query(fetchAdminsQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		admin := entity.User{}
+
+		if err := rows.Scan(&admin.Id, &admin.FullName, &admin.Email); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		admins = append(admins, &admin)
+	}
+
+	return admins, nil
+}
+
+// MarkLowStockAlerted implements report_repo.ReportRepo.
+func (pg *reportPg) MarkLowStockAlerted(productIds []int) exception.Exception {
+
+	if _, err := pg.db.Exec(markLowStockAlertedQuery, pq.Array(productIds)); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// ClearLowStockAlerts implements report_repo.ReportRepo.
+func (pg *reportPg) ClearLowStockAlerts() exception.Exception {
+
+	if _, err := pg.db.Exec(clearLowStockAlertsQuery); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchSales implements report_repo.ReportRepo.
+func (pg *reportPg) FetchSales(query *dto.ReportQuery) ([]*entity.SalesBucket, exception.Exception) {
+
+	buckets := []*entity.SalesBucket{}
+
+	rows, err := pg.db.Query(fetchSalesQuery, query.Interval, query.From, query.To, query.Currency)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		bucket, currency := entity.SalesBucket{}, money.Money{Currency: query.Currency}
+		sales := entity.Sales{GrossRevenue: currency, Refunds: currency, Discount: currency, Tax: currency, Shipping: currency}
+
+		if err := rows.Scan(
+			&bucket.Period,
+			&sales.Orders,
+			&sales.GrossRevenue.Amount,
+			&sales.Discount.Amount,
+			&sales.Tax.Amount,
+			&sales.Shipping.Amount,
+			&sales.Refunds.Amount,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		// adding to a zero bucket works out revenue and the average order value
+		bucket.Add(&sales)
+
+		buckets = append(buckets, &bucket)
+	}
+
+	return buckets, nil
+}
+
+// FetchTopProducts implements report_repo.ReportRepo.
+func (pg *reportPg) FetchTopProducts(query *dto.ReportQuery) ([]*entity.TopSeller, exception.Exception) {
+	return pg.fetchTopSellers(query, fmt.Sprintf(fetchTopSellersQuery, "ti.product_id", "p.name", topSellerRanks[query.Rank], "left join product as p on ti.product_id = p.id"))
+}
+
+// FetchTopCategories implements report_repo.ReportRepo.
+func (pg *reportPg) FetchTopCategories(query *dto.ReportQuery) ([]*entity.TopSeller, exception.Exception) {
+	return pg.fetchTopSellers(query, fmt.Sprintf(fetchTopSellersQuery, "ti.category_id", "c.type", topSellerRanks[query.Rank], "left join category as c on ti.category_id = c.id"))
+}
+
+func (pg *reportPg) fetchTopSellers(query *dto.ReportQuery, statement string) ([]*entity.TopSeller, exception.Exception) {
+
+	items := []*entity.TopSeller{}
+
+	rows, err := pg.db.Query(statement, query.From, query.To, query.Currency, query.Limit)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		item := entity.TopSeller{Revenue: money.Money{Currency: query.Currency}}
+
+		if err := rows.Scan(&item.Id, &item.Name, &item.Units, &item.Orders, &item.Revenue.Amount); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+// FetchCustomers implements report_repo.ReportRepo.
+func (pg *reportPg) FetchCustomers(query *dto.ReportQuery) ([]*entity.CustomerBucket, exception.Exception) {
+
+	buckets := []*entity.CustomerBucket{}
+
+	rows, err := pg.db.Query(fetchCustomersQuery, query.Interval, query.From, query.To)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		bucket := entity.CustomerBucket{}
+
+		if err := rows.Scan(&bucket.Period, &bucket.Total, &bucket.New); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		bucket.Returning = bucket.Total - bucket.New
+
+		buckets = append(buckets, &bucket)
+	}
+
+	return buckets, nil
+}
+
+// FetchCustomerTotals implements report_repo.ReportRepo.
+func (pg *reportPg) FetchCustomerTotals(query *dto.ReportQuery) (*entity.Customers, exception.Exception) {
+
+	totals := entity.Customers{}
+
+	if err := pg.db.QueryRow(fetchCustomerTotalsQuery, query.From, query.To).Scan(&totals.Total, &totals.New); err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	totals.Returning = totals.Total - totals.New
+
+	return &totals, nil
+}
+
+// FetchConversion implements report_repo.ReportRepo.
+func (pg *reportPg) FetchConversion(query *dto.ReportQuery) ([]*entity.ConversionBucket, exception.Exception) {
+
+	buckets := []*entity.ConversionBucket{}
+
+	rows, err := pg.db.Query(fetchConversionQuery, query.Interval, query.From, query.To)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		bucket, conversion := entity.ConversionBucket{}, entity.Conversion{}
+
+		if err := rows.Scan(&bucket.Period, &conversion.Orders, &conversion.CheckedOut, &conversion.Paid); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		bucket.Add(&conversion)
+
+		buckets = append(buckets, &bucket)
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,201 @@
+package report_service
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/infra/config"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"fashion-api/report/report_repo"
+	"net/http"
+)
+
+const maxWindowDays = 365
+
+type reportService struct {
+	rr report_repo.ReportRepo
+}
+
+type ReportService interface {
+	FetchLowStock(windowDays int) (*helper.ResponseBody, exception.Exception)
+	FetchSales(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception)
+	FetchTopProducts(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception)
+	FetchTopCategories(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception)
+	FetchCustomers(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception)
+	FetchConversion(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewReportService(rr report_repo.ReportRepo) ReportService {
+	return &reportService{
+		rr: rr,
+	}
+}
+
+// FetchLowStock implements ReportService.
+func (rs *reportService) FetchLowStock(windowDays int) (*helper.ResponseBody, exception.Exception) {
+
+	if windowDays == 0 {
+		windowDays = entity.LowStockWindowDays
+	}
+
+	if windowDays < 1 || windowDays > maxWindowDays {
+		return nil, exception.NewBadRequestError("days must be between 1 and 365")
+	}
+
+	items, err := rs.rr.FetchLowStock(windowDays)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "low stock report successfully fetched",
+		Data: &dto.LowStockReport{
+			WindowDays: windowDays,
+			Products:   items,
+		},
+	}, nil
+}
+
+// FetchSales implements ReportService.
+func (rs *reportService) FetchSales(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception) {
+
+	reportCurrency(query)
+
+	buckets, err := rs.rr.FetchSales(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	currency := money.Money{Currency: query.Currency}
+	totals := &entity.Sales{GrossRevenue: currency, Refunds: currency, Revenue: currency, Discount: currency, Tax: currency, Shipping: currency, AverageOrderValue: currency}
+
+	for _, bucket := range buckets {
+		totals.Add(&bucket.Sales)
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "sales report successfully fetched",
+		Data: &dto.SalesReport{
+			ReportRange: dto.NewReportRange(query),
+			Interval:    query.Interval,
+			Currency:    query.Currency,
+			Totals:      totals,
+			Buckets:     buckets,
+		},
+	}, nil
+}
+
+// FetchTopProducts implements ReportService.
+func (rs *reportService) FetchTopProducts(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception) {
+
+	reportCurrency(query)
+
+	items, err := rs.rr.FetchTopProducts(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "top products successfully fetched",
+		Data: &dto.TopSellerReport{
+			ReportRange: dto.NewReportRange(query),
+			Currency:    query.Currency,
+			Rank:        query.Rank,
+			Items:       items,
+		},
+	}, nil
+}
+
+// FetchTopCategories implements ReportService.
+func (rs *reportService) FetchTopCategories(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception) {
+
+	reportCurrency(query)
+
+	items, err := rs.rr.FetchTopCategories(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "top categories successfully fetched",
+		Data: &dto.TopSellerReport{
+			ReportRange: dto.NewReportRange(query),
+			Currency:    query.Currency,
+			Rank:        query.Rank,
+			Items:       items,
+		},
+	}, nil
+}
+
+// FetchCustomers implements ReportService.
+func (rs *reportService) FetchCustomers(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception) {
+
+	buckets, err := rs.rr.FetchCustomers(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a customer paying in several periods is counted once in the totals
+	totals, err := rs.rr.FetchCustomerTotals(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "customer report successfully fetched",
+		Data: &dto.CustomerReport{
+			ReportRange: dto.NewReportRange(query),
+			Interval:    query.Interval,
+			Totals:      totals,
+			Buckets:     buckets,
+		},
+	}, nil
+}
+
+// FetchConversion implements ReportService.
+func (rs *reportService) FetchConversion(query *dto.ReportQuery) (*helper.ResponseBody, exception.Exception) {
+
+	buckets, err := rs.rr.FetchConversion(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	totals := &entity.Conversion{}
+
+	for _, bucket := range buckets {
+		totals.Add(&bucket.Conversion)
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "conversion report successfully fetched",
+		Data: &dto.ConversionReport{
+			ReportRange: dto.NewReportRange(query),
+			Interval:    query.Interval,
+			Totals:      totals,
+			Buckets:     buckets,
+		},
+	}, nil
+}
+
+// reportCurrency reports amounts in the base currency unless another one
+// was asked for, transactions in other currencies are left out.
+func reportCurrency(query *dto.ReportQuery) {
+
+	if query.Currency == "" {
+		query.Currency = config.NewAppConfig().BaseCurrency
+	}
+}
@@ -0,0 +1,156 @@
+package review_handler
+
+import (
+	"encoding/json"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/review/review_service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type reviewHandler struct {
+	rs review_service.ReviewService
+}
+
+type ReviewHandler interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	FetchByProductId(w http.ResponseWriter, r *http.Request)
+	Fetch(w http.ResponseWriter, r *http.Request)
+	Approve(w http.ResponseWriter, r *http.Request)
+	Hide(w http.ResponseWriter, r *http.Request)
+}
+
+func NewReviewHandler(rs review_service.ReviewService) ReviewHandler {
+	return &reviewHandler{
+		rs: rs,
+	}
+}
+
+// Add implements ReviewHandler.
+func (rh *reviewHandler) Add(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	user := r.Context().Value("userData").(*entity.User)
+	productId, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	payload := &dto.AddReviewPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := rh.rs.Add(user.Id, productId, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchByProductId implements ReviewHandler.
+func (rh *reviewHandler) FetchByProductId(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	productId, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	page, limit, err := helper.Pagination(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := rh.rs.FetchByProductId(productId, r.URL.Query().Get("sort"), page, limit)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Fetch implements ReviewHandler.
+func (rh *reviewHandler) Fetch(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	page, limit, err := helper.Pagination(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := rh.rs.Fetch(r.URL.Query().Get("status"), page, limit)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Approve implements ReviewHandler.
+func (rh *reviewHandler) Approve(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := rh.rs.Approve(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Hide implements ReviewHandler.
+func (rh *reviewHandler) Hide(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := rh.rs.Hide(id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -0,0 +1,15 @@
+package review_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type ReviewRepo interface {
+	Add(review *entity.Review) exception.Exception
+	HasPurchased(userId int, productId int) (bool, exception.Exception)
+	Fetch(status string, limit int, offset int) ([]*entity.Review, int, exception.Exception)
+	FetchByProductId(productId int, sort string, limit int, offset int) ([]*entity.Review, int, exception.Exception)
+	FetchById(id int) (*entity.Review, exception.Exception)
+	Moderate(review *entity.Review, status string) exception.Exception
+}
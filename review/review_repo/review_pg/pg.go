@@ -0,0 +1,208 @@
+package review_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/review/review_repo"
+	"fmt"
+	"log"
+)
+
+type reviewPg struct {
+	db *sql.DB
+}
+
+const (
+	addReviewQuery = `insert into review (product_id, user_id, rating, title, body) values ($1, $2, $3, $4, $5)`
+
+	duplicateReviewError = `pq: duplicate key value violates unique constraint "review_product_id_user_id_key"`
+
+	// only a paid transaction counts, refunded ones don't
+	hasPurchasedQuery = `select exists (select 1 from transaction as t join transaction_item as ti on ti.transaction_id = t.id where t.user_id = $1 and ti.product_id = $2 and t.status = 'paid')`
+
+	fetchReviewsQuery = `select r.id, r.product_id, r.user_id, u.full_name, r.rating, r.title, r.body, r.status, r.created_at, r.updated_at from review as r join "user" as u on r.user_id = u.id`
+
+	// an empty status lists reviews of every status
+	fetchReviewsByStatusQuery = fetchReviewsQuery + ` where ($1 = '' or r.status = $1) order by r.created_at desc, r.id desc limit $2 offset $3`
+
+	countReviewsByStatusQuery = `select count(*) from review where ($1 = '' or status = $1)`
+
+	fetchReviewsByProductIdQuery = fetchReviewsQuery + ` where r.product_id = $1 and r.status = 'approved' order by %s limit $2 offset $3`
+
+	countReviewsByProductIdQuery = `select count(*) from review where product_id = $1 and status = 'approved'`
+
+	fetchReviewByIdQuery = fetchReviewsQuery + ` where r.id = $1`
+
+	moderateReviewQuery = `update review set status = $2, updated_at = now() where id = $1 and status = $3`
+
+	// the rating shown on product listings is kept on the product so it
+	// doesn't have to be aggregated per request
+	refreshProductRatingQuery = `update product set rating_average = coalesce((select round(avg(rating), 2) from review where product_id = $1 and status = 'approved'), 0), review_count = (select count(*) from review where product_id = $1 and status = 'approved') where id = $1`
+)
+
+func NewReviewPg(db *sql.DB) review_repo.ReviewRepo {
+	return &reviewPg{
+		db: db,
+	}
+}
+
+// Add implements review_repo.ReviewRepo.
+func (pg *reviewPg) Add(review *entity.Review) exception.Exception {
+
+	if _, err := pg.db.Exec(
+		addReviewQuery,
+		review.ProductId,
+		review.UserId,
+		review.Rating,
+		review.Title,
+		review.Body,
+	); err != nil {
+		if err.Error() == duplicateReviewError {
+			log.Println(err.Error())
+			return exception.NewConflictError("you have already reviewed this product")
+		}
+
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// HasPurchased implements review_repo.ReviewRepo.
+func (pg *reviewPg) HasPurchased(userId int, productId int) (bool, exception.Exception) {
+
+	purchased := false
+
+	if err := pg.db.QueryRow(hasPurchasedQuery, userId, productId).Scan(&purchased); err != nil {
+		log.Println(err.Error())
+		return false, exception.NewInternalServerError("something went wrong")
+	}
+
+	return purchased, nil
+}
+
+// Fetch implements review_repo.ReviewRepo.
+func (pg *reviewPg) Fetch(status string, limit int, offset int) ([]*entity.Review, int, exception.Exception) {
+
+	total := 0
+
+	if err := pg.db.QueryRow(countReviewsByStatusQuery, status).Scan(&total); err != nil {
+		log.Println(err.Error())
+		return nil, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	reviews, err := pg.fetchReviews(fetchReviewsByStatusQuery, status, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+// FetchByProductId implements review_repo.ReviewRepo.
+func (pg *reviewPg) FetchByProductId(productId int, sort string, limit int, offset int) ([]*entity.Review, int, exception.Exception) {
+
+	total := 0
+
+	if err := pg.db.QueryRow(countReviewsByProductIdQuery, productId).Scan(&total); err != nil {
+		log.Println(err.Error())
+		return nil, 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	reviews, err := pg.fetchReviews(fmt.Sprintf(fetchReviewsByProductIdQuery, reviewSortColumns(sort)), productId, limit, offset)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+// FetchById implements review_repo.ReviewRepo.
+func (pg *reviewPg) FetchById(id int) (*entity.Review, exception.Exception) {
+
+	review, err := scanReview(pg.db.QueryRow(fetchReviewByIdQuery, id))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("review not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return review, nil
+}
+
+// Moderate implements review_repo.ReviewRepo.
+func (pg *reviewPg) Moderate(review *entity.Review, status string) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	res, err := tx.Exec(moderateReviewQuery, review.Id, status, review.Status)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewConflictError("review status has changed, please try again")
+	}
+
+	if _, err := tx.Exec(refreshProductRatingQuery, review.ProductId); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+func (pg *reviewPg) fetchReviews(query string, args ...any) ([]*entity.Review, exception.Exception) {
+
+	reviews := []*entity.Review{}
+
+	rows, err := pg.db.Query(query, args...)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		review, err := scanReview(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		reviews = append(reviews, review)
+	}
+
+	return reviews, nil
+}
@@ -0,0 +1,45 @@
+package review_pg
+
+import "fashion-api/entity"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanReview(row scanner) (*entity.Review, error) {
+
+	review := entity.Review{}
+
+	if err := row.Scan(
+		&review.Id,
+		&review.ProductId,
+		&review.UserId,
+		&review.UserName,
+		&review.Rating,
+		&review.Title,
+		&review.Body,
+		&review.Status,
+		&review.CreatedAt,
+		&review.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// reviewSortColumns returns the order by columns for a sort, ties are
+// broken by id so pages are stable.
+func reviewSortColumns(sort string) string {
+
+	switch sort {
+	case entity.ReviewSortOldest:
+		return "r.created_at asc, r.id asc"
+	case entity.ReviewSortRatingDesc:
+		return "r.rating desc, r.created_at desc, r.id desc"
+	case entity.ReviewSortRatingAsc:
+		return "r.rating asc, r.created_at desc, r.id desc"
+	}
+
+	return "r.created_at desc, r.id desc"
+}
@@ -0,0 +1,161 @@
+package review_service
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/product/product_repo"
+	"fashion-api/review/review_repo"
+	"net/http"
+	"strings"
+)
+
+type reviewService struct {
+	rr review_repo.ReviewRepo
+	pr product_repo.ProductRepo
+}
+
+type ReviewService interface {
+	Add(userId int, productId int, payload *dto.AddReviewPayload) (*helper.ResponseBody, exception.Exception)
+	FetchByProductId(productId int, sort string, page int, limit int) (*helper.ResponseBody, exception.Exception)
+	Fetch(status string, page int, limit int) (*helper.ResponseBody, exception.Exception)
+	Approve(id int) (*helper.ResponseBody, exception.Exception)
+	Hide(id int) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewReviewService(rr review_repo.ReviewRepo, pr product_repo.ProductRepo) ReviewService {
+	return &reviewService{
+		rr: rr,
+		pr: pr,
+	}
+}
+
+// Add implements ReviewService.
+func (rs *reviewService) Add(userId int, productId int, payload *dto.AddReviewPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if payload.Rating < 1 || payload.Rating > 5 {
+		return nil, exception.NewBadRequestError("Rating must be between 1 and 5")
+	}
+
+	if _, err := rs.pr.FetchById(productId); err != nil {
+		return nil, err
+	}
+
+	purchased, err := rs.rr.HasPurchased(userId, productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !purchased {
+		return nil, exception.NewUnauthorizedError("only customers who bought this product can review it")
+	}
+
+	review := &entity.Review{
+		ProductId: productId,
+		UserId:    userId,
+		Rating:    payload.Rating,
+		Title:     strings.TrimSpace(payload.Title),
+		Body:      strings.TrimSpace(payload.Body),
+	}
+
+	if err := rs.rr.Add(review); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "review successfully submitted, it will be shown once approved",
+		Data:    nil,
+	}, nil
+}
+
+// FetchByProductId implements ReviewService.
+func (rs *reviewService) FetchByProductId(productId int, sort string, page int, limit int) (*helper.ResponseBody, exception.Exception) {
+
+	switch sort {
+	case "":
+		sort = entity.ReviewSortNewest
+	case entity.ReviewSortNewest, entity.ReviewSortOldest, entity.ReviewSortRatingDesc, entity.ReviewSortRatingAsc:
+	default:
+		return nil, exception.NewBadRequestError("sort must be newest, oldest, rating_desc or rating_asc")
+	}
+
+	if _, err := rs.pr.FetchById(productId); err != nil {
+		return nil, err
+	}
+
+	reviews, total, err := rs.rr.FetchByProductId(productId, sort, limit, (page-1)*limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "reviews successfully fetched",
+		Data: &dto.ReviewPage{
+			Reviews:    reviews,
+			Pagination: dto.NewPageInfo(page, limit, total),
+		},
+	}, nil
+}
+
+// Fetch implements ReviewService.
+func (rs *reviewService) Fetch(status string, page int, limit int) (*helper.ResponseBody, exception.Exception) {
+
+	switch status {
+	case "", entity.ReviewStatusPending, entity.ReviewStatusApproved, entity.ReviewStatusHidden:
+	default:
+		return nil, exception.NewBadRequestError("status must be pending, approved or hidden")
+	}
+
+	reviews, total, err := rs.rr.Fetch(status, limit, (page-1)*limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "reviews successfully fetched",
+		Data: &dto.ReviewPage{
+			Reviews:    reviews,
+			Pagination: dto.NewPageInfo(page, limit, total),
+		},
+	}, nil
+}
+
+// Approve implements ReviewService.
+func (rs *reviewService) Approve(id int) (*helper.ResponseBody, exception.Exception) {
+	return rs.moderate(id, entity.ReviewStatusApproved, "review successfully approved")
+}
+
+// Hide implements ReviewService.
+func (rs *reviewService) Hide(id int) (*helper.ResponseBody, exception.Exception) {
+	return rs.moderate(id, entity.ReviewStatusHidden, "review successfully hidden")
+}
+
+func (rs *reviewService) moderate(id int, status string, message string) (*helper.ResponseBody, exception.Exception) {
+
+	review, err := rs.rr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if review.Status == status {
+		return nil, exception.NewConflictError("review is already " + status)
+	}
+
+	if err := rs.rr.Moderate(review, status); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: message,
+		Data:    nil,
+	}, nil
+}
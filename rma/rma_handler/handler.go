@@ -0,0 +1,198 @@
+package rma_handler
+
+import (
+	"encoding/json"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/rma/rma_service"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type rmaHandler struct {
+	rs rma_service.RmaService
+}
+
+type RmaHandler interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	CustomersReturn(w http.ResponseWriter, r *http.Request)
+	Fetch(w http.ResponseWriter, r *http.Request)
+	Approve(w http.ResponseWriter, r *http.Request)
+	Reject(w http.ResponseWriter, r *http.Request)
+	Receive(w http.ResponseWriter, r *http.Request)
+}
+
+func NewRmaHandler(rs rma_service.RmaService) RmaHandler {
+	return &rmaHandler{
+		rs: rs,
+	}
+}
+
+// Add implements RmaHandler.
+func (rh *rmaHandler) Add(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	user := r.Context().Value("userData").(*entity.User)
+	payload := &dto.AddReturnPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		invalidJSONRequest := exception.NewUnprocessableEntityError("invalid JSON body request")
+
+		w.WriteHeader(invalidJSONRequest.Status())
+		w.Write(helper.ResponseJSON(invalidJSONRequest))
+
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := rh.rs.Add(user.Id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// CustomersReturn implements RmaHandler.
+func (rh *rmaHandler) CustomersReturn(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	user := r.Context().Value("userData").(*entity.User)
+
+	res, err := rh.rs.CustomersReturn(user.Id)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Fetch implements RmaHandler.
+func (rh *rmaHandler) Fetch(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	res, err := rh.rs.Fetch()
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Approve implements RmaHandler.
+func (rh *rmaHandler) Approve(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	payload := &dto.ApproveReturnPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil && err != io.EOF {
+		invalidJSONRequest := exception.NewUnprocessableEntityError("invalid JSON body request")
+
+		w.WriteHeader(invalidJSONRequest.Status())
+		w.Write(helper.ResponseJSON(invalidJSONRequest))
+
+		return
+	}
+
+	res, err := rh.rs.Approve(id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Reject implements RmaHandler.
+func (rh *rmaHandler) Reject(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	payload := &dto.RejectReturnPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		invalidJSONRequest := exception.NewUnprocessableEntityError("invalid JSON body request")
+
+		w.WriteHeader(invalidJSONRequest.Status())
+		w.Write(helper.ResponseJSON(invalidJSONRequest))
+
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := rh.rs.Reject(id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Receive implements RmaHandler.
+func (rh *rmaHandler) Receive(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	payload := &dto.ReceiveReturnPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil && err != io.EOF {
+		invalidJSONRequest := exception.NewUnprocessableEntityError("invalid JSON body request")
+
+		w.WriteHeader(invalidJSONRequest.Status())
+		w.Write(helper.ResponseJSON(invalidJSONRequest))
+
+		return
+	}
+
+	res, err := rh.rs.Receive(id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -0,0 +1,20 @@
+package rma_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+)
+
+type RmaRepo interface {
+	Add(returnRequest *entity.ReturnRequest) exception.Exception
+	Fetch() ([]*entity.ReturnRequest, exception.Exception)
+	FetchByUserId(userId int) ([]*entity.ReturnRequest, exception.Exception)
+	FetchById(id int) (*entity.ReturnRequest, exception.Exception)
+	FetchReturnedQty(orderId int) (int, exception.Exception)
+	// Approve marks the return approved and calls refund before committing,
+	// the approval is rolled back when refund fails
+	Approve(id int, refundAmount money.Money, note string, refund func() exception.Exception) exception.Exception
+	Reject(id int, note string) exception.Exception
+	Receive(returnRequest *entity.ReturnRequest, restock bool, note string) exception.Exception
+}
@@ -0,0 +1,302 @@
+package rma_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/money"
+	"fashion-api/rma/rma_repo"
+	"fmt"
+	"log"
+)
+
+type rmaPg struct {
+	db *sql.DB
+}
+
+const (
+	addReturnQuery = `insert into return_request (order_id, transaction_id, user_id, qty, reason) values ($1, $2, $3, $4, $5)`
+
+	fetchReturnsQuery = `select id, order_id, transaction_id, user_id, qty, reason, status, refund_amount, (select t.currency from transaction as t where t.id = return_request.transaction_id), restocked, coalesce(admin_note, ''), created_at, updated_at from return_request order by created_at desc`
+
+	fetchReturnsByUserIdQuery = `select id, order_id, transaction_id, user_id, qty, reason, status, refund_amount, (select t.currency from transaction as t where t.id = return_request.transaction_id), restocked, coalesce(admin_note, ''), created_at, updated_at from return_request where user_id = $1 order by created_at desc`
+
+	fetchReturnByIdQuery = `select id, order_id, transaction_id, user_id, qty, reason, status, refund_amount, (select t.currency from transaction as t where t.id = return_request.transaction_id), restocked, coalesce(admin_note, ''), created_at, updated_at from return_request where id = $1`
+
+	fetchReturnedQtyQuery = `select coalesce(sum(qty), 0) from return_request where order_id = $1 and status <> 'rejected'`
+
+	approveReturnQuery = `update return_request set status = 'approved', refund_amount = $2, admin_note = $3, updated_at = now() where id = $1 and status = 'requested'`
+
+	rejectReturnQuery = `update return_request set status = 'rejected', admin_note = $2, updated_at = now() where id = $1 and status = 'requested'`
+
+	receiveReturnQuery = `update return_request set status = 'received', restocked = $2, admin_note = coalesce(nullif($3, ''), admin_note), updated_at = now() where id = $1 and status = 'approved'`
+
+	// returned units go back to the location most of the order shipped
+	// from, or the primary location for orders older than locations
+	restockReturnQuery = `with target as (
+			select o.product_id, coalesce(
+				(select location_id from order_allocation where order_id = o.id order by qty desc, location_id asc limit 1),
+				(select id from location where active order by priority asc, id asc limit 1)
+			) as location_id from "order" as o where o.id = $1
+		), moved as (
+			insert into stock_level (product_id, location_id, stock) select product_id, location_id, $2 from target
+			on conflict (product_id, location_id) do update set stock = stock_level.stock + excluded.stock
+			returning product_id, location_id, stock, reserved
+		), aggregated as (
+			update product set stock = stock + $2, sold = greatest(sold - $2, 0), updated_at = now() where id = (select product_id from target)
+		)
+		insert into stock_movement (product_id, location_id, type, qty, stock_after, reserved_after, reason, reference)
+		select product_id, location_id, 'return', $2, stock, reserved, 'return received', $3 from moved`
+)
+
+func NewRmaPg(db *sql.DB) rma_repo.RmaRepo {
+	return &rmaPg{
+		db: db,
+	}
+}
+
+// Add implements rma_repo.RmaRepo.
+func (pg *rmaPg) Add(returnRequest *entity.ReturnRequest) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	stmt, err := tx.Prepare(addReturnQuery)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := stmt.Exec(
+		returnRequest.OrderId,
+		returnRequest.TransactionId,
+		returnRequest.UserId,
+		returnRequest.Qty,
+		returnRequest.Reason,
+	); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Fetch implements rma_repo.RmaRepo.
+func (pg *rmaPg) Fetch() ([]*entity.ReturnRequest, exception.Exception) {
+
+	rows, err := pg.db.Query(fetchReturnsQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return scanReturnRequests(rows)
+}
+
+// FetchByUserId implements rma_repo.RmaRepo.
+func (pg *rmaPg) FetchByUserId(userId int) ([]*entity.ReturnRequest, exception.Exception) {
+
+	rows, err := pg.db.Query(fetchReturnsByUserIdQuery, userId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return scanReturnRequests(rows)
+}
+
+// FetchById implements rma_repo.RmaRepo.
+func (pg *rmaPg) FetchById(id int) (*entity.ReturnRequest, exception.Exception) {
+
+	returnRequest := entity.ReturnRequest{}
+
+	if err := pg.db.QueryRow(fetchReturnByIdQuery, id).Scan(
+		&returnRequest.Id,
+		&returnRequest.OrderId,
+		&returnRequest.TransactionId,
+		&returnRequest.UserId,
+		&returnRequest.Qty,
+		&returnRequest.Reason,
+		&returnRequest.Status,
+		&returnRequest.RefundAmount.Amount,
+		&returnRequest.RefundAmount.Currency,
+		&returnRequest.Restocked,
+		&returnRequest.AdminNote,
+		&returnRequest.CreatedAt,
+		&returnRequest.UpdatedAt,
+	); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("return request not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return &returnRequest, nil
+}
+
+// FetchReturnedQty implements rma_repo.RmaRepo.
+func (pg *rmaPg) FetchReturnedQty(orderId int) (int, exception.Exception) {
+
+	qty := 0
+
+	if err := pg.db.QueryRow(fetchReturnedQtyQuery, orderId).Scan(&qty); err != nil {
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return qty, nil
+}
+
+// Approve implements rma_repo.RmaRepo. The row stays locked while refund
+// runs, so a concurrent approval waits and then finds it processed.
+func (pg *rmaPg) Approve(id int, refundAmount money.Money, note string, refund func() exception.Exception) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	res, err := tx.Exec(approveReturnQuery, id, refundAmount.Amount, note)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewConflictError("return request has already been processed")
+	}
+
+	if err := refund(); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("return request #%d was refunded but its approval couldn't be saved: %s", id, err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Reject implements rma_repo.RmaRepo.
+func (pg *rmaPg) Reject(id int, note string) exception.Exception {
+	return pg.transition(rejectReturnQuery, id, note)
+}
+
+// Receive implements rma_repo.RmaRepo.
+func (pg *rmaPg) Receive(returnRequest *entity.ReturnRequest, restock bool, note string) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	res, err := tx.Exec(receiveReturnQuery, returnRequest.Id, restock, note)
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return exception.NewConflictError("return request can no longer be received")
+	}
+
+	if restock {
+		if _, err := tx.Exec(restockReturnQuery, returnRequest.OrderId, returnRequest.Qty, fmt.Sprintf("return #%d", returnRequest.Id)); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+func (pg *rmaPg) transition(query string, args ...any) exception.Exception {
+
+	res, err := pg.db.Exec(query, args...)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return exception.NewConflictError("return request has already been processed")
+	}
+
+	return nil
+}
+
+func scanReturnRequests(rows *sql.Rows) ([]*entity.ReturnRequest, exception.Exception) {
+
+	defer rows.Close()
+
+	returnRequests := []*entity.ReturnRequest{}
+
+	for rows.Next() {
+
+		returnRequest := entity.ReturnRequest{}
+
+		if err := rows.Scan(
+			&returnRequest.Id,
+			&returnRequest.OrderId,
+			&returnRequest.TransactionId,
+			&returnRequest.UserId,
+			&returnRequest.Qty,
+			&returnRequest.Reason,
+			&returnRequest.Status,
+			&returnRequest.RefundAmount.Amount,
+			&returnRequest.RefundAmount.Currency,
+			&returnRequest.Restocked,
+			&returnRequest.AdminNote,
+			&returnRequest.CreatedAt,
+			&returnRequest.UpdatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		returnRequests = append(returnRequests, &returnRequest)
+	}
+
+	return returnRequests, nil
+}
@@ -0,0 +1,230 @@
+package rma_service
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/order/order_repo"
+	"fashion-api/payment/payment_service"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"fashion-api/rma/rma_repo"
+	"fashion-api/transaction/transaction_repo"
+	"fashion-api/wishlist/wishlist_job"
+	"fmt"
+	"net/http"
+)
+
+type rmaService struct {
+	rr rma_repo.RmaRepo
+	or order_repo.OrderRepo
+	tr transaction_repo.TransactionRepo
+	ps payment_service.PaymentService
+	bj wishlist_job.BackInStockJob
+}
+
+type RmaService interface {
+	Add(userId int, payload *dto.AddReturnPayload) (*helper.ResponseBody, exception.Exception)
+	CustomersReturn(userId int) (*helper.ResponseBody, exception.Exception)
+	Fetch() (*helper.ResponseBody, exception.Exception)
+	Approve(id int, payload *dto.ApproveReturnPayload) (*helper.ResponseBody, exception.Exception)
+	Reject(id int, payload *dto.RejectReturnPayload) (*helper.ResponseBody, exception.Exception)
+	Receive(id int, payload *dto.ReceiveReturnPayload) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewRmaService(rr rma_repo.RmaRepo, or order_repo.OrderRepo, tr transaction_repo.TransactionRepo, ps payment_service.PaymentService, bj wishlist_job.BackInStockJob) RmaService {
+	return &rmaService{
+		rr: rr,
+		or: or,
+		tr: tr,
+		ps: ps,
+		bj: bj,
+	}
+}
+
+// Add implements RmaService.
+func (rs *rmaService) Add(userId int, payload *dto.AddReturnPayload) (*helper.ResponseBody, exception.Exception) {
+
+	order, err := rs.or.FetchOrderById(payload.OrderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserId != userId {
+		return nil, exception.NewUnauthorizedError("you're not authorized to access this order")
+	}
+
+	if order.Status != entity.OrderStatusDelivered {
+		return nil, exception.NewBadRequestError("order isn't eligible for return")
+	}
+
+	transaction, err := rs.tr.FetchByOrderId(order.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	returnedQty, err := rs.rr.FetchReturnedQty(order.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Qty < 1 || payload.Qty > order.Qty-returnedQty {
+		return nil, exception.NewBadRequestError("qty is greater than returnable qty")
+	}
+
+	if err := rs.rr.Add(&entity.ReturnRequest{
+		OrderId:       order.Id,
+		TransactionId: transaction.Id,
+		UserId:        userId,
+		Qty:           payload.Qty,
+		Reason:        payload.Reason,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "return request successfully added",
+		Data:    nil,
+	}, nil
+}
+
+// CustomersReturn implements RmaService.
+func (rs *rmaService) CustomersReturn(userId int) (*helper.ResponseBody, exception.Exception) {
+
+	data, err := rs.rr.FetchByUserId(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "return requests successfully fetched",
+		Data:    data,
+	}, nil
+}
+
+// Fetch implements RmaService.
+func (rs *rmaService) Fetch() (*helper.ResponseBody, exception.Exception) {
+
+	data, err := rs.rr.Fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "return requests successfully fetched",
+		Data:    data,
+	}, nil
+}
+
+// Approve implements RmaService.
+func (rs *rmaService) Approve(id int, payload *dto.ApproveReturnPayload) (*helper.ResponseBody, exception.Exception) {
+
+	returnRequest, err := rs.rr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if returnRequest.Status != entity.ReturnStatusRequested {
+		return nil, exception.NewConflictError("return request has already been processed")
+	}
+
+	transaction, err := rs.tr.FetchByOrderId(returnRequest.OrderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// without an explicit amount the returned units are refunded at the
+	// price the customer paid for them, as recorded on the transaction
+	refundAmount := money.New(payload.RefundAmount, transaction.Currency)
+
+	if refundAmount.IsZero() {
+		for _, item := range transaction.Items {
+			refundAmount = refundAmount.Add(item.Total.MulDiv(returnRequest.Qty, item.Qty))
+		}
+	}
+
+	if refundAmount.IsNegative() {
+		return nil, exception.NewBadRequestError("refund amount can't be negative")
+	}
+
+	// the approval is claimed first so concurrent approvals can't both refund
+	refund := func() exception.Exception {
+
+		if !refundAmount.IsPositive() {
+			return nil
+		}
+
+		_, err := rs.ps.Refund(returnRequest.TransactionId, refundAmount, fmt.Sprintf("return request #%d", returnRequest.Id))
+
+		return err
+	}
+
+	if err := rs.rr.Approve(id, refundAmount, payload.Note, refund); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "return request successfully approved",
+		Data:    nil,
+	}, nil
+}
+
+// Reject implements RmaService.
+func (rs *rmaService) Reject(id int, payload *dto.RejectReturnPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := rs.rr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	if err := rs.rr.Reject(id, payload.Note); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "return request successfully rejected",
+		Data:    nil,
+	}, nil
+}
+
+// Receive implements RmaService.
+func (rs *rmaService) Receive(id int, payload *dto.ReceiveReturnPayload) (*helper.ResponseBody, exception.Exception) {
+
+	returnRequest, err := rs.rr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rs.rr.Receive(returnRequest, payload.Restock, payload.Note); err != nil {
+		return nil, err
+	}
+
+	if payload.Restock {
+
+		order, err := rs.or.FetchOrderById(returnRequest.OrderId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		rs.bj.Queue(order.ProductId)
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "return request successfully received",
+		Data:    nil,
+	}, nil
+}
@@ -0,0 +1,116 @@
+package shipment_handler
+
+import (
+	"encoding/json"
+	"fashion-api/dto"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/shipment/shipment_service"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type shipmentHandler struct {
+	ss shipment_service.ShipmentService
+}
+
+type ShipmentHandler interface {
+	Add(w http.ResponseWriter, r *http.Request)
+	FetchByOrderId(w http.ResponseWriter, r *http.Request)
+	Modify(w http.ResponseWriter, r *http.Request)
+}
+
+func NewShipmentHandler(ss shipment_service.ShipmentService) ShipmentHandler {
+	return &shipmentHandler{
+		ss: ss,
+	}
+}
+
+// Add implements ShipmentHandler.
+func (sh *shipmentHandler) Add(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	orderId, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	payload := &dto.AddShipmentPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := sh.ss.Add(orderId, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// FetchByOrderId implements ShipmentHandler.
+func (sh *shipmentHandler) FetchByOrderId(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	orderId, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	res, err := sh.ss.FetchByOrderId(orderId)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// Modify implements ShipmentHandler.
+func (sh *shipmentHandler) Modify(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+
+	payload := &dto.ModifyShipmentPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		err := exception.NewUnprocessableEntityError("invalid JSON body request")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := sh.ss.Modify(id, payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
@@ -0,0 +1,14 @@
+package shipment_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type ShipmentRepo interface {
+	Add(shipment *entity.Shipment) exception.Exception
+	FetchById(id int) (*entity.Shipment, exception.Exception)
+	FetchByOrderId(orderId int) ([]*entity.Shipment, exception.Exception)
+	FetchShippedQty(orderId int) (int, exception.Exception)
+	Modify(shipment *entity.Shipment) exception.Exception
+}
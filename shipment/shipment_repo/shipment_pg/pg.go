@@ -0,0 +1,264 @@
+package shipment_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/shipment/shipment_repo"
+	"log"
+)
+
+type shipmentPg struct {
+	db *sql.DB
+}
+
+const (
+	lockOrderQuery = `select qty from "order" where id = $1 for update`
+
+	fetchShippedQtyQuery = `select coalesce(sum(si.qty), 0) from shipment_item as si join shipment as s on si.shipment_id = s.id where s.order_id = $1`
+
+	addShipmentQuery = `insert into shipment (order_id, carrier, tracking_number, status, shipped_at) values ($1, $2, $3, $4, $5) returning id, created_at, updated_at`
+
+	addShipmentItemQuery = `insert into shipment_item (shipment_id, product_id, qty) values ($1, $2, $3) returning id`
+
+	fetchShipmentsByOrderIdQuery = `select id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at from shipment where order_id = $1 order by shipped_at asc, id asc`
+
+	fetchShipmentByIdQuery = `select id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at from shipment where id = $1`
+
+	fetchShipmentItemsQuery = `select si.id, si.shipment_id, si.product_id, si.qty from shipment_item as si join shipment as s on si.shipment_id = s.id where s.order_id = $1 order by si.id`
+
+	modifyShipmentQuery = `update shipment set carrier = $2, tracking_number = $3, status = $4, delivered_at = $5, updated_at = now() where id = $1`
+
+	// an order is shipped once its whole qty went out and delivered once
+	// every shipment arrived, partially shipped orders stay paid
+	syncOrderStatusQuery = `update "order" as o set status = case
+			when s.shipped >= o.qty and s.delivered then 'delivered'
+			when s.shipped >= o.qty then 'shipped'
+			else 'paid'
+		end, updated_at = now()
+		from (select coalesce(sum(si.qty), 0) as shipped, coalesce(bool_and(sh.status = 'delivered'), false) as delivered from shipment as sh join shipment_item as si on si.shipment_id = sh.id where sh.order_id = $1) as s
+		where o.id = $1 and o.status in ('paid', 'shipped', 'delivered')`
+)
+
+func NewShipmentPg(db *sql.DB) shipment_repo.ShipmentRepo {
+	return &shipmentPg{
+		db: db,
+	}
+}
+
+// Add implements shipment_repo.ShipmentRepo.
+func (pg *shipmentPg) Add(shipment *entity.Shipment) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	// the order row is locked so two shipments can't both take the last
+	// unshipped items
+	orderQty, shippedQty := 0, 0
+
+	if err := tx.QueryRow(lockOrderQuery, shipment.OrderId).Scan(&orderQty); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.QueryRow(fetchShippedQtyQuery, shipment.OrderId).Scan(&shippedQty); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	for _, item := range shipment.Items {
+		shippedQty += item.Qty
+	}
+
+	if shippedQty > orderQty {
+		tx.Rollback()
+		return exception.NewBadRequestError("qty is greater than unshipped qty")
+	}
+
+	if err := tx.QueryRow(
+		addShipmentQuery,
+		shipment.OrderId,
+		shipment.Carrier,
+		shipment.TrackingNumber,
+		shipment.Status,
+		shipment.ShippedAt,
+	).Scan(&shipment.Id, &shipment.CreatedAt, &shipment.UpdatedAt); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	for _, item := range shipment.Items {
+
+		item.ShipmentId = shipment.Id
+
+		if err := tx.QueryRow(addShipmentItemQuery, shipment.Id, item.ProductId, item.Qty).Scan(&item.Id); err != nil {
+			tx.Rollback()
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+	}
+
+	if _, err := tx.Exec(syncOrderStatusQuery, shipment.OrderId); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchById implements shipment_repo.ShipmentRepo.
+func (pg *shipmentPg) FetchById(id int) (*entity.Shipment, exception.Exception) {
+
+	shipment, err := scanShipment(pg.db.QueryRow(fetchShipmentByIdQuery, id))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("shipment not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := pg.loadItems(shipment.OrderId, shipment); err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// FetchByOrderId implements shipment_repo.ShipmentRepo.
+func (pg *shipmentPg) FetchByOrderId(orderId int) ([]*entity.Shipment, exception.Exception) {
+
+	shipments := []*entity.Shipment{}
+
+	rows, err := pg.db.Query(fetchShipmentsByOrderIdQuery, orderId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		shipment, err := scanShipment(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		shipments = append(shipments, shipment)
+	}
+
+	if err := pg.loadItems(orderId, shipments...); err != nil {
+		return nil, err
+	}
+
+	return shipments, nil
+}
+
+// FetchShippedQty implements shipment_repo.ShipmentRepo.
+func (pg *shipmentPg) FetchShippedQty(orderId int) (int, exception.Exception) {
+
+	qty := 0
+
+	if err := pg.db.QueryRow(fetchShippedQtyQuery, orderId).Scan(&qty); err != nil {
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return qty, nil
+}
+
+// Modify implements shipment_repo.ShipmentRepo.
+func (pg *shipmentPg) Modify(shipment *entity.Shipment) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(
+		modifyShipmentQuery,
+		shipment.Id,
+		shipment.Carrier,
+		shipment.TrackingNumber,
+		shipment.Status,
+		shipment.DeliveredAt,
+	); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(syncOrderStatusQuery, shipment.OrderId); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+func (pg *shipmentPg) loadItems(orderId int, shipments ...*entity.Shipment) exception.Exception {
+
+	byId := map[int]*entity.Shipment{}
+
+	for _, shipment := range shipments {
+		byId[shipment.Id] = shipment
+	}
+
+	rows, err := pg.db.Query(fetchShipmentItemsQuery, orderId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		item := entity.ShipmentItem{}
+
+		if err := rows.Scan(&item.Id, &item.ShipmentId, &item.ProductId, &item.Qty); err != nil {
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+
+		if shipment, ok := byId[item.ShipmentId]; ok {
+			shipment.Items = append(shipment.Items, &item)
+		}
+	}
+
+	return nil
+}
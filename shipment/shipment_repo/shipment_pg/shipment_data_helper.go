@@ -0,0 +1,36 @@
+package shipment_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShipment(row scanner) (*entity.Shipment, error) {
+
+	shipment := entity.Shipment{Items: []*entity.ShipmentItem{}}
+	deliveredAt := sql.NullTime{}
+
+	if err := row.Scan(
+		&shipment.Id,
+		&shipment.OrderId,
+		&shipment.Carrier,
+		&shipment.TrackingNumber,
+		&shipment.Status,
+		&shipment.ShippedAt,
+		&deliveredAt,
+		&shipment.CreatedAt,
+		&shipment.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if deliveredAt.Valid {
+		shipment.DeliveredAt = &deliveredAt.Time
+	}
+
+	return &shipment, nil
+}
@@ -0,0 +1,180 @@
+package shipment_service
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/notification/notification_job"
+	"fashion-api/order/order_repo"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/shipment/shipment_repo"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type shipmentService struct {
+	sr shipment_repo.ShipmentRepo
+	or order_repo.OrderRepo
+	nj notification_job.NotificationJob
+}
+
+type ShipmentService interface {
+	Add(orderId int, payload *dto.AddShipmentPayload) (*helper.ResponseBody, exception.Exception)
+	FetchByOrderId(orderId int) (*helper.ResponseBody, exception.Exception)
+	Modify(id int, payload *dto.ModifyShipmentPayload) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewShipmentService(sr shipment_repo.ShipmentRepo, or order_repo.OrderRepo, nj notification_job.NotificationJob) ShipmentService {
+	return &shipmentService{
+		sr: sr,
+		or: or,
+		nj: nj,
+	}
+}
+
+// Add implements ShipmentService.
+func (ss *shipmentService) Add(orderId int, payload *dto.AddShipmentPayload) (*helper.ResponseBody, exception.Exception) {
+
+	order, err := ss.or.FetchOrderById(orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch order.Status {
+	case entity.OrderStatusPaid:
+	case entity.OrderStatusShipped, entity.OrderStatusDelivered:
+		return nil, exception.NewConflictError("order has already been shipped")
+	default:
+		return nil, exception.NewBadRequestError("order hasn't been paid")
+	}
+
+	shippedQty, err := ss.sr.FetchShippedQty(order.Id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*entity.ShipmentItem{}
+
+	for _, item := range payload.Items {
+
+		if item.ProductId != order.ProductId {
+			return nil, exception.NewBadRequestError("product isn't part of this order")
+		}
+
+		if item.Qty < 1 {
+			return nil, exception.NewBadRequestError("Qty must be greater than zero")
+		}
+
+		items = append(items, &entity.ShipmentItem{
+			ProductId: item.ProductId,
+			Qty:       item.Qty,
+		})
+	}
+
+	// without items the shipment takes everything that hasn't gone out yet
+	if len(items) == 0 {
+		items = append(items, &entity.ShipmentItem{
+			ProductId: order.ProductId,
+			Qty:       order.Qty - shippedQty,
+		})
+	}
+
+	shipment := &entity.Shipment{
+		OrderId:        order.Id,
+		Carrier:        strings.TrimSpace(payload.Carrier),
+		TrackingNumber: strings.TrimSpace(payload.TrackingNumber),
+		Status:         entity.ShipmentStatusShipped,
+		Items:          items,
+		ShippedAt:      time.Now(),
+	}
+
+	if payload.ShippedAt != nil {
+		shipment.ShippedAt = *payload.ShippedAt
+	}
+
+	if err := ss.sr.Add(shipment); err != nil {
+		return nil, err
+	}
+
+	ss.nj.Queue(&entity.Notification{
+		Kind:   entity.NotificationShipped,
+		UserId: order.UserId,
+		Data:   shipment,
+	})
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "shipment successfully added",
+		Data:    shipment,
+	}, nil
+}
+
+// FetchByOrderId implements ShipmentService.
+func (ss *shipmentService) FetchByOrderId(orderId int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ss.or.FetchOrderById(orderId); err != nil {
+		return nil, err
+	}
+
+	shipments, err := ss.sr.FetchByOrderId(orderId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipments successfully fetched",
+		Data:    shipments,
+	}, nil
+}
+
+// Modify implements ShipmentService.
+func (ss *shipmentService) Modify(id int, payload *dto.ModifyShipmentPayload) (*helper.ResponseBody, exception.Exception) {
+
+	shipment, err := ss.sr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if payload.Carrier != "" {
+		shipment.Carrier = strings.TrimSpace(payload.Carrier)
+	}
+
+	if payload.TrackingNumber != "" {
+		shipment.TrackingNumber = strings.TrimSpace(payload.TrackingNumber)
+	}
+
+	if payload.Status != "" && payload.Status != shipment.Status {
+
+		if !shipment.CanMoveTo(payload.Status) {
+			return nil, exception.NewBadRequestError("shipment can't move from " + shipment.Status + " to " + payload.Status)
+		}
+
+		shipment.Status = payload.Status
+
+		if shipment.Status == entity.ShipmentStatusDelivered {
+			deliveredAt := time.Now()
+
+			if payload.DeliveredAt != nil {
+				deliveredAt = *payload.DeliveredAt
+			}
+
+			shipment.DeliveredAt = &deliveredAt
+		}
+	}
+
+	if err := ss.sr.Modify(shipment); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipment successfully modified",
+		Data:    shipment,
+	}, nil
+}
@@ -0,0 +1,20 @@
+package shipping_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type ShippingRepo interface {
+	AddZone(zone *entity.ShippingZone) exception.Exception
+	FetchZones() ([]*entity.ShippingZone, exception.Exception)
+	FetchZoneById(id int) (*entity.ShippingZone, exception.Exception)
+	ModifyZone(id int, zone *entity.ShippingZone) exception.Exception
+	DeleteZone(id int) exception.Exception
+	AddMethod(method *entity.ShippingMethod) exception.Exception
+	FetchMethods() ([]*entity.ShippingMethod, exception.Exception)
+	FetchMethodById(id int) (*entity.ShippingMethod, exception.Exception)
+	FetchMethodsForAddress(country string, region string) ([]*entity.ShippingMethod, exception.Exception)
+	ModifyMethod(id int, method *entity.ShippingMethod) exception.Exception
+	DeleteMethod(id int) exception.Exception
+}
@@ -0,0 +1,353 @@
+package shipping_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/shipping/shipping_repo"
+	"log"
+)
+
+type shippingPg struct {
+	db *sql.DB
+}
+
+const (
+	addZoneQuery = `insert into shipping_zone (name) values ($1) returning id`
+
+	addZoneLocationQuery = `insert into shipping_zone_location (zone_id, country, region) values ($1, upper($2), $3)`
+
+	fetchZonesQuery = `select id, name, created_at, updated_at from shipping_zone where deleted_at is null order by name asc`
+
+	fetchZoneByIdQuery = `select id, name, created_at, updated_at from shipping_zone where id = $1 and deleted_at is null`
+
+	fetchZoneLocationsQuery = `select zone_id, country, region from shipping_zone_location order by country asc, region asc`
+
+	modifyZoneQuery = `update shipping_zone set name = $2, updated_at = now() where id = $1`
+
+	deleteZoneLocationsQuery = `delete from shipping_zone_location where zone_id = $1`
+
+	deleteZoneQuery = `update shipping_zone set deleted_at = now(), updated_at = now() where id = $1`
+
+	deactivateZoneMethodsQuery = `update shipping_method set active = false, deleted_at = now(), updated_at = now() where zone_id = $1 and deleted_at is null`
+
+	shippingMethodColumns = `m.id, m.zone_id, m.name, m.type, m.currency, m.rate, m.per_kg_rate, m.free_threshold, m.max_weight_grams, m.active, m.created_at, m.updated_at`
+
+	addMethodQuery = `insert into shipping_method (zone_id, name, type, currency, rate, per_kg_rate, free_threshold, max_weight_grams, active) values ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	fetchMethodsQuery = `select ` + shippingMethodColumns + ` from shipping_method as m where m.deleted_at is null order by m.zone_id asc, m.rate asc`
+
+	fetchMethodByIdQuery = `select ` + shippingMethodColumns + ` from shipping_method as m where m.id = $1 and m.deleted_at is null`
+
+	// a zone location without a region covers the whole country
+	fetchMethodsForAddressQuery = `select ` + shippingMethodColumns + ` from shipping_method as m join shipping_zone as z on m.zone_id = z.id
+		where m.active and m.deleted_at is null and z.deleted_at is null
+		and exists (select 1 from shipping_zone_location as l where l.zone_id = z.id and l.country = upper($1) and (l.region = '' or upper(l.region) = upper($2)))
+		order by m.rate asc, m.id asc`
+
+	modifyMethodQuery = `update shipping_method set zone_id = $2, name = $3, type = $4, currency = $5, rate = $6, per_kg_rate = $7, free_threshold = $8, max_weight_grams = $9, active = $10, updated_at = now() where id = $1`
+
+	deleteMethodQuery = `update shipping_method set active = false, deleted_at = now(), updated_at = now() where id = $1`
+)
+
+func NewShippingPg(db *sql.DB) shipping_repo.ShippingRepo {
+	return &shippingPg{
+		db: db,
+	}
+}
+
+// AddZone implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) AddZone(zone *entity.ShippingZone) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.QueryRow(addZoneQuery, zone.Name).Scan(&zone.Id); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := addZoneLocations(tx, zone); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchZones implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) FetchZones() ([]*entity.ShippingZone, exception.Exception) {
+
+	zones := []*entity.ShippingZone{}
+
+	rows, err := pg.db.Query(fetchZonesQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		zone := entity.ShippingZone{Locations: []*entity.ShippingZoneLocation{}}
+
+		if err := rows.Scan(&zone.Id, &zone.Name, &zone.CreatedAt, &zone.UpdatedAt); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		zones = append(zones, &zone)
+	}
+
+	if err := pg.loadLocations(zones...); err != nil {
+		return nil, err
+	}
+
+	return zones, nil
+}
+
+// FetchZoneById implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) FetchZoneById(id int) (*entity.ShippingZone, exception.Exception) {
+
+	zone := entity.ShippingZone{Locations: []*entity.ShippingZoneLocation{}}
+
+	if err := pg.db.QueryRow(fetchZoneByIdQuery, id).Scan(&zone.Id, &zone.Name, &zone.CreatedAt, &zone.UpdatedAt); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("shipping zone not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := pg.loadLocations(&zone); err != nil {
+		return nil, err
+	}
+
+	return &zone, nil
+}
+
+// ModifyZone implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) ModifyZone(id int, zone *entity.ShippingZone) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	zone.Id = id
+
+	if _, err := tx.Exec(modifyZoneQuery, id, zone.Name); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(deleteZoneLocationsQuery, id); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := addZoneLocations(tx, zone); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// DeleteZone implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) DeleteZone(id int) exception.Exception {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	// the methods go with their zone, orders keep referencing them
+	if _, err := tx.Exec(deactivateZoneMethodsQuery, id); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(deleteZoneQuery, id); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// AddMethod implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) AddMethod(method *entity.ShippingMethod) exception.Exception {
+
+	if _, err := pg.db.Exec(addMethodQuery, methodArgs(method)...); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// FetchMethods implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) FetchMethods() ([]*entity.ShippingMethod, exception.Exception) {
+	return pg.fetchMethods(fetchMethodsQuery)
+}
+
+// FetchMethodById implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) FetchMethodById(id int) (*entity.ShippingMethod, exception.Exception) {
+
+	method, err := scanShippingMethod(pg.db.QueryRow(fetchMethodByIdQuery, id))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("shipping method not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return method, nil
+}
+
+// FetchMethodsForAddress implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) FetchMethodsForAddress(country string, region string) ([]*entity.ShippingMethod, exception.Exception) {
+	return pg.fetchMethods(fetchMethodsForAddressQuery, country, region)
+}
+
+// ModifyMethod implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) ModifyMethod(id int, method *entity.ShippingMethod) exception.Exception {
+
+	if _, err := pg.db.Exec(modifyMethodQuery, append([]any{id}, methodArgs(method)...)...); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// DeleteMethod implements shipping_repo.ShippingRepo.
+func (pg *shippingPg) DeleteMethod(id int) exception.Exception {
+
+	if _, err := pg.db.Exec(deleteMethodQuery, id); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+func (pg *shippingPg) fetchMethods(query string, args ...any) ([]*entity.ShippingMethod, exception.Exception) {
+
+	methods := []*entity.ShippingMethod{}
+
+	rows, err := pg.db.Query(query, args...)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		method, err := scanShippingMethod(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		methods = append(methods, method)
+	}
+
+	return methods, nil
+}
+
+func (pg *shippingPg) loadLocations(zones ...*entity.ShippingZone) exception.Exception {
+
+	byId := map[int]*entity.ShippingZone{}
+
+	for _, zone := range zones {
+		byId[zone.Id] = zone
+	}
+
+	rows, err := pg.db.Query(fetchZoneLocationsQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		zoneId, location := 0, entity.ShippingZoneLocation{}
+
+		if err := rows.Scan(&zoneId, &location.Country, &location.Region); err != nil {
+			log.Println(err.Error())
+			return exception.NewInternalServerError("something went wrong")
+		}
+
+		if zone, ok := byId[zoneId]; ok {
+			zone.Locations = append(zone.Locations, &location)
+		}
+	}
+
+	return nil
+}
+
+func addZoneLocations(tx *sql.Tx, zone *entity.ShippingZone) error {
+
+	for _, location := range zone.Locations {
+		if _, err := tx.Exec(addZoneLocationQuery, zone.Id, location.Country, location.Region); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package shipping_pg
+
+import "fashion-api/entity"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShippingMethod(row scanner) (*entity.ShippingMethod, error) {
+
+	method := entity.ShippingMethod{}
+
+	if err := row.Scan(
+		&method.Id,
+		&method.ZoneId,
+		&method.Name,
+		&method.Type,
+		&method.Currency,
+		&method.Rate,
+		&method.PerKgRate,
+		&method.FreeThreshold,
+		&method.MaxWeightGrams,
+		&method.Active,
+		&method.CreatedAt,
+		&method.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &method, nil
+}
+
+func methodArgs(method *entity.ShippingMethod) []any {
+	return []any{
+		method.ZoneId,
+		method.Name,
+		method.Type,
+		method.Currency,
+		method.Rate,
+		method.PerKgRate,
+		method.FreeThreshold,
+		method.MaxWeightGrams,
+		method.Active,
+	}
+}
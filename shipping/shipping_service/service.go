@@ -0,0 +1,432 @@
+package shipping_service
+
+import (
+	"fashion-api/currency/currency_service"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/infra/config"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/pkg/money"
+	"fashion-api/product/product_repo"
+	"fashion-api/shipping/shipping_repo"
+	"net/http"
+	"strings"
+)
+
+type shippingService struct {
+	sr shipping_repo.ShippingRepo
+	pr product_repo.ProductRepo
+	cs currency_service.CurrencyService
+}
+
+type ShippingService interface {
+	AddZone(payload *dto.ShippingZonePayload) (*helper.ResponseBody, exception.Exception)
+	FetchZones() (*helper.ResponseBody, exception.Exception)
+	FetchZoneById(id int) (*helper.ResponseBody, exception.Exception)
+	ModifyZone(id int, payload *dto.ShippingZonePayload) (*helper.ResponseBody, exception.Exception)
+	DeleteZone(id int) (*helper.ResponseBody, exception.Exception)
+	AddMethod(payload *dto.ShippingMethodPayload) (*helper.ResponseBody, exception.Exception)
+	FetchMethods() (*helper.ResponseBody, exception.Exception)
+	FetchMethodById(id int) (*helper.ResponseBody, exception.Exception)
+	ModifyMethod(id int, payload *dto.ShippingMethodPayload) (*helper.ResponseBody, exception.Exception)
+	DeleteMethod(id int) (*helper.ResponseBody, exception.Exception)
+	Rates(currency string, payload *dto.ShippingRatesPayload) (*helper.ResponseBody, exception.Exception)
+	Quote(address *entity.Address, parcel *entity.Parcel) ([]*entity.ShippingQuote, exception.Exception)
+	Select(address *entity.Address, parcel *entity.Parcel, methodId int) (*entity.ShippingQuote, exception.Exception)
+}
+
+func NewShippingService(sr shipping_repo.ShippingRepo, pr product_repo.ProductRepo, cs currency_service.CurrencyService) ShippingService {
+	return &shippingService{
+		sr: sr,
+		pr: pr,
+		cs: cs,
+	}
+}
+
+// AddZone implements ShippingService.
+func (ss *shippingService) AddZone(payload *dto.ShippingZonePayload) (*helper.ResponseBody, exception.Exception) {
+
+	if err := ss.sr.AddZone(payloadToZone(payload)); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "shipping zone successfully added",
+		Data:    nil,
+	}, nil
+}
+
+// FetchZones implements ShippingService.
+func (ss *shippingService) FetchZones() (*helper.ResponseBody, exception.Exception) {
+
+	zones, err := ss.sr.FetchZones()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping zones successfully fetched",
+		Data:    zones,
+	}, nil
+}
+
+// FetchZoneById implements ShippingService.
+func (ss *shippingService) FetchZoneById(id int) (*helper.ResponseBody, exception.Exception) {
+
+	zone, err := ss.sr.FetchZoneById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping zone successfully fetched",
+		Data:    zone,
+	}, nil
+}
+
+// ModifyZone implements ShippingService.
+func (ss *shippingService) ModifyZone(id int, payload *dto.ShippingZonePayload) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ss.sr.FetchZoneById(id); err != nil {
+		return nil, err
+	}
+
+	if err := ss.sr.ModifyZone(id, payloadToZone(payload)); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping zone successfully modified",
+		Data:    nil,
+	}, nil
+}
+
+// DeleteZone implements ShippingService.
+func (ss *shippingService) DeleteZone(id int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ss.sr.FetchZoneById(id); err != nil {
+		return nil, err
+	}
+
+	if err := ss.sr.DeleteZone(id); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping zone successfully deleted",
+		Data:    nil,
+	}, nil
+}
+
+// AddMethod implements ShippingService.
+func (ss *shippingService) AddMethod(payload *dto.ShippingMethodPayload) (*helper.ResponseBody, exception.Exception) {
+
+	method, err := ss.payloadToMethod(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ss.sr.AddMethod(method); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "shipping method successfully added",
+		Data:    nil,
+	}, nil
+}
+
+// FetchMethods implements ShippingService.
+func (ss *shippingService) FetchMethods() (*helper.ResponseBody, exception.Exception) {
+
+	methods, err := ss.sr.FetchMethods()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping methods successfully fetched",
+		Data:    methods,
+	}, nil
+}
+
+// FetchMethodById implements ShippingService.
+func (ss *shippingService) FetchMethodById(id int) (*helper.ResponseBody, exception.Exception) {
+
+	method, err := ss.sr.FetchMethodById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping method successfully fetched",
+		Data:    method,
+	}, nil
+}
+
+// ModifyMethod implements ShippingService.
+func (ss *shippingService) ModifyMethod(id int, payload *dto.ShippingMethodPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ss.sr.FetchMethodById(id); err != nil {
+		return nil, err
+	}
+
+	method, err := ss.payloadToMethod(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ss.sr.ModifyMethod(id, method); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping method successfully modified",
+		Data:    nil,
+	}, nil
+}
+
+// DeleteMethod implements ShippingService.
+func (ss *shippingService) DeleteMethod(id int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ss.sr.FetchMethodById(id); err != nil {
+		return nil, err
+	}
+
+	if err := ss.sr.DeleteMethod(id); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping method successfully deleted",
+		Data:    nil,
+	}, nil
+}
+
+// Rates implements ShippingService.
+func (ss *shippingService) Rates(currency string, payload *dto.ShippingRatesPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if currency == "" {
+		currency = config.NewAppConfig().BaseCurrency
+	}
+
+	parcel := &entity.Parcel{Subtotal: money.New(0, currency)}
+	products := []*entity.Product{}
+
+	for _, item := range payload.Items {
+
+		if item.Qty < 1 {
+			return nil, exception.NewBadRequestError("Qty must be greater than zero")
+		}
+
+		product, err := ss.pr.FetchById(item.ProductId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		products = append(products, product)
+	}
+
+	if err := ss.cs.LocalizeAll(products, currency); err != nil {
+		return nil, err
+	}
+
+	for i, item := range payload.Items {
+		parcel.WeightGrams += products[i].ChargeableWeight() * item.Qty
+		parcel.Subtotal = parcel.Subtotal.Add(products[i].EffectivePrice.Mul(item.Qty))
+	}
+
+	quotes, err := ss.Quote(payloadToAddress(payload.ShippingAddress), parcel)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "shipping rates successfully fetched",
+		Data: &dto.ShippingRatesData{
+			Parcel:  parcel,
+			Options: quotes,
+		},
+	}, nil
+}
+
+// Quote implements ShippingService.
+func (ss *shippingService) Quote(address *entity.Address, parcel *entity.Parcel) ([]*entity.ShippingQuote, exception.Exception) {
+
+	quotes := []*entity.ShippingQuote{}
+
+	methods, err := ss.sr.FetchMethodsForAddress(address.Country, address.Region)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var converter *money.Converter
+
+	for _, method := range methods {
+
+		if !method.Accepts(parcel) {
+			continue
+		}
+
+		if converter == nil && method.Currency != parcel.Subtotal.Currency {
+			if converter, err = ss.cs.Converter(); err != nil {
+				return nil, err
+			}
+		}
+
+		localized, err := localizeMethod(converter, method, parcel.Subtotal.Currency)
+
+		if err != nil {
+			return nil, err
+		}
+
+		quotes = append(quotes, &entity.ShippingQuote{
+			MethodId:    method.Id,
+			Name:        method.Name,
+			Type:        method.Type,
+			WeightGrams: parcel.WeightGrams,
+			Cost:        localized.Cost(parcel),
+		})
+	}
+
+	return quotes, nil
+}
+
+// Select implements ShippingService.
+func (ss *shippingService) Select(address *entity.Address, parcel *entity.Parcel, methodId int) (*entity.ShippingQuote, exception.Exception) {
+
+	quotes, err := ss.Quote(address, parcel)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(quotes) == 0 {
+		return nil, exception.NewBadRequestError("we don't ship to this address")
+	}
+
+	if methodId == 0 {
+		return nil, exception.NewBadRequestError("Shipping method id can't be empty")
+	}
+
+	for _, quote := range quotes {
+		if quote.MethodId == methodId {
+			return quote, nil
+		}
+	}
+
+	return nil, exception.NewBadRequestError("shipping method isn't available for this address")
+}
+
+// localizeMethod returns a copy of the method with its amounts converted
+// into the currency being quoted in.
+func localizeMethod(converter *money.Converter, method *entity.ShippingMethod, currency string) (*entity.ShippingMethod, exception.Exception) {
+
+	if method.Currency == currency {
+		return method, nil
+	}
+
+	localized := *method
+	localized.Currency = currency
+
+	for _, amount := range []*int{&localized.Rate, &localized.PerKgRate, &localized.FreeThreshold} {
+
+		converted, err := converter.Convert(money.New(int64(*amount), method.Currency), currency)
+
+		if err != nil {
+			return nil, exception.NewBadRequestError("no exchange rate between " + method.Currency + " and " + currency)
+		}
+
+		*amount = int(converted.Amount)
+	}
+
+	return &localized, nil
+}
+
+func (ss *shippingService) payloadToMethod(payload *dto.ShippingMethodPayload) (*entity.ShippingMethod, exception.Exception) {
+
+	if payload.Rate < 0 || payload.PerKgRate < 0 || payload.FreeThreshold < 0 || payload.MaxWeightGrams < 0 {
+		return nil, exception.NewBadRequestError("Rates, free threshold and max weight can't be negative")
+	}
+
+	if payload.Type == entity.ShippingMethodFreeOver && payload.FreeThreshold == 0 {
+		return nil, exception.NewBadRequestError("Free threshold can't be empty")
+	}
+
+	currency := strings.ToUpper(payload.Currency)
+
+	if currency == "" {
+		currency = config.NewAppConfig().BaseCurrency
+	}
+
+	if !money.IsSupported(currency) {
+		return nil, exception.NewBadRequestError("Currency " + currency + " is not supported")
+	}
+
+	if _, err := ss.sr.FetchZoneById(payload.ZoneId); err != nil {
+		return nil, err
+	}
+
+	return &entity.ShippingMethod{
+		ZoneId:         payload.ZoneId,
+		Name:           payload.Name,
+		Type:           payload.Type,
+		Currency:       currency,
+		Rate:           payload.Rate,
+		PerKgRate:      payload.PerKgRate,
+		FreeThreshold:  payload.FreeThreshold,
+		MaxWeightGrams: payload.MaxWeightGrams,
+		Active:         payload.Active,
+	}, nil
+}
+
+func payloadToZone(payload *dto.ShippingZonePayload) *entity.ShippingZone {
+
+	zone := &entity.ShippingZone{
+		Name:      payload.Name,
+		Locations: []*entity.ShippingZoneLocation{},
+	}
+
+	for _, location := range payload.Locations {
+		zone.Locations = append(zone.Locations, &entity.ShippingZoneLocation{
+			Country: strings.ToUpper(location.Country),
+			Region:  strings.TrimSpace(location.Region),
+		})
+	}
+
+	return zone
+}
+
+// payloadToAddress turns a checkout address payload into the entity the
+// shipping quotes work with.
+func payloadToAddress(payload *dto.AddressPayload) *entity.Address {
+	return &entity.Address{
+		Recipient:  payload.Recipient,
+		Line1:      payload.Line1,
+		City:       payload.City,
+		Region:     payload.Region,
+		PostalCode: payload.PostalCode,
+		Country:    strings.ToUpper(payload.Country),
+	}
+}
@@ -0,0 +1,22 @@
+package tax_calculator
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/tax/tax_repo"
+)
+
+// TaxCalculator works out the tax lines of a transaction whose items are
+// already priced and discounted. An external tax service can be plugged in
+// by implementing it and registering it in NewTaxCalculator.
+type TaxCalculator interface {
+	Name() string
+	Calculate(address *entity.Address, transaction *entity.Transaction) ([]*entity.TaxLine, exception.Exception)
+}
+
+func NewTaxCalculator(name string, tr tax_repo.TaxRepo) TaxCalculator {
+	switch name {
+	default:
+		return NewTableCalculator(tr)
+	}
+}
@@ -0,0 +1,83 @@
+package tax_calculator
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/tax/tax_repo"
+)
+
+type tableCalculator struct {
+	tr tax_repo.TaxRepo
+}
+
+// NewTableCalculator returns a calculator driven by the tax rates admins
+// maintain under /admin/tax-rates.
+func NewTableCalculator(tr tax_repo.TaxRepo) TaxCalculator {
+	return &tableCalculator{
+		tr: tr,
+	}
+}
+
+// Name implements TaxCalculator.
+func (tc *tableCalculator) Name() string {
+	return "table"
+}
+
+// Calculate implements TaxCalculator.
+func (tc *tableCalculator) Calculate(address *entity.Address, transaction *entity.Transaction) ([]*entity.TaxLine, exception.Exception) {
+
+	if address == nil || address.Country == "" {
+		return nil, exception.NewBadRequestError("shipping country is required to calculate tax")
+	}
+
+	rates, err := tc.tr.FetchApplicable(address.Country, address.Region)
+
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []*entity.TaxLine{}
+
+	for _, item := range transaction.Items {
+
+		applicable := []*entity.TaxRate{}
+		includedRate := 0
+
+		for _, rate := range rates {
+			if rate.TaxClass == item.TaxClass {
+				applicable = append(applicable, rate)
+
+				if rate.Inclusive {
+					includedRate += rate.Rate
+				}
+			}
+		}
+
+		taxable := item.UnitPrice.Mul(item.Qty).Sub(item.Discount)
+
+		for _, rate := range applicable {
+
+			line := &entity.TaxLine{
+				OrderId:       transaction.OrderId,
+				ProductId:     item.ProductId,
+				TaxRateId:     rate.Id,
+				Name:          rate.Name,
+				Rate:          rate.Rate,
+				Inclusive:     rate.Inclusive,
+				TaxableAmount: taxable,
+			}
+
+			// an inclusive price already carries every inclusive rate, so
+			// each one is backed out of the gross amount together
+			if rate.Inclusive {
+				line.Amount = taxable.MulDivRound(rate.Rate, 10000+includedRate)
+			} else {
+				line.Amount = taxable.MulDivRound(rate.Rate, 10000)
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,15 @@
+package tax_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type TaxRepo interface {
+	Add(rate *entity.TaxRate) exception.Exception
+	Fetch() ([]*entity.TaxRate, exception.Exception)
+	FetchById(id int) (*entity.TaxRate, exception.Exception)
+	FetchApplicable(country string, region string) ([]*entity.TaxRate, exception.Exception)
+	Modify(id int, rate *entity.TaxRate) exception.Exception
+	Delete(id int) exception.Exception
+}
@@ -0,0 +1,128 @@
+package tax_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/tax/tax_repo"
+	"log"
+)
+
+type taxPg struct {
+	db *sql.DB
+}
+
+const (
+	taxRateColumns = `id, name, country, region, tax_class, rate, inclusive, active, created_at, updated_at`
+
+	addTaxRateQuery = `insert into tax_rate (name, country, region, tax_class, rate, inclusive, active) values ($1, $2, $3, $4, $5, $6, $7)`
+
+	fetchTaxRatesQuery = `select ` + taxRateColumns + ` from tax_rate where deleted_at is null order by country asc, region asc, tax_class asc`
+
+	fetchTaxRateByIdQuery = `select ` + taxRateColumns + ` from tax_rate where id = $1 and deleted_at is null`
+
+	// a rate without a region covers the whole country
+	// a region's own rate replaces the country-wide rate of the same tax
+	// class instead of stacking on top of it
+	fetchApplicableTaxRatesQuery = `select ` + taxRateColumns + ` from tax_rate as tr where active and deleted_at is null and country = upper($1) and (region = '' or upper(region) = upper($2)) and not (region = '' and exists (select 1 from tax_rate as r where r.active and r.deleted_at is null and r.country = tr.country and r.tax_class = tr.tax_class and r.region <> '' and upper(r.region) = upper($2))) order by id asc`
+
+	modifyTaxRateQuery = `update tax_rate set name = $2, country = $3, region = $4, tax_class = $5, rate = $6, inclusive = $7, active = $8, updated_at = now() where id = $1`
+
+	deleteTaxRateQuery = `update tax_rate set active = false, deleted_at = now(), updated_at = now() where id = $1`
+)
+
+func NewTaxPg(db *sql.DB) tax_repo.TaxRepo {
+	return &taxPg{
+		db: db,
+	}
+}
+
+// Add implements tax_repo.TaxRepo.
+func (pg *taxPg) Add(rate *entity.TaxRate) exception.Exception {
+
+	if _, err := pg.db.Exec(addTaxRateQuery, rate.Name, rate.Country, rate.Region, rate.TaxClass, rate.Rate, rate.Inclusive, rate.Active); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Fetch implements tax_repo.TaxRepo.
+func (pg *taxPg) Fetch() ([]*entity.TaxRate, exception.Exception) {
+	return pg.fetchMany(fetchTaxRatesQuery)
+}
+
+// FetchById implements tax_repo.TaxRepo.
+func (pg *taxPg) FetchById(id int) (*entity.TaxRate, exception.Exception) {
+
+	rate, err := scanTaxRate(pg.db.QueryRow(fetchTaxRateByIdQuery, id))
+
+	if err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("tax rate not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	return rate, nil
+}
+
+// FetchApplicable implements tax_repo.TaxRepo.
+func (pg *taxPg) FetchApplicable(country string, region string) ([]*entity.TaxRate, exception.Exception) {
+	return pg.fetchMany(fetchApplicableTaxRatesQuery, country, region)
+}
+
+// Modify implements tax_repo.TaxRepo.
+func (pg *taxPg) Modify(id int, rate *entity.TaxRate) exception.Exception {
+
+	if _, err := pg.db.Exec(modifyTaxRateQuery, id, rate.Name, rate.Country, rate.Region, rate.TaxClass, rate.Rate, rate.Inclusive, rate.Active); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Delete implements tax_repo.TaxRepo.
+func (pg *taxPg) Delete(id int) exception.Exception {
+
+	if _, err := pg.db.Exec(deleteTaxRateQuery, id); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+func (pg *taxPg) fetchMany(query string, args ...any) ([]*entity.TaxRate, exception.Exception) {
+
+	rates := []*entity.TaxRate{}
+
+	rows, err := pg.db.Query(query, args...)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		rate, err := scanTaxRate(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
@@ -0,0 +1,29 @@
+package tax_pg
+
+import "fashion-api/entity"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTaxRate(row scanner) (*entity.TaxRate, error) {
+
+	rate := entity.TaxRate{}
+
+	if err := row.Scan(
+		&rate.Id,
+		&rate.Name,
+		&rate.Country,
+		&rate.Region,
+		&rate.TaxClass,
+		&rate.Rate,
+		&rate.Inclusive,
+		&rate.Active,
+		&rate.CreatedAt,
+		&rate.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &rate, nil
+}
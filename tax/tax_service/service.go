@@ -0,0 +1,146 @@
+package tax_service
+
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/tax/tax_repo"
+	"net/http"
+	"strings"
+)
+
+type taxService struct {
+	tr tax_repo.TaxRepo
+}
+
+type TaxService interface {
+	Add(payload *dto.TaxRatePayload) (*helper.ResponseBody, exception.Exception)
+	Fetch() (*helper.ResponseBody, exception.Exception)
+	FetchById(id int) (*helper.ResponseBody, exception.Exception)
+	Modify(id int, payload *dto.TaxRatePayload) (*helper.ResponseBody, exception.Exception)
+	Delete(id int) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewTaxService(tr tax_repo.TaxRepo) TaxService {
+	return &taxService{
+		tr: tr,
+	}
+}
+
+// Add implements TaxService.
+func (ts *taxService) Add(payload *dto.TaxRatePayload) (*helper.ResponseBody, exception.Exception) {
+
+	rate, err := payloadToTaxRate(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ts.tr.Add(rate); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "tax rate successfully added",
+		Data:    nil,
+	}, nil
+}
+
+// Fetch implements TaxService.
+func (ts *taxService) Fetch() (*helper.ResponseBody, exception.Exception) {
+
+	rates, err := ts.tr.Fetch()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "tax rates successfully fetched",
+		Data:    rates,
+	}, nil
+}
+
+// FetchById implements TaxService.
+func (ts *taxService) FetchById(id int) (*helper.ResponseBody, exception.Exception) {
+
+	rate, err := ts.tr.FetchById(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "tax rate successfully fetched",
+		Data:    rate,
+	}, nil
+}
+
+// Modify implements TaxService.
+func (ts *taxService) Modify(id int, payload *dto.TaxRatePayload) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ts.tr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	rate, err := payloadToTaxRate(payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ts.tr.Modify(id, rate); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "tax rate successfully modified",
+		Data:    nil,
+	}, nil
+}
+
+// Delete implements TaxService.
+func (ts *taxService) Delete(id int) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ts.tr.FetchById(id); err != nil {
+		return nil, err
+	}
+
+	if err := ts.tr.Delete(id); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "tax rate successfully deleted",
+		Data:    nil,
+	}, nil
+}
+
+func payloadToTaxRate(payload *dto.TaxRatePayload) (*entity.TaxRate, exception.Exception) {
+
+	if payload.Rate < 0 || payload.Rate > 10000 {
+		return nil, exception.NewBadRequestError("Rate must be between 0 and 10000 basis points")
+	}
+
+	taxClass := strings.ToLower(strings.TrimSpace(payload.TaxClass))
+
+	if taxClass == "" {
+		taxClass = entity.TaxClassStandard
+	}
+
+	return &entity.TaxRate{
+		Name:      payload.Name,
+		Country:   strings.ToUpper(payload.Country),
+		Region:    strings.TrimSpace(payload.Region),
+		TaxClass:  taxClass,
+		Rate:      payload.Rate,
+		Inclusive: payload.Inclusive,
+		Active:    payload.Active,
+	}, nil
+}
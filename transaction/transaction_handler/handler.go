@@ -23,6 +23,7 @@ type TransactionHandler interface {
 	FetchTransactionById(w http.ResponseWriter, r *http.Request)
 	CustomersTransaction(w http.ResponseWriter, r *http.Request)
 	FetchAllTransaction(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
 }
 
 func NewTransactionHandler(ts transaction_service.TransactionService) TransactionHandler {
@@ -73,7 +74,15 @@ func (th *transactionHandler) CustomersTransaction(w http.ResponseWriter, r *htt
 
 	user := r.Context().Value("userData").(*entity.User)
 
-	res, err := th.ts.CustomersTransaction(user.Id)
+	query, err := helper.HistoryQuery(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := th.ts.CustomersTransaction(user.Id, query)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -109,7 +118,15 @@ func (th *transactionHandler) FetchAllTransaction(w http.ResponseWriter, r *http
 
 	w.Header().Set("Content-Type", "application/json")
 
-	res, err := th.ts.FetchAllTransaction()
+	query, err := helper.TransactionQuery(r)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := th.ts.FetchAllTransaction(query)
 
 	if err != nil {
 		w.WriteHeader(err.Status())
@@ -120,3 +137,20 @@ func (th *transactionHandler) FetchAllTransaction(w http.ResponseWriter, r *http
 	w.WriteHeader(res.Status)
 	w.Write(helper.ResponseJSON(res))
 }
+
+// Export implements TransactionHandler.
+func (th *transactionHandler) Export(w http.ResponseWriter, r *http.Request) {
+
+	query, err := helper.TransactionQuery(r)
+
+	if err == nil {
+		// the CSV is streamed by the service, only errors are JSON
+		err = th.ts.Export(w, query)
+	}
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+	}
+}
@@ -1,10 +1,16 @@
 package transaction_repo
 
-import "time"
+import (
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/money"
+	"time"
+)
 
 type ProductMapped struct {
-	Id   int    `json:"id"`
-	Name string `json:"name"`
+	Id    int         `json:"id"`
+	Name  string      `json:"name"`
+	Price money.Money `json:"price"`
 }
 
 type UserMapped struct {
@@ -13,11 +19,30 @@ type UserMapped struct {
 }
 
 type TransactionWithProductsAndUserMapped struct {
-	Id         int           `json:"id"`
-	Product    ProductMapped `json:"product"`
-	User       UserMapped    `json:"user"`
-	Qty        int           `json:"qty"`
-	TotalPrice int           `json:"total_price"`
-	CreatedAt  time.Time     `json:"created_at"`
-	UpdatedAt  time.Time     `json:"updated_at"`
+	Id         int               `json:"id"`
+	Product    ProductMapped     `json:"product"`
+	User       UserMapped        `json:"user"`
+	Qty        int               `json:"qty"`
+	Currency   string            `json:"currency"`
+	Subtotal   money.Money       `json:"subtotal"`
+	Discount   money.Money       `json:"discount"`
+	Tax        money.Money       `json:"tax"`
+	Shipping   money.Money       `json:"shipping"`
+	TotalPrice money.Money       `json:"total_price"`
+	TaxLines   []*entity.TaxLine `json:"tax_lines,omitempty"`
+	Status     string            `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// TransactionPage is a page of the admin transaction list, NextCursor is
+// empty on the last page.
+type TransactionPage struct {
+	Transactions []*TransactionWithProductsAndUserMapped `json:"transactions"`
+	NextCursor   string                                  `json:"next_cursor"`
+}
+
+type CustomerTransactionPage struct {
+	Transactions []*TransactionWithProductsAndUserMapped `json:"transactions"`
+	Pagination   *dto.PageInfo                           `json:"pagination"`
 }
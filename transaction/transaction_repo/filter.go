@@ -0,0 +1,21 @@
+package transaction_repo
+
+import "time"
+
+// TransactionFilter narrows a transaction list. Transactions come newest
+// first, the admin list pages by continuing after the one identified by
+// AfterCreatedAt and AfterId, customer histories by Offset.
+type TransactionFilter struct {
+	From           *time.Time
+	To             *time.Time
+	UserId         int
+	ProductId      int
+	CategoryId     int
+	Status         string
+	MinAmount      int64
+	MaxAmount      int64
+	AfterCreatedAt time.Time
+	AfterId        int
+	Limit          int
+	Offset         int
+}
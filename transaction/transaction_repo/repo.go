@@ -6,9 +6,13 @@ import (
 )
 
 type TransactionRepo interface {
-	Add(transactionn *entity.Transaction) exception.Exception
-	CustomerTransaction(id int) ([]*TransactionWithProductsAndUserMapped, exception.Exception)
+	Add(transactionn *entity.Transaction, payment *entity.Payment) (*entity.Transaction, exception.Exception)
+	// CustomerTransaction and FetchTransactions return one row per item,
+	// the rows of a transaction next to each other. Limit and Offset count
+	// transactions, not rows.
+	CustomerTransaction(filter *TransactionFilter) ([]*TransactionWithProductsAndUserMapped, int, exception.Exception)
 	FetchUserId(id int) (*TransactionWithProductsAndUserMapped, exception.Exception)
-	FetchTransactions() ([]*TransactionWithProductsAndUserMapped, exception.Exception)
+	FetchTransactions(filter *TransactionFilter) ([]*TransactionWithProductsAndUserMapped, exception.Exception)
 	FetchTransactionById(id int) (*TransactionWithProductsAndUserMapped, exception.Exception)
+	FetchByOrderId(orderId int) (*entity.Transaction, exception.Exception)
 }
@@ -5,6 +5,7 @@ import (
 	"fashion-api/entity"
 	"fashion-api/pkg/exception"
 	"fashion-api/transaction/transaction_repo"
+	"fmt"
 	"log"
 )
 
@@ -13,17 +14,61 @@ type transactionPg struct {
 }
 
 const (
-	addTransactionQuery = `insert into transaction (user_id, order_id) values($1, $2)`
+	addTransactionQuery = `insert into transaction (user_id, order_id, status, currency, subtotal, discount_total, tax_total, shipping_total, grand_total) values($1, $2, $3, $4, $5, $6, $7, $8, $9) returning id, created_at, updated_at`
+
+	addTransactionItemQuery = `insert into transaction_item (transaction_id, product_id, product_name, category_id, tax_class, unit_price, qty, subtotal, discount, tax, tax_included, total) values($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	addTaxLineQuery = `insert into tax_line (order_id, transaction_id, product_id, tax_rate_id, name, rate, inclusive, taxable_amount, amount) values($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	// the promotion row is locked before redeeming it, so concurrent
+	// checkouts count each other's redemptions against the limits
+	lockPromotionQuery = `select id from promotion where id = $1 for update`
+
+	addPromotionRedemptionQuery = `insert into promotion_redemption (promotion_id, transaction_id, user_id, discount)
+		select p.id, $2, $3, $4 from promotion as p where p.id = $1
+		and (p.usage_limit = 0 or p.usage_limit > (select count(*) from promotion_redemption as r join transaction as t on r.transaction_id = t.id where r.promotion_id = p.id and t.status not in ('failed', 'cancelled')))
+		and (p.per_user_limit = 0 or p.per_user_limit > (select count(*) from promotion_redemption as r join transaction as t on r.transaction_id = t.id where r.promotion_id = p.id and r.user_id = $3 and t.status not in ('failed', 'cancelled')))`
+
+	addPaymentQuery = `insert into payment (transaction_id, provider, intent_id, amount, currency, status) values($1, $2, $3, $4, $5, $6)`
+
+	// the order takes the price it was checked out at, which replaces the
+	// one it was placed with
+	checkoutOrderQuery = `update "order" set status = 'awaiting_payment', total_price = $11, shipping_recipient = $2, shipping_line1 = $3, shipping_city = $4, shipping_region = $5, shipping_postal_code = $6, shipping_country = $7, shipping_method_id = $8, shipping_method_name = $9, shipping_cost = $10, updated_at = now() where id = $1 and status = 'pending' and deleted_at is null`
+
+	// nothing is inserted when the location doesn't have qty available
+	reserveLocationStockQuery = `with moved as (
+			update stock_level set reserved = reserved + $3 from "order" as o where o.id = $1 and stock_level.product_id = o.product_id and stock_level.location_id = $2 and stock_level.stock - stock_level.reserved >= $3
+			returning stock_level.product_id, stock_level.location_id, stock_level.stock, stock_level.reserved
+		), allocated as (
+			insert into order_allocation (order_id, location_id, qty) select $1, location_id, $3 from moved
+			on conflict (order_id, location_id) do update set qty = order_allocation.qty + excluded.qty
+		)
+		insert into stock_movement (product_id, location_id, type, reserved_qty, stock_after, reserved_after, reason, reference, actor_id)
+		select product_id, location_id, 'reservation', $3, stock, reserved, 'checkout', 'order #' || $1::int, $4 from moved`
+
+	reserveStockQuery = `update product set reserved = reserved + o.qty, updated_at = now() from "order" as o where o.id = $1 and product.id = o.product_id and product.stock - product.reserved >= o.qty`
+
+	fetchTaxLinesQuery = `select id, order_id, transaction_id, product_id, coalesce(tax_rate_id, 0), name, rate, inclusive, taxable_amount, amount, created_at from tax_line where transaction_id = $1 order by id`
 
 	fetchUserIdQuery = `select id, user_id from transaction where id = $1`
 
-	fetchAllCustomerTransactionQuery = `select t.id, o.product_id, p.name, o.qty, o.total_price, t.user_id, u.full_name, t.created_at, t.updated_at from transaction as t left join "user" as u on t.user_id = u.id left join "order" as o on t.order_id = o.id left join product as p on o.product_id = p.id where t.user_id = $1 and t.deleted_at is null order by created_at desc`
+	fetchByOrderIdQuery = `select id, user_id, order_id, status, currency, subtotal, discount_total, tax_total, shipping_total, grand_total, created_at, updated_at from transaction where order_id = $1 and status <> 'failed' and deleted_at is null order by created_at desc limit 1`
 
-	fetchAllTransactionQuery = `select t.id, o.product_id, p.name, o.qty, o.total_price, t.user_id, u.full_name, t.created_at, t.updated_at from transaction as t left join "user" as u on t.user_id = u.id left join "order" as o on t.order_id = o.id left join product as p on o.product_id = p.id where t.deleted_at is null order by created_at desc`
+	fetchTransactionItemsQuery = `select id, transaction_id, product_id, product_name, coalesce(category_id, 0), tax_class, unit_price, qty, subtotal, discount, tax, tax_included, total, created_at from transaction_item where transaction_id = $1 order by id`
 
-	fetchTransactionByIdQuery = `select t.id, o.product_id, p.name, o.qty, o.total_price, t.user_id, u.full_name, t.created_at, t.updated_at from transaction as t left join "user" as u on t.user_id = u.id left join "order" as o on t.order_id = o.id left join product as p on o.product_id = p.id where t.id = $1 and t.deleted_at is null order by created_at desc`
+	countTransactionQuery = `select count(*) from transaction as t where t.deleted_at is null`
 
-	updateStockAndSoldQuery = `update product set stock = stock - (select qty from "order" where id = $1), sold = sold + (select qty from "order" where id = $1), updated_at = now() where id = (select product_id from "order" where id = $1)`
+	// the page is cut on transactions before their items are joined, so a
+	// transaction with several items is never split or counted twice. %[1]s
+	// are the filter conditions and %[2]s the limit and offset.
+	fetchTransactionPageQuery = `with page as (
+			select t.id from transaction as t where t.deleted_at is null%[1]s order by t.created_at desc, t.id desc %[2]s
+		)
+		select t.id, ti.product_id, ti.product_name, ti.unit_price, ti.qty, t.subtotal, t.discount_total, t.tax_total, t.shipping_total, t.grand_total, t.currency, t.status, t.user_id, u.full_name, t.created_at, t.updated_at
+		from page as p join transaction as t on t.id = p.id left join "user" as u on t.user_id = u.id left join transaction_item as ti on ti.transaction_id = t.id
+		order by t.created_at desc, t.id desc, ti.id asc`
+
+	fetchTransactionByIdQuery = `select t.id, ti.product_id, ti.product_name, ti.unit_price, ti.qty, t.subtotal, t.discount_total, t.tax_total, t.shipping_total, t.grand_total, t.currency, t.status, t.user_id, u.full_name, t.created_at, t.updated_at from transaction as t left join "user" as u on t.user_id = u.id left join transaction_item as ti on ti.transaction_id = t.id where t.id = $1 and t.deleted_at is null`
 )
 
 func NewTransactionPg(db *sql.DB) transaction_repo.TransactionRepo {
@@ -33,97 +78,228 @@ func NewTransactionPg(db *sql.DB) transaction_repo.TransactionRepo {
 }
 
 // Add implements transaction_repo.TransactionRepo.
-func (pg *transactionPg) Add(transactionn *entity.Transaction) exception.Exception {
+func (pg *transactionPg) Add(transactionn *entity.Transaction, payment *entity.Payment) (*entity.Transaction, exception.Exception) {
 
 	tx, err := pg.db.Begin()
 
 	if err != nil {
 		log.Println(err.Error())
 		tx.Rollback()
-		return exception.NewInternalServerError("something went wrong")
+		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	stmt, err := tx.Prepare(addTransactionQuery)
+	address, method := transactionn.ShippingAddress, transactionn.ShippingMethod
+
+	if address == nil {
+		address = &entity.Address{}
+	}
+
+	if method == nil {
+		method = &entity.ShippingQuote{}
+	}
+
+	checkoutOrder, err := tx.Exec(
+		checkoutOrderQuery,
+		transactionn.OrderId,
+		address.Recipient,
+		address.Line1,
+		address.City,
+		address.Region,
+		address.PostalCode,
+		address.Country,
+		sql.NullInt64{Int64: int64(method.MethodId), Valid: method.MethodId != 0},
+		method.Name,
+		transactionn.ShippingTotal.Amount,
+		transactionn.Subtotal.Amount,
+	)
 
 	if err != nil {
 		log.Println(err.Error())
 		tx.Rollback()
-		return exception.NewInternalServerError("something went wrong")
+		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(transactionn.UserId, transactionn.OrderId); err != nil {
-		log.Println(err.Error())
+	if affected, _ := checkoutOrder.RowsAffected(); affected == 0 {
 		tx.Rollback()
-		return exception.NewInternalServerError("something went wrong")
+		return nil, exception.NewConflictError("order has already been checked out")
+	}
+
+	for _, allocation := range transactionn.Allocations {
+
+		reserveLocationStock, err := tx.Exec(reserveLocationStockQuery, transactionn.OrderId, allocation.LocationId, allocation.Qty, transactionn.UserId)
+
+		if err != nil {
+			log.Println(err.Error())
+			tx.Rollback()
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		if affected, _ := reserveLocationStock.RowsAffected(); affected == 0 {
+			tx.Rollback()
+			return nil, exception.NewBadRequestError("qty is greater than stock")
+		}
 	}
 
-	updateStockAndSold, err := tx.Prepare(updateStockAndSoldQuery)
+	reserveStock, err := tx.Exec(reserveStockQuery, transactionn.OrderId)
 
 	if err != nil {
 		log.Println(err.Error())
 		tx.Rollback()
-		return exception.NewInternalServerError("something went wrong")
+		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := updateStockAndSold.Exec(transactionn.OrderId); err != nil {
+	if affected, _ := reserveStock.RowsAffected(); affected == 0 {
+		tx.Rollback()
+		return nil, exception.NewBadRequestError("qty is greater than stock")
+	}
+
+	stmt, err := tx.Prepare(addTransactionQuery)
+
+	if err != nil {
 		log.Println(err.Error())
 		tx.Rollback()
-		return exception.NewInternalServerError("something went wrong")
+		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := stmt.QueryRow(
+		transactionn.UserId,
+		transactionn.OrderId,
+		transactionn.Status,
+		transactionn.Currency,
+		transactionn.Subtotal.Amount,
+		transactionn.DiscountTotal.Amount,
+		transactionn.TaxTotal.Amount,
+		transactionn.ShippingTotal.Amount,
+		transactionn.GrandTotal.Amount,
+	).Scan(
+		&transactionn.Id,
+		&transactionn.CreatedAt,
+		&transactionn.UpdatedAt,
+	); err != nil {
 		log.Println(err.Error())
 		tx.Rollback()
-		return exception.NewInternalServerError("something went wrong")
+		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	return nil
-}
+	for _, item := range transactionn.Items {
+
+		item.TransactionId = transactionn.Id
+
+		if _, err := tx.Exec(
+			addTransactionItemQuery,
+			item.TransactionId,
+			item.ProductId,
+			item.ProductName,
+			item.CategoryId,
+			item.TaxClass,
+			item.UnitPrice.Amount,
+			item.Qty,
+			item.Subtotal.Amount,
+			item.Discount.Amount,
+			item.Tax.Amount,
+			item.TaxIncluded.Amount,
+			item.Total.Amount,
+		); err != nil {
+			log.Println(err.Error())
+			tx.Rollback()
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+	}
 
-// CustomerTransaction implements transaction_repo.TransactionRepo.
-func (pg *transactionPg) CustomerTransaction(id int) ([]*transaction_repo.TransactionWithProductsAndUserMapped, exception.Exception) {
+	for _, line := range transactionn.TaxLines {
+
+		line.TransactionId = transactionn.Id
+
+		if _, err := tx.Exec(
+			addTaxLineQuery,
+			transactionn.OrderId,
+			line.TransactionId,
+			line.ProductId,
+			sql.NullInt64{Int64: int64(line.TaxRateId), Valid: line.TaxRateId != 0},
+			line.Name,
+			line.Rate,
+			line.Inclusive,
+			line.TaxableAmount.Amount,
+			line.Amount.Amount,
+		); err != nil {
+			log.Println(err.Error())
+			tx.Rollback()
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+	}
 
-	data := []*transaction_repo.TransactionWithProductsAndUserMapped{}
+	for _, promotion := range transactionn.Promotions {
+
+		if _, err := tx.Exec(lockPromotionQuery, promotion.PromotionId); err != nil {
+			log.Println(err.Error())
+			tx.Rollback()
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
 
-	stmt, err := pg.db.Prepare(fetchAllCustomerTransactionQuery)
+		res, err := tx.Exec(
+			addPromotionRedemptionQuery,
+			promotion.PromotionId,
+			transactionn.Id,
+			transactionn.UserId,
+			promotion.Discount.Amount,
+		)
 
-	if err != nil {
+		if err != nil {
+			log.Println(err.Error())
+			tx.Rollback()
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			tx.Rollback()
+			return nil, exception.NewBadRequestError("promotion " + promotion.Name + " has reached its usage limit")
+		}
+	}
+
+	if _, err := tx.Exec(
+		addPaymentQuery,
+		transactionn.Id,
+		payment.Provider,
+		payment.IntentId,
+		payment.Amount.Amount,
+		payment.Amount.Currency,
+		payment.Status,
+	); err != nil {
 		log.Println(err.Error())
+		tx.Rollback()
 		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	rows, err := stmt.Query(id)
-
-	if err != nil {
+	if err := tx.Commit(); err != nil {
 		log.Println(err.Error())
+		tx.Rollback()
 		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	defer rows.Close()
+	return transactionn, nil
+}
 
-	for rows.Next() {
+// CustomerTransaction implements transaction_repo.TransactionRepo.
+func (pg *transactionPg) CustomerTransaction(filter *transaction_repo.TransactionFilter) ([]*transaction_repo.TransactionWithProductsAndUserMapped, int, exception.Exception) {
 
-		transactionWithUserAndProduct := transaction_repo.TransactionWithProductsAndUserMapped{}
+	total := 0
 
-		if err := rows.Scan(
-			&transactionWithUserAndProduct.Id,
-			&transactionWithUserAndProduct.Product.Id,
-			&transactionWithUserAndProduct.Product.Name,
-			&transactionWithUserAndProduct.Qty,
-			&transactionWithUserAndProduct.TotalPrice,
-			&transactionWithUserAndProduct.User.Id,
-			&transactionWithUserAndProduct.User.FullName,
-			&transactionWithUserAndProduct.CreatedAt,
-			&transactionWithUserAndProduct.UpdatedAt,
-		); err != nil {
-			log.Println(err.Error())
-			return nil, exception.NewInternalServerError("something went wrong")
-		}
+	where, args := transactionFilterConditions(filter)
 
-		data = append(data, &transactionWithUserAndProduct)
+	if err := pg.db.QueryRow(countTransactionQuery+where, args...).Scan(&total); err != nil {
+		log.Println(err.Error())
+		return nil, 0, exception.NewInternalServerError("something went wrong")
 	}
 
-	return data, nil
+	args = append(args, filter.Limit, filter.Offset)
+
+	data, err := pg.fetchPage(fmt.Sprintf(fetchTransactionPageQuery, where, fmt.Sprintf("limit $%d offset $%d", len(args)-1, len(args))), args)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return data, total, nil
 }
 
 // FetchUserId implements transaction_repo.TransactionRepo.
@@ -152,8 +328,6 @@ func (pg *transactionPg) FetchUserId(id int) (*transaction_repo.TransactionWithP
 // FetchTransactionById implements transaction_repo.TransactionRepo.
 func (pg *transactionPg) FetchTransactionById(id int) (*transaction_repo.TransactionWithProductsAndUserMapped, exception.Exception) {
 
-	transactionWithUserAndProduct := transaction_repo.TransactionWithProductsAndUserMapped{}
-
 	stmt, err := pg.db.Prepare(fetchTransactionByIdQuery)
 
 	if err != nil {
@@ -161,30 +335,122 @@ func (pg *transactionPg) FetchTransactionById(id int) (*transaction_repo.Transac
 		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	if err := stmt.QueryRow(id).Scan(
-		&transactionWithUserAndProduct.Id,
-		&transactionWithUserAndProduct.Product.Id,
-		&transactionWithUserAndProduct.Product.Name,
-		&transactionWithUserAndProduct.Qty,
-		&transactionWithUserAndProduct.TotalPrice,
-		&transactionWithUserAndProduct.User.Id,
-		&transactionWithUserAndProduct.User.FullName,
-		&transactionWithUserAndProduct.CreatedAt,
-		&transactionWithUserAndProduct.UpdatedAt,
-	); err != nil {
+	transactionWithUserAndProduct, err := scanTransactionWithProductsAndUser(stmt.QueryRow(id))
+
+	if err != nil {
 		log.Println(err.Error())
 		return nil, exception.NewInternalServerError("something went wrong")
 	}
 
-	return &transactionWithUserAndProduct, nil
+	taxLines, errs := pg.fetchTaxLines(id, transactionWithUserAndProduct.Currency)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	transactionWithUserAndProduct.TaxLines = taxLines
+
+	return transactionWithUserAndProduct, nil
 }
 
 // FetchTransactions implements transaction_repo.TransactionRepo.
-func (pg *transactionPg) FetchTransactions() ([]*transaction_repo.TransactionWithProductsAndUserMapped, exception.Exception) {
+func (pg *transactionPg) FetchTransactions(filter *transaction_repo.TransactionFilter) ([]*transaction_repo.TransactionWithProductsAndUserMapped, exception.Exception) {
+
+	where, args := transactionFilterConditions(filter)
+
+	args = append(args, filter.Limit)
+
+	return pg.fetchPage(fmt.Sprintf(fetchTransactionPageQuery, where, fmt.Sprintf("limit $%d", len(args))), args)
+}
+
+func (pg *transactionPg) fetchPage(query string, args []any) ([]*transaction_repo.TransactionWithProductsAndUserMapped, exception.Exception) {
 
 	data := []*transaction_repo.TransactionWithProductsAndUserMapped{}
 
-	rows, err := pg.db.Query(fetchAllTransactionQuery)
+	rows, err := pg.db.Query(query, args...)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		transactionWithUserAndProduct, err := scanTransactionWithProductsAndUser(rows)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		data = append(data, transactionWithUserAndProduct)
+	}
+
+	return data, nil
+}
+
+// FetchByOrderId implements transaction_repo.TransactionRepo.
+func (pg *transactionPg) FetchByOrderId(orderId int) (*entity.Transaction, exception.Exception) {
+
+	transaction := entity.Transaction{}
+
+	stmt, err := pg.db.Prepare(fetchByOrderIdQuery)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer stmt.Close()
+
+	if err := stmt.QueryRow(orderId).Scan(
+		&transaction.Id,
+		&transaction.UserId,
+		&transaction.OrderId,
+		&transaction.Status,
+		&transaction.Currency,
+		&transaction.Subtotal.Amount,
+		&transaction.DiscountTotal.Amount,
+		&transaction.TaxTotal.Amount,
+		&transaction.ShippingTotal.Amount,
+		&transaction.GrandTotal.Amount,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return nil, exception.NewNotFoundError("transaction not found")
+		}
+
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	transaction.Subtotal.Currency = transaction.Currency
+	transaction.DiscountTotal.Currency = transaction.Currency
+	transaction.TaxTotal.Currency = transaction.Currency
+	transaction.ShippingTotal.Currency = transaction.Currency
+	transaction.GrandTotal.Currency = transaction.Currency
+
+	items, errs := pg.fetchItems(transaction.Id, transaction.Currency)
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	transaction.Items = items
+
+	return &transaction, nil
+}
+
+func (pg *transactionPg) fetchItems(transactionId int, currency string) ([]*entity.TransactionItem, exception.Exception) {
+
+	items := []*entity.TransactionItem{}
+
+	rows, err := pg.db.Query(fetchTransactionItemsQuery, transactionId)
 
 	if err != nil {
 		log.Println(err.Error())
@@ -195,25 +461,65 @@ func (pg *transactionPg) FetchTransactions() ([]*transaction_repo.TransactionWit
 
 	for rows.Next() {
 
-		transactionWithUserAndProduct := transaction_repo.TransactionWithProductsAndUserMapped{}
+		item := entity.TransactionItem{}
 
 		if err := rows.Scan(
-			&transactionWithUserAndProduct.Id,
-			&transactionWithUserAndProduct.Product.Id,
-			&transactionWithUserAndProduct.Product.Name,
-			&transactionWithUserAndProduct.Qty,
-			&transactionWithUserAndProduct.TotalPrice,
-			&transactionWithUserAndProduct.User.Id,
-			&transactionWithUserAndProduct.User.FullName,
-			&transactionWithUserAndProduct.CreatedAt,
-			&transactionWithUserAndProduct.UpdatedAt,
+			&item.Id,
+			&item.TransactionId,
+			&item.ProductId,
+			&item.ProductName,
+			&item.CategoryId,
+			&item.TaxClass,
+			&item.UnitPrice.Amount,
+			&item.Qty,
+			&item.Subtotal.Amount,
+			&item.Discount.Amount,
+			&item.Tax.Amount,
+			&item.TaxIncluded.Amount,
+			&item.Total.Amount,
+			&item.CreatedAt,
 		); err != nil {
 			log.Println(err.Error())
 			return nil, exception.NewInternalServerError("something went wrong")
 		}
 
-		data = append(data, &transactionWithUserAndProduct)
+		item.UnitPrice.Currency = currency
+		item.Subtotal.Currency = currency
+		item.Discount.Currency = currency
+		item.Tax.Currency = currency
+		item.TaxIncluded.Currency = currency
+		item.Total.Currency = currency
+
+		items = append(items, &item)
 	}
 
-	return data, nil
+	return items, nil
+}
+
+func (pg *transactionPg) fetchTaxLines(transactionId int, currency string) ([]*entity.TaxLine, exception.Exception) {
+
+	lines := []*entity.TaxLine{}
+
+	rows, err := pg.db.Query(fetchTaxLinesQuery, transactionId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		line, err := scanTaxLine(rows, currency)
+
+		if err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
 }
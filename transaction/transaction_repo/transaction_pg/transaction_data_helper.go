@@ -0,0 +1,131 @@
+package transaction_pg
+
+import (
+	"fashion-api/entity"
+	"fashion-api/transaction/transaction_repo"
+	"fmt"
+	"strings"
+)
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTransactionWithProductsAndUser(row scanner) (*transaction_repo.TransactionWithProductsAndUserMapped, error) {
+
+	transaction := transaction_repo.TransactionWithProductsAndUserMapped{}
+
+	if err := row.Scan(
+		&transaction.Id,
+		&transaction.Product.Id,
+		&transaction.Product.Name,
+		&transaction.Product.Price.Amount,
+		&transaction.Qty,
+		&transaction.Subtotal.Amount,
+		&transaction.Discount.Amount,
+		&transaction.Tax.Amount,
+		&transaction.Shipping.Amount,
+		&transaction.TotalPrice.Amount,
+		&transaction.Currency,
+		&transaction.Status,
+		&transaction.User.Id,
+		&transaction.User.FullName,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	transaction.Product.Price.Currency = transaction.Currency
+	transaction.Subtotal.Currency = transaction.Currency
+	transaction.Discount.Currency = transaction.Currency
+	transaction.Tax.Currency = transaction.Currency
+	transaction.Shipping.Currency = transaction.Currency
+	transaction.TotalPrice.Currency = transaction.Currency
+
+	return &transaction, nil
+}
+
+func scanTaxLine(row scanner, currency string) (*entity.TaxLine, error) {
+
+	line := entity.TaxLine{}
+
+	if err := row.Scan(
+		&line.Id,
+		&line.OrderId,
+		&line.TransactionId,
+		&line.ProductId,
+		&line.TaxRateId,
+		&line.Name,
+		&line.Rate,
+		&line.Inclusive,
+		&line.TaxableAmount.Amount,
+		&line.Amount.Amount,
+		&line.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	line.TaxableAmount.Currency = currency
+	line.Amount.Currency = currency
+
+	return &line, nil
+}
+
+// transactionFilterConditions turns the filter into "and ..." conditions
+// on the transaction alias t, with numbered placeholders matching the
+// returned arguments. Categories are the ones recorded on the items when
+// they were sold.
+func transactionFilterConditions(filter *transaction_repo.TransactionFilter) (string, []any) {
+
+	conditions, args := []string{}, []any{}
+
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.From != nil {
+		add("t.created_at >= $%d", *filter.From)
+	}
+
+	if filter.To != nil {
+		add("t.created_at < $%d", *filter.To)
+	}
+
+	if filter.UserId != 0 {
+		add("t.user_id = $%d", filter.UserId)
+	}
+
+	if filter.ProductId != 0 {
+		add("exists (select 1 from transaction_item where transaction_id = t.id and product_id = $%d)", filter.ProductId)
+	}
+
+	if filter.CategoryId != 0 {
+		add("exists (select 1 from transaction_item where transaction_id = t.id and category_id = $%d)", filter.CategoryId)
+	}
+
+	if filter.Status != "" {
+		add("t.status = $%d", filter.Status)
+	}
+
+	if filter.MinAmount > 0 {
+		add("t.grand_total >= $%d", filter.MinAmount)
+	}
+
+	if filter.MaxAmount > 0 {
+		add("t.grand_total <= $%d", filter.MaxAmount)
+	}
+
+	// keyset pagination, the list is ordered by created_at and id descending
+	if filter.AfterId != 0 {
+		args = append(args, filter.AfterCreatedAt, filter.AfterId)
+		conditions = append(conditions, fmt.Sprintf("(t.created_at, t.id) < ($%d::timestamptz, $%d::int)", len(args)-1, len(args)))
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+
+	return " and " + strings.Join(conditions, " and "), args
+}
@@ -0,0 +1,202 @@
+package transaction_service
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"fashion-api/dto"
+	"fashion-api/pkg/exception"
+	"fashion-api/transaction/transaction_repo"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// transactions are fetched this many at a time while exporting, so the
+// export never holds more than one batch in memory
+const exportBatchSize = 500
+
+// exportStatusTrailer tells whether the whole export was written. The status
+// is already sent when a later batch fails, so the failure also ends the
+// file with a row starting with exportFailedMarker.
+const (
+	exportStatusTrailer = "X-Export-Status"
+	exportFailedMarker  = "# export incomplete"
+)
+
+var transactionColumns = []string{"id", "created_at", "status", "user_id", "user_name", "product_id", "product_name", "qty", "currency", "subtotal", "discount", "tax", "shipping", "total"}
+
+// Export implements TransactionService.
+func (ts *transactionService) Export(w http.ResponseWriter, query *dto.TransactionQuery) exception.Exception {
+
+	filter, err := transactionFilter(query)
+
+	if err != nil {
+		return err
+	}
+
+	filter.Limit = exportBatchSize
+
+	transactions, err := ts.tr.FetchTransactions(filter)
+
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="transactions.csv"`)
+	w.Header().Set("Trailer", exportStatusTrailer)
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write(transactionColumns)
+
+	status := "complete"
+
+	for len(transactions) > 0 {
+
+		for _, transaction := range transactions {
+			writer.Write(transactionToRecord(transaction))
+		}
+
+		writer.Flush()
+
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if _, more := firstTransactions(transactions, exportBatchSize-1); !more {
+			break
+		}
+
+		last := transactions[len(transactions)-1]
+		filter.AfterCreatedAt, filter.AfterId = last.CreatedAt, last.Id
+
+		if transactions, err = ts.tr.FetchTransactions(filter); err != nil {
+			log.Println(err.Message())
+			status = "incomplete"
+			writer.Write([]string{exportFailedMarker, err.Message()})
+			break
+		}
+	}
+
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		log.Println(err.Error())
+		status = "incomplete"
+	}
+
+	w.Header().Set(exportStatusTrailer, status)
+
+	return nil
+}
+
+// firstTransactions returns the rows of the first n transactions and
+// whether rows of another transaction follow them.
+func firstTransactions(rows []*transaction_repo.TransactionWithProductsAndUserMapped, n int) ([]*transaction_repo.TransactionWithProductsAndUserMapped, bool) {
+
+	seen := 0
+
+	for i, row := range rows {
+
+		if i > 0 && row.Id == rows[i-1].Id {
+			continue
+		}
+
+		if seen++; seen > n {
+			return rows[:i], true
+		}
+	}
+
+	return rows, false
+}
+
+// transactionFilter turns the list query into a repo filter, continuing
+// after the cursor when one is given.
+func transactionFilter(query *dto.TransactionQuery) (*transaction_repo.TransactionFilter, exception.Exception) {
+
+	filter := &transaction_repo.TransactionFilter{
+		From:       query.From,
+		To:         query.To,
+		UserId:     query.UserId,
+		ProductId:  query.ProductId,
+		CategoryId: query.CategoryId,
+		Status:     query.Status,
+		MinAmount:  query.MinAmount,
+		MaxAmount:  query.MaxAmount,
+	}
+
+	if query.Cursor == "" {
+		return filter, nil
+	}
+
+	createdAt, id, ok := decodeTransactionCursor(query.Cursor)
+
+	if !ok {
+		return nil, exception.NewBadRequestError("cursor is invalid")
+	}
+
+	filter.AfterCreatedAt, filter.AfterId = createdAt, id
+
+	return filter, nil
+}
+
+// encodeTransactionCursor points after transaction, the cursor is opaque
+// to clients but holds the created_at microseconds and id the list is
+// ordered by.
+func encodeTransactionCursor(transaction *transaction_repo.TransactionWithProductsAndUserMapped) string {
+
+	value := strconv.FormatInt(transaction.CreatedAt.UnixMicro(), 10) + "." + strconv.Itoa(transaction.Id)
+
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func decodeTransactionCursor(cursor string) (time.Time, int, bool) {
+
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	micro, id, found := strings.Cut(string(value), ".")
+
+	if !found {
+		return time.Time{}, 0, false
+	}
+
+	createdAt, err := strconv.ParseInt(micro, 10, 64)
+
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+
+	parsedId, err := strconv.Atoi(id)
+
+	if err != nil || parsedId < 1 {
+		return time.Time{}, 0, false
+	}
+
+	return time.UnixMicro(createdAt), parsedId, true
+}
+
+func transactionToRecord(transaction *transaction_repo.TransactionWithProductsAndUserMapped) []string {
+	return []string{
+		strconv.Itoa(transaction.Id),
+		transaction.CreatedAt.Format(time.RFC3339),
+		transaction.Status,
+		strconv.Itoa(transaction.User.Id),
+		transaction.User.FullName,
+		strconv.Itoa(transaction.Product.Id),
+		transaction.Product.Name,
+		strconv.Itoa(transaction.Qty),
+		transaction.Currency,
+		transaction.Subtotal.Decimal(),
+		transaction.Discount.Decimal(),
+		transaction.Tax.Decimal(),
+		transaction.Shipping.Decimal(),
+		transaction.TotalPrice.Decimal(),
+	}
+}
@@ -1,13 +1,26 @@
 package transaction_service
 
 import (
+	"fashion-api/currency/currency_service"
 	"fashion-api/dto"
 	"fashion-api/entity"
+	"fashion-api/inventory/inventory_allocator"
+	"fashion-api/inventory/inventory_repo"
+	"fashion-api/notification/notification_job"
 	"fashion-api/order/order_repo"
+	"fashion-api/payment/payment_provider"
 	"fashion-api/pkg/exception"
 	"fashion-api/pkg/helper"
+	"fashion-api/product/product_repo"
+	"fashion-api/promotion/promotion_service"
+	"fashion-api/shipping/shipping_service"
+	"fashion-api/tax/tax_calculator"
 	"fashion-api/transaction/transaction_repo"
+	"fmt"
+	"log"
 	"strconv"
+	"strings"
+	"time"
 
 	"net/http"
 
@@ -15,22 +28,41 @@ import (
 )
 
 type transactionService struct {
-	tr transaction_repo.TransactionRepo
-	or order_repo.OrderRepo
+	tr  transaction_repo.TransactionRepo
+	or  order_repo.OrderRepo
+	pr  product_repo.ProductRepo
+	pms promotion_service.PromotionService
+	pp  payment_provider.PaymentProvider
+	cs  currency_service.CurrencyService
+	tc  tax_calculator.TaxCalculator
+	ss  shipping_service.ShippingService
+	ir  inventory_repo.InventoryRepo
+	al  inventory_allocator.Allocator
+	nj  notification_job.NotificationJob
 }
 
 type TransactionService interface {
 	Authorization(next http.Handler) http.Handler
 	Add(userId int, payload *dto.AddTransactionPayload) (*helper.ResponseBody, exception.Exception)
 	FetchTransactionById(id int) (*helper.ResponseBody, exception.Exception)
-	CustomersTransaction(userId int) (*helper.ResponseBody, exception.Exception)
-	FetchAllTransaction() (*helper.ResponseBody, exception.Exception)
+	CustomersTransaction(userId int, query *dto.HistoryQuery) (*helper.ResponseBody, exception.Exception)
+	FetchAllTransaction(query *dto.TransactionQuery) (*helper.ResponseBody, exception.Exception)
+	Export(w http.ResponseWriter, query *dto.TransactionQuery) exception.Exception
 }
 
-func NewTransactionService(tr transaction_repo.TransactionRepo, or order_repo.OrderRepo) TransactionService {
+func NewTransactionService(tr transaction_repo.TransactionRepo, or order_repo.OrderRepo, pr product_repo.ProductRepo, pms promotion_service.PromotionService, pp payment_provider.PaymentProvider, cs currency_service.CurrencyService, tc tax_calculator.TaxCalculator, ss shipping_service.ShippingService, ir inventory_repo.InventoryRepo, al inventory_allocator.Allocator, nj notification_job.NotificationJob) TransactionService {
 	return &transactionService{
-		tr: tr,
-		or: or,
+		tr:  tr,
+		or:  or,
+		pr:  pr,
+		pms: pms,
+		pp:  pp,
+		cs:  cs,
+		tc:  tc,
+		ss:  ss,
+		ir:  ir,
+		al:  al,
+		nj:  nj,
 	}
 }
 
@@ -47,17 +79,142 @@ func (ts *transactionService) Add(userId int, payload *dto.AddTransactionPayload
 		return nil, exception.NewUnauthorizedError("You're not authorized to access this order")
 	}
 
-	if err := ts.tr.Add(&entity.Transaction{
-		UserId:  userId,
-		OrderId: payload.OrderId,
-	}); err != nil {
+	if order.Status != entity.OrderStatusPending {
+		return nil, exception.NewConflictError("order has already been checked out")
+	}
+
+	product, err := ts.pr.FetchById(order.ProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// a pending order can sit for a long time, so it is priced again at
+	// checkout in the currency it was placed in, a sale that has ended since
+	// doesn't carry over
+	if err := ts.cs.Localize(product, order.TotalPrice.Currency); err != nil {
+		return nil, err
+	}
+
+	unitPrice := product.PriceAt(time.Now())
+
+	transaction := &entity.Transaction{
+		UserId:   userId,
+		OrderId:  payload.OrderId,
+		Status:   entity.TransactionStatusPending,
+		Currency: order.TotalPrice.Currency,
+		ShippingAddress: &entity.Address{
+			Recipient:  payload.ShippingAddress.Recipient,
+			Line1:      payload.ShippingAddress.Line1,
+			City:       payload.ShippingAddress.City,
+			Region:     payload.ShippingAddress.Region,
+			PostalCode: payload.ShippingAddress.PostalCode,
+			Country:    strings.ToUpper(payload.ShippingAddress.Country),
+		},
+		Items: []*entity.TransactionItem{
+			{
+				ProductId:   product.Id,
+				ProductName: product.Name,
+				CategoryId:  product.CategoryId,
+				TaxClass:    product.TaxClass,
+				UnitPrice:   unitPrice,
+				Qty:         order.Qty,
+			},
+		},
+	}
+
+	if !transaction.InCurrency() {
+		log.Printf("product #%d isn't priced in %s", product.Id, transaction.Currency)
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	levels, err := ts.ir.FetchStockLevels(product.Id)
+
+	if err != nil {
 		return nil, err
 	}
 
+	transaction.Allocations, err = ts.al.Allocate(levels, order.Qty, transaction.ShippingAddress)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ts.pms.Apply(userId, payload.CouponCode, transaction); err != nil {
+		return nil, err
+	}
+
+	parcel := &entity.Parcel{
+		WeightGrams: product.ChargeableWeight() * order.Qty,
+		Subtotal:    unitPrice.Mul(order.Qty),
+	}
+
+	shipping, err := ts.ss.Select(transaction.ShippingAddress, parcel, payload.ShippingMethodId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transaction.ShippingMethod = shipping
+	transaction.ShippingTotal = shipping.Cost
+
+	// tax is worked out on the discounted lines
+	taxLines, err := ts.tc.Calculate(transaction.ShippingAddress, transaction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// shipping and tax are looked up in their own tables, one that isn't
+	// kept in the order currency mustn't reach the totals
+	transaction.TaxLines = taxLines
+
+	if !transaction.InCurrency() {
+		log.Printf("shipping or tax for order #%d isn't in %s", order.Id, transaction.Currency)
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	transaction.ApplyTaxLines(taxLines)
+	transaction.CalculateTotals()
+
+	intent, err := ts.pp.CreateIntent(transaction.GrandTotal, fmt.Sprintf("order_%d", order.Id))
+
+	if err != nil {
+		return nil, err
+	}
+
+	transaction, err = ts.tr.Add(transaction, &entity.Payment{
+		Provider: ts.pp.Name(),
+		IntentId: intent.Id,
+		Amount:   intent.Amount,
+		Status:   entity.PaymentStatusPending,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ts.nj.Queue(&entity.Notification{
+		Kind:          entity.NotificationOrderPlaced,
+		UserId:        userId,
+		TransactionId: transaction.Id,
+		Data:          transaction,
+	})
+
 	return &helper.ResponseBody{
 		Status:  http.StatusCreated,
-		Message: "transaction successfully added",
-		Data:    nil,
+		Message: "transaction successfully added, waiting for payment",
+		Data: &dto.TransactionPaymentData{
+			TransactionId: transaction.Id,
+			Status:        transaction.Status,
+			Provider:      ts.pp.Name(),
+			IntentId:      intent.Id,
+			ClientSecret:  intent.ClientSecret,
+			Amount:        intent.Amount,
+			Discount:      transaction.DiscountTotal,
+			Tax:           transaction.TaxTotal,
+			Shipping:      transaction.ShippingTotal,
+		},
 	}, nil
 }
 
@@ -90,9 +247,22 @@ func (ts *transactionService) Authorization(next http.Handler) http.Handler {
 }
 
 // CustomersTransaction implements TransactionService.
-func (ts *transactionService) CustomersTransaction(userId int) (*helper.ResponseBody, exception.Exception) {
+func (ts *transactionService) CustomersTransaction(userId int, query *dto.HistoryQuery) (*helper.ResponseBody, exception.Exception) {
 
-	data, err := ts.tr.CustomerTransaction(userId)
+	switch query.Status {
+	case "", entity.TransactionStatusPending, entity.TransactionStatusPaid, entity.TransactionStatusFailed, entity.TransactionStatusRefunded, entity.TransactionStatusCancelled:
+	default:
+		return nil, exception.NewBadRequestError("status must be pending, paid, failed, refunded or cancelled")
+	}
+
+	data, total, err := ts.tr.CustomerTransaction(&transaction_repo.TransactionFilter{
+		UserId: userId,
+		From:   query.From,
+		To:     query.To,
+		Status: query.Status,
+		Limit:  query.Limit,
+		Offset: (query.Page - 1) * query.Limit,
+	})
 
 	if err != nil {
 		return nil, err
@@ -101,23 +271,43 @@ func (ts *transactionService) CustomersTransaction(userId int) (*helper.Response
 	return &helper.ResponseBody{
 		Status:  http.StatusOK,
 		Message: "transaction successfully fetched",
-		Data:    data,
+		Data: &transaction_repo.CustomerTransactionPage{
+			Transactions: data,
+			Pagination:   dto.NewPageInfo(query.Page, query.Limit, total),
+		},
 	}, nil
 }
 
 // FetchAllTransaction implements TransactionService.
-func (ts *transactionService) FetchAllTransaction() (*helper.ResponseBody, exception.Exception) {
+func (ts *transactionService) FetchAllTransaction(query *dto.TransactionQuery) (*helper.ResponseBody, exception.Exception) {
 
-	data, err := ts.tr.FetchTransactions()
+	filter, err := transactionFilter(query)
 
 	if err != nil {
 		return nil, err
 	}
 
+	// one extra transaction tells whether there is a next page
+	filter.Limit = query.Limit + 1
+
+	data, err := ts.tr.FetchTransactions(filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, more := firstTransactions(data, query.Limit)
+
+	page := &transaction_repo.TransactionPage{Transactions: transactions}
+
+	if more {
+		page.NextCursor = encodeTransactionCursor(transactions[len(transactions)-1])
+	}
+
 	return &helper.ResponseBody{
 		Status:  http.StatusOK,
 		Message: "transaction successfully fetched",
-		Data:    data,
+		Data:    page,
 	}, nil
 }
 
@@ -21,6 +21,8 @@ type UserHandler interface {
 	Modify(w http.ResponseWriter, r *http.Request)
 	Profile(w http.ResponseWriter, r *http.Request)
 	ChangePassword(w http.ResponseWriter, r *http.Request)
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
 }
 
 func NewUserHandler(us user_service.UserService) UserHandler {
@@ -185,3 +187,71 @@ func (uh *userHandler) SignUp(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(res.Status)
 	w.Write(helper.ResponseJSON(res))
 }
+
+// ForgotPassword implements UserHandler.
+func (uh *userHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	payload := &dto.UserForgotPasswordPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		invalidBodyRequest := exception.NewUnprocessableEntityError("invalid JSON body request")
+
+		w.WriteHeader(invalidBodyRequest.Status())
+		w.Write(helper.ResponseJSON(invalidBodyRequest))
+
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := uh.us.ForgotPassword(payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
+
+// ResetPassword implements UserHandler.
+func (uh *userHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+
+	w.Header().Set("Content-Type", "application/json")
+
+	payload := &dto.UserResetPasswordPayload{}
+
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		invalidBodyRequest := exception.NewUnprocessableEntityError("invalid JSON body request")
+
+		w.WriteHeader(invalidBodyRequest.Status())
+		w.Write(helper.ResponseJSON(invalidBodyRequest))
+
+		return
+	}
+
+	if err := helper.ValidateStruct(payload); err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	res, err := uh.us.ResetPassword(payload)
+
+	if err != nil {
+		w.WriteHeader(err.Status())
+		w.Write(helper.ResponseJSON(err))
+		return
+	}
+
+	w.WriteHeader(res.Status)
+	w.Write(helper.ResponseJSON(res))
+}
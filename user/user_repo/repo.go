@@ -3,6 +3,7 @@ package user_repo
 import (
 	"fashion-api/entity"
 	"fashion-api/pkg/exception"
+	"time"
 )
 
 type UserRepository interface {
@@ -11,4 +12,6 @@ type UserRepository interface {
 	FetchByEmail(email string) (*entity.User, exception.Exception)
 	Modify(id int, user *entity.User) exception.Exception
 	ChangePassword(id int, user *entity.User) exception.Exception
+	AddPasswordReset(userId int, tokenHash string, expiresAt time.Time) exception.Exception
+	ResetPassword(tokenHash string, password string) (int, exception.Exception)
 }
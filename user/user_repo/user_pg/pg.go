@@ -6,6 +6,7 @@ import (
 	"fashion-api/pkg/exception"
 	"fashion-api/user/user_repo"
 	"log"
+	"time"
 )
 
 type userPg struct {
@@ -13,15 +14,23 @@ type userPg struct {
 }
 
 const (
-	addUserQuery = `insert into "user" (full_name, email, password, role) values($1, $2, $3, 'customers')`
+	addUserQuery = `insert into "user" (full_name, email, password, role, locale) values($1, $2, $3, 'customers', $4) returning id`
 
-	modifyUserQuery = `update "user" set full_name = $2, email = $3, address = $4, updated_at = now() where id = $1`
+	// an empty locale keeps the current one
+	modifyUserQuery = `update "user" set full_name = $2, email = $3, address = $4, locale = coalesce(nullif($5, ''), locale), updated_at = now() where id = $1`
 
 	changePasswordQuery = `update "user" set password = $2, updated_at = now() where id = $1`
 
-	fetchUserByEmailQuery = `select id, full_name, email, password, role, address, created_at, updated_at from "user" where email = $1`
+	fetchUserByEmailQuery = `select id, full_name, email, password, role, address, locale, created_at, updated_at from "user" where email = $1`
 
-	fetchUserByIdQuery = `select id, full_name, email, password, role, address, created_at, updated_at from "user" where id = $1`
+	fetchUserByIdQuery = `select id, full_name, email, password, role, address, locale, created_at, updated_at from "user" where id = $1`
+
+	addPasswordResetQuery = `insert into password_reset (user_id, token_hash, expires_at) values ($1, $2, $3)`
+
+	usePasswordResetQuery = `update password_reset set used_at = now() where token_hash = $1 and used_at is null and expires_at > now() returning user_id`
+
+	// the other links of the user stop working once one of them is used
+	expirePasswordResetsQuery = `update password_reset set used_at = now() where user_id = $1 and used_at is null`
 )
 
 func NewUserPg(db *sql.DB) user_repo.UserRepository {
@@ -48,11 +57,12 @@ func (pg *userPg) Add(user *entity.User) exception.Exception {
 		return exception.NewInternalServerError("something went wrong")
 	}
 
-	if _, err := stmt.Exec(
+	if err := stmt.QueryRow(
 		user.FullName,
 		user.Email,
 		user.Password,
-	); err != nil {
+		user.Locale,
+	).Scan(&user.Id); err != nil {
 		if err.Error() == `pq: duplicate key value violates unique constraint "user_email_key"` {
 			tx.Rollback()
 			log.Println(err.Error())
@@ -123,6 +133,7 @@ func (pg *userPg) FetchByEmail(email string) (*entity.User, exception.Exception)
 		&user.Password,
 		&user.Role,
 		&user.Address,
+		&user.Locale,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	); err != nil {
@@ -142,6 +153,7 @@ func (pg *userPg) FetchByEmail(email string) (*entity.User, exception.Exception)
 		Password:  user.Password,
 		Role:      user.Role,
 		Address:   user.Address.String,
+		Locale:    user.Locale,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}, nil
@@ -161,6 +173,7 @@ func (pg *userPg) FetchById(id int) (*entity.User, exception.Exception) {
 		&user.Password,
 		&user.Role,
 		&user.Address,
+		&user.Locale,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	); err != nil {
@@ -180,6 +193,7 @@ func (pg *userPg) FetchById(id int) (*entity.User, exception.Exception) {
 		Password:  user.Password,
 		Role:      user.Role,
 		Address:   user.Address.String,
+		Locale:    user.Locale,
 		CreatedAt: user.CreatedAt,
 		UpdatedAt: user.UpdatedAt,
 	}, nil
@@ -208,6 +222,7 @@ func (pg *userPg) Modify(id int, user *entity.User) exception.Exception {
 		user.FullName,
 		user.Email,
 		user.Address,
+		user.Locale,
 	); err != nil {
 		tx.Rollback()
 		log.Println(err.Error())
@@ -222,3 +237,58 @@ func (pg *userPg) Modify(id int, user *entity.User) exception.Exception {
 
 	return nil
 }
+
+// AddPasswordReset implements user_repo.UserRepository.
+func (pg *userPg) AddPasswordReset(userId int, tokenHash string, expiresAt time.Time) exception.Exception {
+
+	if _, err := pg.db.Exec(addPasswordResetQuery, userId, tokenHash, expiresAt); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// ResetPassword implements user_repo.UserRepository.
+func (pg *userPg) ResetPassword(tokenHash string, password string) (int, exception.Exception) {
+
+	tx, err := pg.db.Begin()
+
+	if err != nil {
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	var userId int
+
+	if err := tx.QueryRow(usePasswordResetQuery, tokenHash).Scan(&userId); err != nil {
+		tx.Rollback()
+
+		if err == sql.ErrNoRows {
+			return 0, exception.NewBadRequestError("reset token is invalid or has expired")
+		}
+
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(changePasswordQuery, userId, password); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	if _, err := tx.Exec(expirePasswordResetsQuery, userId); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	if err := tx.Commit(); err != nil {
+		tx.Rollback()
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return userId, nil
+}
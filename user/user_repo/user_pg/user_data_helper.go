@@ -12,6 +12,7 @@ type userData struct {
 	Password  string         `json:"password"`
 	Role      string         `json:"role"`
 	Address   sql.NullString `json:"address"`
+	Locale    string         `json:"locale"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 }
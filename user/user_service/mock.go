@@ -14,6 +14,8 @@ var (
 	Authentication func(next http.Handler) http.Handler
 	Authorization  func(next http.Handler) http.Handler
 	ChangePassword func(id int, payload *dto.UserChangePasswordPayload) (*helper.ResponseBody, exception.Exception)
+	ForgotPassword func(payload *dto.UserForgotPasswordPayload) (*helper.ResponseBody, exception.Exception)
+	ResetPassword  func(payload *dto.UserResetPasswordPayload) (*helper.ResponseBody, exception.Exception)
 	Modify         func(id int, payload *dto.UserModifyPayload) (*helper.ResponseBody, exception.Exception)
 	Profile        func(id int) (*helper.ResponseBody, exception.Exception)
 	SignIn         func(payload *dto.UserSignInPayload) (*helper.ResponseBody, exception.Exception)
@@ -35,6 +37,16 @@ func (s *serviceMock) ChangePassword(id int, payload *dto.UserChangePasswordPayl
 	return ChangePassword(id, payload)
 }
 
+// ForgotPassword implements UserService.
+func (s *serviceMock) ForgotPassword(payload *dto.UserForgotPasswordPayload) (*helper.ResponseBody, exception.Exception) {
+	return ForgotPassword(payload)
+}
+
+// ResetPassword implements UserService.
+func (s *serviceMock) ResetPassword(payload *dto.UserResetPasswordPayload) (*helper.ResponseBody, exception.Exception) {
+	return ResetPassword(payload)
+}
+
 // Modify implements UserService.
 func (s *serviceMock) Modify(id int, payload *dto.UserModifyPayload) (*helper.ResponseBody, exception.Exception) {
 	return Modify(id, payload)
@@ -3,19 +3,29 @@ package user_service
 import (
 	"fashion-api/dto"
 	"fashion-api/entity"
+	"fashion-api/infra/config"
 	"fashion-api/model"
+	"fashion-api/notification/notification_job"
 	"fashion-api/pkg/exception"
 	"fashion-api/pkg/helper"
 	"fashion-api/user/user_repo"
+	"strings"
 	"sync"
+	"time"
 
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
 	"net/http"
 )
 
 type userService struct {
 	ur user_repo.UserRepository
 	wg *sync.WaitGroup
+	nj notification_job.NotificationJob
 }
 
 type UserService interface {
@@ -24,14 +34,17 @@ type UserService interface {
 	Modify(id int, payload *dto.UserModifyPayload) (*helper.ResponseBody, exception.Exception)
 	ChangePassword(id int, payload *dto.UserChangePasswordPayload) (*helper.ResponseBody, exception.Exception)
 	Profile(id int) (*helper.ResponseBody, exception.Exception)
+	ForgotPassword(payload *dto.UserForgotPasswordPayload) (*helper.ResponseBody, exception.Exception)
+	ResetPassword(payload *dto.UserResetPasswordPayload) (*helper.ResponseBody, exception.Exception)
 	Authentication(next http.Handler) http.Handler
 	Authorization(next http.Handler) http.Handler
 }
 
-func NewUserService(ur user_repo.UserRepository, wg *sync.WaitGroup) UserService {
+func NewUserService(ur user_repo.UserRepository, wg *sync.WaitGroup, nj notification_job.NotificationJob) UserService {
 	return &userService{
 		ur: ur,
 		wg: wg,
+		nj: nj,
 	}
 }
 
@@ -151,12 +164,19 @@ func (us *userService) ChangePassword(id int, payload *dto.UserChangePasswordPay
 // Modify implements UserService.
 func (us *userService) Modify(id int, payload *dto.UserModifyPayload) (*helper.ResponseBody, exception.Exception) {
 
+	locale := strings.ToLower(payload.Locale)
+
+	if locale != "" && !supportedLocale(locale) {
+		return nil, exception.NewBadRequestError("locale must be en or id")
+	}
+
 	errCh := make(chan exception.Exception, 1)
 
 	user := &entity.User{
 		FullName: payload.FullName,
 		Email:    payload.Email,
 		Address:  payload.Address,
+		Locale:   locale,
 	}
 
 	us.wg.Add(1)
@@ -222,6 +242,7 @@ func (us *userService) Profile(id int) (*helper.ResponseBody, exception.Exceptio
 				Email:     user.Email,
 				Address:   user.Address,
 				Role:      user.Role,
+				Locale:    user.Locale,
 				CreatedAt: user.CreatedAt,
 				UpdatedAt: user.UpdatedAt,
 			},
@@ -280,6 +301,15 @@ func (us *userService) SignUp(payload *dto.UserSignUpPayload) (*helper.ResponseB
 		FullName: payload.FullName,
 		Email:    payload.Email,
 		Password: payload.Password,
+		Locale:   strings.ToLower(payload.Locale),
+	}
+
+	if user.Locale == "" {
+		user.Locale = config.NewAppConfig().DefaultLocale
+	}
+
+	if !supportedLocale(user.Locale) {
+		return nil, exception.NewBadRequestError("locale must be en or id")
 	}
 
 	user.GenerateHashPassword()
@@ -304,6 +334,11 @@ func (us *userService) SignUp(payload *dto.UserSignUpPayload) (*helper.ResponseB
 	case err := <-chErr:
 		return nil, err
 	default:
+		us.nj.Queue(&entity.Notification{
+			Kind:   entity.NotificationSignUp,
+			UserId: user.Id,
+		})
+
 		return &helper.ResponseBody{
 			Status:  http.StatusCreated,
 			Message: "user successfully sign up",
@@ -311,3 +346,83 @@ func (us *userService) SignUp(payload *dto.UserSignUpPayload) (*helper.ResponseB
 		}, nil
 	}
 }
+
+// ForgotPassword implements UserService. The response is the same whether
+// or not the email is registered, so it can't be used to look up accounts.
+func (us *userService) ForgotPassword(payload *dto.UserForgotPasswordPayload) (*helper.ResponseBody, exception.Exception) {
+
+	res := &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "if the email is registered, a password reset link has been sent to it",
+		Data:    nil,
+	}
+
+	user, err := us.ur.FetchByEmail(payload.Email)
+
+	if err != nil && err.Status() == http.StatusNotFound {
+		return res, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+
+	if _, err := rand.Read(secret); err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	reset := &entity.PasswordReset{
+		Token:     base64.RawURLEncoding.EncodeToString(secret),
+		ExpiresAt: time.Now().Add(config.NewAppConfig().PasswordResetTTL),
+	}
+
+	if err := us.ur.AddPasswordReset(user.Id, hashResetToken(reset.Token), reset.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	us.nj.Queue(&entity.Notification{
+		Kind:   entity.NotificationPasswordReset,
+		UserId: user.Id,
+		Data:   reset,
+	})
+
+	return res, nil
+}
+
+// ResetPassword implements UserService.
+func (us *userService) ResetPassword(payload *dto.UserResetPasswordPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if payload.NewPassword != payload.ConfirmNewPassword {
+		return nil, exception.NewBadRequestError("password didn't match")
+	}
+
+	u := &entity.User{
+		Password: payload.NewPassword,
+	}
+
+	u.GenerateHashPassword()
+
+	if _, err := us.ur.ResetPassword(hashResetToken(payload.Token), u.Password); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "password successfully reset",
+		Data:    nil,
+	}, nil
+}
+
+// hashResetToken is what is stored of a reset token, a leaked table can't
+// be used to reset passwords.
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func supportedLocale(locale string) bool {
+	return locale == entity.LocaleEnglish || locale == entity.LocaleIndonesian
+}
@@ -0,0 +1,94 @@
+package wishlist_job
+
+import (
+	"fashion-api/entity"
+	"fashion-api/notification/notification_job"
+	"fashion-api/product/product_repo"
+	"fashion-api/wishlist/wishlist_repo"
+	"log"
+)
+
+const backInStockQueueSize = 100
+
+type backInStockJob struct {
+	wr    wishlist_repo.WishlistRepo
+	pr    product_repo.ProductRepo
+	nj    notification_job.NotificationJob
+	queue chan int
+}
+
+// BackInStockJob notifies the customers waiting on a product once it can
+// be bought again. Queue is safe to call for any stock increase, products
+// that are still unavailable or have no subscribers are skipped.
+type BackInStockJob interface {
+	Queue(productId int)
+	Run()
+}
+
+func NewBackInStockJob(wr wishlist_repo.WishlistRepo, pr product_repo.ProductRepo, nj notification_job.NotificationJob) BackInStockJob {
+	return &backInStockJob{
+		wr:    wr,
+		pr:    pr,
+		nj:    nj,
+		queue: make(chan int, backInStockQueueSize),
+	}
+}
+
+// Queue implements BackInStockJob.
+func (j *backInStockJob) Queue(productId int) {
+	// a full queue must not hold up the request that restocked the product,
+	// a dropped product is picked up again on its next restock since its
+	// subscriptions stay pending
+	select {
+	case j.queue <- productId:
+	default:
+		log.Printf("back in stock queue is full, product #%d dropped", productId)
+	}
+}
+
+// Run implements BackInStockJob.
+func (j *backInStockJob) Run() {
+	for productId := range j.queue {
+		j.notify(productId)
+	}
+}
+
+func (j *backInStockJob) notify(productId int) {
+
+	available, err := j.wr.FetchAvailableStock(productId)
+
+	if err != nil || available <= 0 {
+		return
+	}
+
+	subscriptions, err := j.wr.FetchPendingSubscriptions(productId)
+
+	if err != nil || len(subscriptions) == 0 {
+		return
+	}
+
+	product, err := j.pr.FetchById(productId)
+
+	if err != nil {
+		return
+	}
+
+	for _, subscription := range subscriptions {
+
+		// the outbox retries the delivery, so the subscription is done once
+		// the email is queued
+		j.nj.Queue(&entity.Notification{
+			Kind:   entity.NotificationBackInStock,
+			UserId: subscription.UserId,
+			Data: &entity.BackInStock{
+				ProductId: product.Id,
+				Name:      product.Name,
+				Slug:      product.Slug,
+			},
+		})
+
+		if err := j.wr.MarkNotified(subscription.Id); err != nil {
+			log.Println(err.Message())
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package wishlist_repo
+
+import (
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+)
+
+type WishlistRepo interface {
+	Add(userId int, productId int) exception.Exception
+	Fetch(userId int) ([]*entity.WishlistItem, exception.Exception)
+	Remove(userId int, productId int) exception.Exception
+	Subscribe(userId int, productId int) exception.Exception
+	Unsubscribe(userId int, productId int) exception.Exception
+	FetchAvailableStock(productId int) (int, exception.Exception)
+	FetchPendingSubscriptions(productId int) ([]*entity.StockSubscription, exception.Exception)
+	MarkNotified(id int) exception.Exception
+}
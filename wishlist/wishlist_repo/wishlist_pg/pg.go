@@ -0,0 +1,196 @@
+package wishlist_pg
+
+import (
+	"database/sql"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/wishlist/wishlist_repo"
+	"log"
+)
+
+type wishlistPg struct {
+	db *sql.DB
+}
+
+const (
+	addWishlistQuery = `insert into wishlist (user_id, product_id) values ($1, $2) on conflict do nothing`
+
+	// products deleted since they were saved drop out of the wishlist
+	fetchWishlistQuery = `select w.product_id, w.created_at from wishlist as w join product as p on w.product_id = p.id where w.user_id = $1 and p.deleted_at is null order by w.created_at desc`
+
+	removeWishlistQuery = `delete from wishlist where user_id = $1 and product_id = $2`
+
+	// subscribing again after a notification waits for the next restock
+	subscribeQuery = `insert into stock_subscription (user_id, product_id) values ($1, $2) on conflict (user_id, product_id) do update set notified_at = null, created_at = now()`
+
+	unsubscribeQuery = `delete from stock_subscription where user_id = $1 and product_id = $2 and notified_at is null`
+
+	fetchAvailableStockQuery = `select stock - reserved from product where id = $1 and deleted_at is null`
+
+	fetchPendingSubscriptionsQuery = `select s.id, s.user_id, s.product_id, u.email, u.full_name, s.notified_at, s.created_at from stock_subscription as s join "user" as u on s.user_id = u.id where s.product_id = $1 and s.notified_at is null order by s.created_at asc`
+
+	markNotifiedQuery = `update stock_subscription set notified_at = now() where id = $1`
+)
+
+func NewWishlistPg(db *sql.DB) wishlist_repo.WishlistRepo {
+	return &wishlistPg{
+		db: db,
+	}
+}
+
+// Add implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) Add(userId int, productId int) exception.Exception {
+
+	if _, err := pg.db.Exec(addWishlistQuery, userId, productId); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Fetch implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) Fetch(userId int) ([]*entity.WishlistItem, exception.Exception) {
+
+	items := []*entity.WishlistItem{}
+
+	rows, err := pg.db.Query(fetchWishlistQuery, userId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		item := entity.WishlistItem{}
+
+		if err := rows.Scan(&item.ProductId, &item.AddedAt); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		items = append(items, &item)
+	}
+
+	return items, nil
+}
+
+// Remove implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) Remove(userId int, productId int) exception.Exception {
+
+	res, err := pg.db.Exec(removeWishlistQuery, userId, productId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return exception.NewNotFoundError("product is not in your wishlist")
+	}
+
+	return nil
+}
+
+// Subscribe implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) Subscribe(userId int, productId int) exception.Exception {
+
+	if _, err := pg.db.Exec(subscribeQuery, userId, productId); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
+
+// Unsubscribe implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) Unsubscribe(userId int, productId int) exception.Exception {
+
+	res, err := pg.db.Exec(unsubscribeQuery, userId, productId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return exception.NewNotFoundError("you're not subscribed to this product")
+	}
+
+	return nil
+}
+
+// FetchAvailableStock implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) FetchAvailableStock(productId int) (int, exception.Exception) {
+
+	available := 0
+
+	if err := pg.db.QueryRow(fetchAvailableStockQuery, productId).Scan(&available); err != nil {
+
+		if err == sql.ErrNoRows {
+			log.Println(err.Error())
+			return 0, exception.NewNotFoundError("product not found")
+		}
+
+		log.Println(err.Error())
+		return 0, exception.NewInternalServerError("something went wrong")
+	}
+
+	return available, nil
+}
+
+// FetchPendingSubscriptions implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) FetchPendingSubscriptions(productId int) ([]*entity.StockSubscription, exception.Exception) {
+
+	subscriptions := []*entity.StockSubscription{}
+
+	rows, err := pg.db.Query(fetchPendingSubscriptionsQuery, productId)
+
+	if err != nil {
+		log.Println(err.Error())
+		return nil, exception.NewInternalServerError("something went wrong")
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+
+		subscription := entity.StockSubscription{}
+		notifiedAt := sql.NullTime{}
+
+		if err := rows.Scan(
+			&subscription.Id,
+			&subscription.UserId,
+			&subscription.ProductId,
+			&subscription.Email,
+			&subscription.FullName,
+			&notifiedAt,
+			&subscription.CreatedAt,
+		); err != nil {
+			log.Println(err.Error())
+			return nil, exception.NewInternalServerError("something went wrong")
+		}
+
+		if notifiedAt.Valid {
+			subscription.NotifiedAt = &notifiedAt.Time
+		}
+
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	return subscriptions, nil
+}
+
+// MarkNotified implements wishlist_repo.WishlistRepo.
+func (pg *wishlistPg) MarkNotified(id int) exception.Exception {
+
+	if _, err := pg.db.Exec(markNotifiedQuery, id); err != nil {
+		log.Println(err.Error())
+		return exception.NewInternalServerError("something went wrong")
+	}
+
+	return nil
+}
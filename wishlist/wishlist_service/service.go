@@ -0,0 +1,135 @@
+package wishlist_service
+
+import (
+	"fashion-api/currency/currency_service"
+	"fashion-api/dto"
+	"fashion-api/entity"
+	"fashion-api/pkg/exception"
+	"fashion-api/pkg/helper"
+	"fashion-api/product/product_repo"
+	"fashion-api/wishlist/wishlist_repo"
+	"net/http"
+)
+
+type wishlistService struct {
+	wr wishlist_repo.WishlistRepo
+	pr product_repo.ProductRepo
+	cs currency_service.CurrencyService
+}
+
+type WishlistService interface {
+	Fetch(userId int, currency string) (*helper.ResponseBody, exception.Exception)
+	Add(userId int, payload *dto.AddWishlistPayload) (*helper.ResponseBody, exception.Exception)
+	Remove(userId int, productId int) (*helper.ResponseBody, exception.Exception)
+	Subscribe(userId int, productId int) (*helper.ResponseBody, exception.Exception)
+	Unsubscribe(userId int, productId int) (*helper.ResponseBody, exception.Exception)
+}
+
+func NewWishlistService(wr wishlist_repo.WishlistRepo, pr product_repo.ProductRepo, cs currency_service.CurrencyService) WishlistService {
+	return &wishlistService{
+		wr: wr,
+		pr: pr,
+		cs: cs,
+	}
+}
+
+// Fetch implements WishlistService.
+func (ws *wishlistService) Fetch(userId int, currency string) (*helper.ResponseBody, exception.Exception) {
+
+	items, err := ws.wr.Fetch(userId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	products := []*entity.Product{}
+
+	for _, item := range items {
+
+		if item.Product, err = ws.pr.FetchById(item.ProductId); err != nil {
+			return nil, err
+		}
+
+		products = append(products, item.Product)
+	}
+
+	if err := ws.cs.LocalizeAll(products, currency); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "wishlist successfully fetched",
+		Data:    items,
+	}, nil
+}
+
+// Add implements WishlistService.
+func (ws *wishlistService) Add(userId int, payload *dto.AddWishlistPayload) (*helper.ResponseBody, exception.Exception) {
+
+	if _, err := ws.pr.FetchById(payload.ProductId); err != nil {
+		return nil, err
+	}
+
+	if err := ws.wr.Add(userId, payload.ProductId); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "product successfully added to wishlist",
+		Data:    nil,
+	}, nil
+}
+
+// Remove implements WishlistService.
+func (ws *wishlistService) Remove(userId int, productId int) (*helper.ResponseBody, exception.Exception) {
+
+	if err := ws.wr.Remove(userId, productId); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "product successfully removed from wishlist",
+		Data:    nil,
+	}, nil
+}
+
+// Subscribe implements WishlistService.
+func (ws *wishlistService) Subscribe(userId int, productId int) (*helper.ResponseBody, exception.Exception) {
+
+	available, err := ws.wr.FetchAvailableStock(productId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if available > 0 {
+		return nil, exception.NewBadRequestError("product is in stock")
+	}
+
+	if err := ws.wr.Subscribe(userId, productId); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusCreated,
+		Message: "you will be notified when the product is back in stock",
+		Data:    nil,
+	}, nil
+}
+
+// Unsubscribe implements WishlistService.
+func (ws *wishlistService) Unsubscribe(userId int, productId int) (*helper.ResponseBody, exception.Exception) {
+
+	if err := ws.wr.Unsubscribe(userId, productId); err != nil {
+		return nil, err
+	}
+
+	return &helper.ResponseBody{
+		Status:  http.StatusOK,
+		Message: "back in stock notification successfully cancelled",
+		Data:    nil,
+	}, nil
+}